@@ -0,0 +1,25 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// Structures passed to Windows must match the layout in the Windows SDK for
+// the target architecture. Each of these fails to compile if the size of the
+// structure differs from the one declared in the abi_*.go file, so building
+// for every GOARCH (see README.md) catches layout mistakes.
+var (
+	_ [0]struct{} = [unsafe.Sizeof(win.NOTIFYICONDATA{}) - pSizeofNOTIFYICONDATA]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(win.MENUITEMINFO{}) - pSizeofMENUITEMINFO]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(win.MSG{}) - pSizeofMSG]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(win.WNDCLASSEX{}) - pSizeofWNDCLASSEX]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(pNOTIFYICONIDENTIFIER{}) - pSizeofNOTIFYICONIDENTIFIER]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(pSHSTOCKICONINFO{}) - pSizeofSHSTOCKICONINFO]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(pPROPVARIANT{}) - pSizeofPROPVARIANT]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(pCOPYDATASTRUCT{}) - pSizeofCOPYDATASTRUCT]struct{}{}
+	_ [0]struct{} = [unsafe.Sizeof(win.HIGHCONTRAST{}) - pSizeofHIGHCONTRAST]struct{}{}
+)
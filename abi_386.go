@@ -0,0 +1,40 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// Sizes of structures in the Windows SDK (checked in abi.go)
+const (
+	pSizeofNOTIFYICONDATA       = 956
+	pSizeofMENUITEMINFO         = 48
+	pSizeofMSG                  = 28
+	pSizeofWNDCLASSEX           = 48
+	pSizeofNOTIFYICONIDENTIFIER = 28
+	pSizeofSHSTOCKICONINFO      = 536
+	pSizeofPROPVARIANT          = 16
+	pSizeofCOPYDATASTRUCT       = 12
+	pSizeofHIGHCONTRAST         = 12
+)
+
+// rectArgs returns the arguments for passing a RECT by value; on 386,
+// structures are copied onto the stack one field at a time.
+func rectArgs(rc *win.RECT) []uintptr {
+	return []uintptr{
+		uintptr(rc.Left),
+		uintptr(rc.Top),
+		uintptr(rc.Right),
+		uintptr(rc.Bottom),
+	}
+}
+
+// uint64Args returns the arguments for passing a 64-bit integer, which
+// occupies two stack slots on 386 (low half first).
+func uint64Args(v uint64) []uintptr {
+	return []uintptr{
+		uintptr(uint32(v)),
+		uintptr(uint32(v >> 32)),
+	}
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// Sizes of structures in the Windows SDK (checked in abi.go)
+const (
+	pSizeofNOTIFYICONDATA       = 976
+	pSizeofMENUITEMINFO         = 80
+	pSizeofMSG                  = 48
+	pSizeofWNDCLASSEX           = 80
+	pSizeofNOTIFYICONIDENTIFIER = 40
+	pSizeofSHSTOCKICONINFO      = 544
+	pSizeofPROPVARIANT          = 24
+	pSizeofCOPYDATASTRUCT       = 24
+	pSizeofHIGHCONTRAST         = 16
+)
+
+// rectArgs returns the arguments for passing a RECT by value; on amd64,
+// structures larger than eight bytes are passed by reference.
+func rectArgs(rc *win.RECT) []uintptr {
+	return []uintptr{uintptr(unsafe.Pointer(rc))}
+}
+
+// uint64Args returns the arguments for passing a 64-bit integer.
+func uint64Args(v uint64) []uintptr {
+	return []uintptr{uintptr(v)}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// Sizes of structures in the Windows SDK (checked in abi.go)
+const (
+	pSizeofNOTIFYICONDATA       = 976
+	pSizeofMENUITEMINFO         = 80
+	pSizeofMSG                  = 48
+	pSizeofWNDCLASSEX           = 80
+	pSizeofNOTIFYICONIDENTIFIER = 40
+	pSizeofSHSTOCKICONINFO      = 544
+	pSizeofPROPVARIANT          = 24
+	pSizeofCOPYDATASTRUCT       = 24
+	pSizeofHIGHCONTRAST         = 16
+)
+
+// rectArgs returns the arguments for passing a RECT by value; on arm64,
+// structures of up to sixteen bytes are passed in a pair of registers.
+func rectArgs(rc *win.RECT) []uintptr {
+	return []uintptr{
+		uintptr(uint32(rc.Left)) | uintptr(uint32(rc.Top))<<32,
+		uintptr(uint32(rc.Right)) | uintptr(uint32(rc.Bottom))<<32,
+	}
+}
+
+// uint64Args returns the arguments for passing a 64-bit integer.
+func uint64Args(v uint64) []uintptr {
+	return []uintptr{uintptr(v)}
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+package wintray
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// defaultAccessibleName returns the name given to an icon that has neither
+// a tooltip nor an accessible name, which is the name of the executable.
+func defaultAccessibleName() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	name := filepath.Base(exe)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// shellTip returns the text passed to the shell as the tooltip, which is
+// also the name that screen readers announce for the icon.
+func (w *WinTray) shellTip(text string) string {
+	if w.accessibleName != "" {
+		return w.accessibleName
+	}
+	return text
+}
+
+// SetAccessibleName sets the name that screen readers such as Narrator
+// announce for the icon, independently of the tooltip. Until it is called
+// (or if it is called with ""), the tooltip is used, or the name of the
+// executable if there is no tooltip. Windows uses the same text for both, so
+// the tooltip is displayed by a rich tooltip popup while an accessible name is
+// set; before Windows 7, the accessible name is displayed as the tooltip
+// instead. Names are limited to 127 characters; ErrTruncated is returned for
+// longer names.
+func (w *WinTray) SetAccessibleName(name string) error {
+	name, err := normalizeText("SetAccessibleName", name)
+	if err != nil {
+		return err
+	}
+	return w.invoke(func() error {
+		w.accessibleName = name
+
+		// Update the tooltip, which moves it to or from the popup as needed
+		switch {
+		case w.richTipExplicit:
+			err = w.setTipText("SetAccessibleName", w.tip, true)
+		case w.richTipEnabled:
+			err = w.setTip(w.richTip.text)
+		default:
+			err = w.setTip(w.tip)
+		}
+		if err != nil {
+			return err
+		}
+		if len(utf16.Encode([]rune(name))) >= len(win.NOTIFYICONDATA{}.SzTip) {
+			return newErrorFrom("SetAccessibleName", "name was truncated", ErrTruncated, nil)
+		}
+		return nil
+	})
+}
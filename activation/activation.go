@@ -0,0 +1,207 @@
+package activation
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/nathan-osman/go-wintray"
+)
+
+// Built-in verbs.
+const (
+
+	// VerbNotify shows a notification. The first argument is the text and
+	// the optional second argument is the title.
+	VerbNotify = "notify"
+
+	// VerbShowMenu shows the context menu.
+	VerbShowMenu = "show-menu"
+)
+
+// ErrMissingArgument indicates that a verb was used without a required
+// argument.
+var ErrMissingArgument = errors.New("activation: missing argument")
+
+// Handler processes a verb and the arguments that follow it.
+type Handler func(args []string) error
+
+// URLHandler processes a URL passed as an argument.
+type URLHandler func(u *url.URL) error
+
+// Option configures a Router.
+type Option func(*Router)
+
+// WithErrorHandler registers a function to be invoked when a handler fails
+// while processing the arguments of a second instance. By default, these
+// errors are ignored.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(r *Router) {
+		r.onError = fn
+	}
+}
+
+// Router dispatches verbs and URLs to their handlers.
+type Router struct {
+	w       *wintray.WinTray
+	onError func(err error)
+
+	mutex    sync.Mutex
+	handlers map[string]Handler
+	urls     map[string]URLHandler
+	fallback Handler
+}
+
+// New creates a Router whose built-in verbs control w.
+func New(w *wintray.WinTray, opts ...Option) *Router {
+	r := &Router{
+		w:    w,
+		urls: make(map[string]URLHandler),
+	}
+	r.handlers = map[string]Handler{
+		VerbNotify:   r.notify,
+		VerbShowMenu: r.showMenu,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Router) notify(args []string) error {
+	if len(args) == 0 {
+		return ErrMissingArgument
+	}
+	title := ""
+	if len(args) > 1 {
+		title = args[1]
+	}
+	return r.w.ShowNotification(args[0], title)
+}
+
+func (r *Router) showMenu([]string) error {
+	return r.w.ShowMenu()
+}
+
+// Handle registers a function for a verb (without the leading "--"),
+// replacing the built-in handler if there is one. Passing nil removes the
+// handler.
+func (r *Router) Handle(verb string, fn Handler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if fn == nil {
+		delete(r.handlers, verb)
+	} else {
+		r.handlers[verb] = fn
+	}
+}
+
+// HandleURL registers a function for arguments that are URLs with the
+// specified scheme, such as those opened after calling
+// wintray.RegisterURLScheme. Passing nil removes the handler.
+func (r *Router) HandleURL(scheme string, fn URLHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	scheme = strings.ToLower(scheme)
+	if fn == nil {
+		delete(r.urls, scheme)
+	} else {
+		r.urls[scheme] = fn
+	}
+}
+
+// HandleDefault registers a function for the arguments that do not follow a
+// verb, such as the paths of files opened with the application. If no
+// function is registered, these arguments are ignored.
+func (r *Router) HandleDefault(fn Handler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fallback = fn
+}
+
+// call is a handler along with the arguments collected for it.
+type call struct {
+	fn   func() error
+	args []string
+}
+
+// Dispatch runs the handlers for the verbs and URLs in args in the order in
+// which they appear; the function registered with HandleDefault is run once
+// with all of the arguments that do not follow a verb, in the position of
+// the first of them. Every handler is run even if one fails; the first error
+// is returned. Arguments after "--" are never treated as verbs, which keeps
+// links and files opened through wintray.RegisterURLScheme and
+// wintray.RegisterFileType (which pass the item after "--") from running
+// arbitrary verbs.
+func (r *Router) Dispatch(args []string) error {
+	r.mutex.Lock()
+	var (
+		calls []*call
+		cur   *call
+		rest  []string
+		data  bool
+
+		// The position of the first argument for the fallback in calls
+		restAt int
+	)
+	for _, a := range args {
+		if !data && a == "--" {
+			data = true
+			cur = nil
+			continue
+		}
+		if !data && strings.HasPrefix(a, "--") {
+			name, value, hasValue := strings.Cut(a[2:], "=")
+			if fn, ok := r.handlers[name]; ok {
+				c := &call{}
+				c.fn = func() error { return fn(c.args) }
+				if hasValue {
+					c.args = []string{value}
+				}
+				calls = append(calls, c)
+				cur = c
+				continue
+			}
+		}
+		if u, err := url.Parse(a); err == nil && u.Scheme != "" {
+			if fn, ok := r.urls[strings.ToLower(u.Scheme)]; ok {
+				calls = append(calls, &call{fn: func() error { return fn(u) }})
+				cur = nil
+				continue
+			}
+		}
+		if cur != nil {
+			cur.args = append(cur.args, a)
+		} else {
+			if len(rest) == 0 {
+				restAt = len(calls)
+			}
+			rest = append(rest, a)
+		}
+	}
+	if fn := r.fallback; fn != nil && len(rest) > 0 {
+		calls = append(calls[:restAt], append([]*call{{fn: func() error { return fn(rest) }}}, calls[restAt:]...)...)
+	}
+	r.mutex.Unlock()
+
+	var firstErr error
+	for _, c := range calls {
+		if err := c.fn(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Listen dispatches the arguments of each instance launched after this one,
+// which wintray.EnsureSingleInstance forwards to w. The arguments of the
+// current instance are not dispatched; pass os.Args[1:] to Dispatch for
+// them.
+func (r *Router) Listen() error {
+	return r.w.OnActivate(func(args []string) {
+		if err := r.Dispatch(args); err != nil && r.onError != nil {
+			r.onError(err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package activation_test
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nathan-osman/go-wintray/activation"
+)
+
+func TestDispatchOrder(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			"default first",
+			[]string{"a.txt", "--open", "x", "myapp://one"},
+			[]string{"default a.txt", "open x", "url myapp://one"},
+		},
+		{
+			"default between verb and URL",
+			[]string{"--open", "x", "--", "a.txt", "myapp://one", "b.txt"},
+			[]string{"open x", "default a.txt b.txt", "url myapp://one"},
+		},
+		{
+			"default last",
+			[]string{"myapp://one", "--open", "--", "a.txt"},
+			[]string{"url myapp://one", "open", "default a.txt"},
+		},
+		{
+			"verbs after sentinel",
+			[]string{"--", "--open", "myapp://one"},
+			[]string{"default --open", "url myapp://one"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			r := activation.New(nil)
+			r.Handle("open", func(args []string) error {
+				got = append(got, strings.TrimSpace("open "+strings.Join(args, " ")))
+				return nil
+			})
+			r.HandleURL("myapp", func(u *url.URL) error {
+				got = append(got, fmt.Sprintf("url %s", u))
+				return nil
+			})
+			r.HandleDefault(func(args []string) error {
+				got = append(got, "default "+strings.Join(args, " "))
+				return nil
+			})
+			if err := r.Dispatch(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
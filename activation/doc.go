@@ -0,0 +1,36 @@
+// Package activation routes command-line verbs to handlers, so that the same
+// action can be triggered when the application is launched, when a second
+// instance is launched (and forwards its arguments through
+// wintray.EnsureSingleInstance), or when a link registered with
+// wintray.RegisterURLScheme is opened.
+//
+// Verbs are arguments beginning with "--", and the arguments that follow a
+// verb (up to the next verb) are passed to its handler. A value can also be
+// attached with "=", as in "--notify=Done". The built-in verbs are "notify"
+// (text and an optional title) and "show-menu"; applications can add their
+// own with Router.Handle, and handle URLs with Router.HandleURL. Arguments
+// after "--" are data rather than verbs: they are passed to the URL handlers
+// or to the function registered with Router.HandleDefault.
+//
+// In the application:
+//
+//	if err := wintray.EnsureSingleInstance("MyApp"); errors.Is(err, wintray.ErrAlreadyRunning) {
+//		return
+//	}
+//	w := wintray.New()
+//	r := activation.New(w)
+//	r.Handle("settings", func(args []string) error {
+//		return showSettings()
+//	})
+//	r.HandleURL("myapp", func(u *url.URL) error {
+//		return open(u.Query().Get("id"))
+//	})
+//	if err := r.Listen(); err != nil {
+//		return err
+//	}
+//	r.Dispatch(os.Args[1:])
+//	<-w.Done()
+//
+// Running "MyApp.exe --settings" while the application is running then
+// opens its settings instead of starting another instance.
+package activation
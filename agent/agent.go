@@ -0,0 +1,175 @@
+//go:build windows
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nathan-osman/go-wintray"
+)
+
+const (
+	dialTimeout       = 5 * time.Second
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// ErrNotConnected indicates that the agent is not connected to the service.
+var ErrNotConnected = errors.New("agent: not connected")
+
+// Agent displays a tray icon on behalf of a service, reconnecting whenever
+// the service restarts.
+type Agent struct {
+	w            *wintray.WinTray
+	name         string
+	mutex        sync.Mutex
+	conn         *Conn
+	onMessage    func(*Message)
+	onConnect    func()
+	onDisconnect func()
+}
+
+// New creates an agent that controls w on behalf of the service listening on
+// the named pipe with the specified name.
+func New(w *wintray.WinTray, name string) *Agent {
+	return &Agent{
+		w:    w,
+		name: name,
+	}
+}
+
+// OnMessage registers a function to be invoked for messages that are not
+// handled by the agent itself.
+func (a *Agent) OnMessage(fn func(*Message)) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.onMessage = fn
+}
+
+// OnConnect registers a function to be invoked when a connection to the
+// service is established.
+func (a *Agent) OnConnect(fn func()) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.onConnect = fn
+}
+
+// OnDisconnect registers a function to be invoked when the connection to the
+// service is lost.
+func (a *Agent) OnDisconnect(fn func()) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.onDisconnect = fn
+}
+
+// Send sends a message to the service.
+func (a *Agent) Send(m *Message) error {
+	a.mutex.Lock()
+	c := a.conn
+	a.mutex.Unlock()
+	if c == nil {
+		return ErrNotConnected
+	}
+	return c.Send(m)
+}
+
+// AddCommandItem adds a menu item that sends a MessageCommand with the
+// specified command to the service when selected.
+func (a *Agent) AddCommandItem(text, command string) error {
+	return a.w.AddMenuItem(text, func() {
+		a.Send(&Message{Type: MessageCommand, Text: command})
+	})
+}
+
+// handle processes a single message from the service, returning false if
+// the agent should stop.
+func (a *Agent) handle(m *Message) bool {
+	switch m.Type {
+	case MessageSetTip:
+		a.w.SetTipAsync(m.Text)
+	case MessageNotify:
+		a.w.ShowNotificationAsync(m.Text, m.Title)
+	case MessageQuit:
+		a.w.Close()
+		return false
+	default:
+		a.mutex.Lock()
+		fn := a.onMessage
+		a.mutex.Unlock()
+		if fn != nil {
+			fn(m)
+		}
+	}
+	return true
+}
+
+// serve processes messages until the connection fails or the agent stops.
+func (a *Agent) serve(ctx context.Context, c *Conn) {
+	a.mutex.Lock()
+	a.conn = c
+	onConnect := a.onConnect
+	a.mutex.Unlock()
+	if onConnect != nil {
+		onConnect()
+	}
+
+	// Close the connection if the agent stops in order to interrupt Receive
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-a.w.Done():
+		case <-done:
+		}
+		c.Close()
+	}()
+
+	for {
+		m, err := c.Receive()
+		if err != nil || !a.handle(m) {
+			break
+		}
+	}
+
+	a.mutex.Lock()
+	a.conn = nil
+	onDisconnect := a.onDisconnect
+	a.mutex.Unlock()
+	if onDisconnect != nil {
+		onDisconnect()
+	}
+}
+
+// Run connects to the service and processes messages, reconnecting as
+// necessary, until ctx is cancelled or the icon is closed. The return value
+// is the reason the agent stopped.
+func (a *Agent) Run(ctx context.Context) error {
+	delay := minReconnectDelay
+	for {
+		if c, err := Dial(a.name, dialTimeout); err == nil {
+			a.serve(ctx, c)
+			delay = minReconnectDelay
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.w.Done():
+			return a.w.Err()
+		default:
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.w.Done():
+			return a.w.Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package agent connects a Windows service to a tray icon running in the
+// user's session.
+//
+// Services run in session 0 and cannot create tray icons. Instead, the
+// service listens on a named pipe and a small agent process, started in each
+// user session (for example with wintray.EnableAutostart), connects to it and
+// displays the icon. Messages are exchanged in both directions.
+//
+// The agent only connects to a pipe owned by SYSTEM, so the service must run
+// as LocalSystem; this prevents another user from creating the pipe first
+// and receiving the agent's messages. The service can tell which session and
+// user each agent belongs to with Conn.SessionID and Conn.User.
+//
+// In the service:
+//
+//	s, err := agent.Listen("MyService")
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+//	for {
+//		c, err := s.Accept()
+//		if err != nil {
+//			return err
+//		}
+//		go func() {
+//			defer c.Close()
+//			c.Send(&agent.Message{Type: agent.MessageSetTip, Text: "Running"})
+//			for {
+//				m, err := c.Receive()
+//				if err != nil {
+//					return
+//				}
+//				if m.Type == agent.MessageCommand && m.Text == "restart" {
+//					// Only allow the command for permitted users
+//					if sid, err := c.User(); err == nil && allowed(sid) {
+//						// ...
+//					}
+//				}
+//			}
+//		}()
+//	}
+//
+// In the agent:
+//
+//	w := wintray.New()
+//	a := agent.New(w, "MyService")
+//	a.AddCommandItem("Restart", "restart")
+//	w.AddQuitItem("Quit")
+//	a.Run(context.Background())
+package agent
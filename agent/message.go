@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"encoding/json"
+)
+
+// Message types understood by Agent. Applications may define their own types
+// and handle them with Agent.OnMessage.
+const (
+
+	// MessageSetTip sets the tooltip of the icon to Text.
+	MessageSetTip = "set_tip"
+
+	// MessageNotify shows a notification with Title and Text.
+	MessageNotify = "notify"
+
+	// MessageQuit closes the icon and stops the agent.
+	MessageQuit = "quit"
+
+	// MessageCommand is sent by the agent when a menu item added with
+	// AddCommandItem is selected; Text contains the command.
+	MessageCommand = "command"
+)
+
+// Message is exchanged between the service and the agent. Messages are
+// encoded as JSON, one per line.
+type Message struct {
+	Type  string          `json:"type"`
+	Title string          `json:"title,omitempty"`
+	Text  string          `json:"text,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
@@ -0,0 +1,394 @@
+//go:build windows
+
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+
+	// DefaultSDDL allows SYSTEM and administrators full access to the pipe
+	// and interactive users to read and write it.
+	DefaultSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GRGW;;;IU)"
+
+	// SystemSID is the SID of the LocalSystem account, which Dial requires
+	// to own the pipe.
+	SystemSID = "S-1-5-18"
+
+	pipeBufferSize = 4096
+)
+
+var (
+	// ErrClosed indicates that the server or connection was closed.
+	ErrClosed = errors.New("agent: closed")
+
+	// ErrInUse indicates that another process has already created a pipe
+	// with the same name.
+	ErrInUse = errors.New("agent: pipe name is already in use")
+
+	// ErrUntrustedServer indicates that the pipe is owned by an account other
+	// than the expected one, such as a pipe created by another user before
+	// the service started.
+	ErrUntrustedServer = errors.New("agent: pipe is not owned by the expected account")
+)
+
+var (
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	advapi32 = windows.NewLazySystemDLL("advapi32.dll")
+
+	pGetNamedPipeClientSessionId = kernel32.NewProc("GetNamedPipeClientSessionId")
+	pGetNamedPipeServerProcessId = kernel32.NewProc("GetNamedPipeServerProcessId")
+	pImpersonateNamedPipeClient  = advapi32.NewProc("ImpersonateNamedPipeClient")
+)
+
+// pipePath returns the full path of the named pipe.
+func pipePath(name string) string {
+	return `\\.\pipe\` + name
+}
+
+// Conn is a connection between the service and the agent. Send and Receive
+// may be used concurrently.
+type Conn struct {
+	h          windows.Handle
+	f          *os.File
+	r          *bufio.Reader
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+}
+
+func newConn(h windows.Handle, name string) *Conn {
+	f := os.NewFile(uintptr(h), name)
+	return &Conn{
+		h: h,
+		f: f,
+		r: bufio.NewReader(f),
+	}
+}
+
+// Send writes a message to the other end of the connection.
+func (c *Conn) Send(m *Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	_, err = c.f.Write(append(b, '\n'))
+	return err
+}
+
+// Receive waits for the next message from the other end of the connection.
+func (c *Conn) Receive() (*Message, error) {
+	b, err := c.r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SessionID returns the ID of the Windows session in which the client is
+// running, so that a service can tell which user session an agent belongs
+// to. It is only meaningful for connections returned by Server.Accept.
+func (c *Conn) SessionID() (uint32, error) {
+	var id uint32
+	if r, _, err := pGetNamedPipeClientSessionId.Call(
+		uintptr(c.h),
+		uintptr(unsafe.Pointer(&id)),
+	); r == 0 {
+		return 0, err
+	}
+	return id, nil
+}
+
+// User returns the SID of the user running the client, which the service can
+// use to decide which commands to accept. It is only meaningful for
+// connections returned by Server.Accept, and Windows only allows it once a
+// message has been received from the client.
+func (c *Conn) User() (string, error) {
+	type result struct {
+		sid string
+		err error
+	}
+	ch := make(chan result, 1)
+
+	// The client is impersonated on a thread of its own, which is only
+	// returned to the scheduler once the impersonation has been reverted
+	go func() {
+		runtime.LockOSThread()
+		if r, _, err := pImpersonateNamedPipeClient.Call(uintptr(c.h)); r == 0 {
+			runtime.UnlockOSThread()
+			ch <- result{err: err}
+			return
+		}
+		var t windows.Token
+		err := windows.OpenThreadToken(windows.CurrentThread(), windows.TOKEN_QUERY, true, &t)
+		if err := windows.RevertToSelf(); err != nil {
+			ch <- result{err: err}
+			return
+		}
+		runtime.UnlockOSThread()
+		if err != nil {
+			ch <- result{err: err}
+			return
+		}
+		defer t.Close()
+		user, err := t.GetTokenUser()
+		if err != nil {
+			ch <- result{err: err}
+			return
+		}
+		ch <- result{sid: user.User.Sid.String()}
+	}()
+	r := <-ch
+	return r.sid, r.err
+}
+
+// ServerUser returns the SID of the user running the server process. It is
+// only meaningful for connections returned by Dial or DialAs, and requires
+// permission to query the server process, which a client has when it runs
+// as the same user.
+func (c *Conn) ServerUser() (string, error) {
+	var pid uint32
+	if r, _, err := pGetNamedPipeServerProcessId.Call(
+		uintptr(c.h),
+		uintptr(unsafe.Pointer(&pid)),
+	); r == 0 {
+		return "", err
+	}
+	p, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(p)
+	var t windows.Token
+	if err := windows.OpenProcessToken(p, windows.TOKEN_QUERY, &t); err != nil {
+		return "", err
+	}
+	defer t.Close()
+	user, err := t.GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return user.User.Sid.String(), nil
+}
+
+// Close closes the connection, interrupting any pending Receive.
+func (c *Conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		windows.CancelIoEx(c.h, nil)
+		err = c.f.Close()
+	})
+	return err
+}
+
+// Server accepts connections from agents.
+type Server struct {
+	path      string
+	sa        *windows.SecurityAttributes
+	mutex     sync.Mutex
+	pending   windows.Handle
+	accepting bool
+	closed    bool
+}
+
+// Listen creates a named pipe that agents can connect to using DefaultSDDL.
+func Listen(name string) (*Server, error) {
+	return ListenSDDL(name, DefaultSDDL)
+}
+
+// ListenSDDL creates a named pipe with the specified security descriptor. If
+// the descriptor does not specify an owner, the pipe is owned by the user
+// running the process so that clients can verify it.
+func ListenSDDL(name, sddl string) (*Server, error) {
+	sd, err := windows.SecurityDescriptorFromString(sddl)
+	if err != nil {
+		return nil, err
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil {
+		user, err := windows.GetCurrentProcessToken().GetTokenUser()
+		if err != nil {
+			return nil, err
+		}
+		sd, err = windows.SecurityDescriptorFromString("O:" + user.User.Sid.String() + sddl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := &Server{
+		path: pipePath(name),
+		sa: &windows.SecurityAttributes{
+			SecurityDescriptor: sd,
+		},
+	}
+	s.sa.Length = uint32(unsafe.Sizeof(*s.sa))
+
+	// The first instance is created immediately so that another process
+	// cannot claim the name, and fails if another process already has
+	h, err := s.createInstance(windows.FILE_FLAG_FIRST_PIPE_INSTANCE)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_ACCESS_DENIED) || errors.Is(err, windows.ERROR_PIPE_BUSY) {
+			return nil, ErrInUse
+		}
+		return nil, err
+	}
+	s.pending = h
+	return s, nil
+}
+
+func (s *Server) createInstance(flags uint32) (windows.Handle, error) {
+	p, err := windows.UTF16PtrFromString(s.path)
+	if err != nil {
+		return 0, err
+	}
+	return windows.CreateNamedPipe(
+		p,
+		windows.PIPE_ACCESS_DUPLEX|flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		s.sa,
+	)
+}
+
+// Accept waits for an agent to connect.
+func (s *Server) Accept() (*Conn, error) {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil, ErrClosed
+	}
+	if s.accepting {
+		s.mutex.Unlock()
+		return nil, errors.New("agent: Accept called concurrently")
+	}
+	h := s.pending
+	if h == 0 {
+		var err error
+		if h, err = s.createInstance(0); err != nil {
+			s.mutex.Unlock()
+			return nil, err
+		}
+		s.pending = h
+	}
+	s.accepting = true
+	s.mutex.Unlock()
+
+	err := windows.ConnectNamedPipe(h, nil)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.accepting = false
+	s.pending = 0
+	if s.closed {
+		windows.CloseHandle(h)
+		return nil, ErrClosed
+	}
+	if err != nil && !errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+		windows.CloseHandle(h)
+		return nil, err
+	}
+	return newConn(h, s.path), nil
+}
+
+// Close stops accepting connections. Existing connections are unaffected.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.pending != 0 {
+		if s.accepting {
+			windows.CancelIoEx(s.pending, nil)
+		} else {
+			windows.CloseHandle(s.pending)
+			s.pending = 0
+		}
+	}
+	return nil
+}
+
+// Dial connects to the service, waiting up to timeout for the pipe to become
+// available. The pipe must be owned by SYSTEM, so the service must run as
+// LocalSystem; ErrUntrustedServer is returned otherwise.
+func Dial(name string, timeout time.Duration) (*Conn, error) {
+	return DialAs(name, timeout, SystemSID)
+}
+
+// DialAs is identical to Dial but requires the pipe to be owned by the
+// account with the specified SID instead of SYSTEM.
+func DialAs(name string, timeout time.Duration, owner string) (*Conn, error) {
+	ownerSid, err := windows.StringToSid(owner)
+	if err != nil {
+		return nil, err
+	}
+	path := pipePath(name)
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		h, err := windows.CreateFile(
+			p,
+			windows.GENERIC_READ|windows.GENERIC_WRITE,
+			0,
+			nil,
+			windows.OPEN_EXISTING,
+			windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION,
+			0,
+		)
+		if err == nil {
+			if err := checkOwner(h, ownerSid); err != nil {
+				windows.CloseHandle(h)
+				return nil, err
+			}
+			return newConn(h, path), nil
+		}
+		if !errors.Is(err, windows.ERROR_PIPE_BUSY) || time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// checkOwner returns ErrUntrustedServer unless the pipe is owned by sid.
+// Taking ownership of an object on behalf of another account requires
+// privileges that only administrators have, so a pipe created by another
+// user cannot pass the check.
+func checkOwner(h windows.Handle, sid *windows.SID) error {
+	sd, err := windows.GetSecurityInfo(h, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return err
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return err
+	}
+	if owner == nil || !owner.Equals(sid) {
+		return ErrUntrustedServer
+	}
+	return nil
+}
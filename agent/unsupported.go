@@ -0,0 +1,102 @@
+//go:build !windows
+
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/nathan-osman/go-wintray"
+)
+
+// These stubs allow applications that use this package to compile on
+// platforms other than Windows; every function fails with
+// wintray.ErrUnsupported.
+
+const (
+	DefaultSDDL = ""
+	SystemSID   = "S-1-5-18"
+)
+
+var (
+	ErrClosed          = wintray.ErrUnsupported
+	ErrInUse           = wintray.ErrUnsupported
+	ErrNotConnected    = wintray.ErrUnsupported
+	ErrUntrustedServer = wintray.ErrUnsupported
+)
+
+type Conn struct{}
+
+func (c *Conn) Send(m *Message) error {
+	return wintray.ErrUnsupported
+}
+
+func (c *Conn) Receive() (*Message, error) {
+	return nil, wintray.ErrUnsupported
+}
+
+func (c *Conn) SessionID() (uint32, error) {
+	return 0, wintray.ErrUnsupported
+}
+
+func (c *Conn) User() (string, error) {
+	return "", wintray.ErrUnsupported
+}
+
+func (c *Conn) ServerUser() (string, error) {
+	return "", wintray.ErrUnsupported
+}
+
+func (c *Conn) Close() error {
+	return wintray.ErrUnsupported
+}
+
+type Server struct{}
+
+func Listen(name string) (*Server, error) {
+	return nil, wintray.ErrUnsupported
+}
+
+func ListenSDDL(name, sddl string) (*Server, error) {
+	return nil, wintray.ErrUnsupported
+}
+
+func (s *Server) Accept() (*Conn, error) {
+	return nil, wintray.ErrUnsupported
+}
+
+func (s *Server) Close() error {
+	return wintray.ErrUnsupported
+}
+
+func Dial(name string, timeout time.Duration) (*Conn, error) {
+	return nil, wintray.ErrUnsupported
+}
+
+func DialAs(name string, timeout time.Duration, owner string) (*Conn, error) {
+	return nil, wintray.ErrUnsupported
+}
+
+type Agent struct{}
+
+func New(w *wintray.WinTray, name string) *Agent {
+	return &Agent{}
+}
+
+func (a *Agent) OnMessage(fn func(*Message)) {}
+
+func (a *Agent) OnConnect(fn func()) {}
+
+func (a *Agent) OnDisconnect(fn func()) {}
+
+func (a *Agent) Send(m *Message) error {
+	return wintray.ErrUnsupported
+}
+
+func (a *Agent) AddCommandItem(text, command string) error {
+	return wintray.ErrUnsupported
+}
+
+func (a *Agent) Run(ctx context.Context) error {
+	return wintray.ErrUnsupported
+}
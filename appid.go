@@ -0,0 +1,184 @@
+//go:build windows
+
+package wintray
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	pSetCurrentProcessExplicitAppUserModelID = shell32.MustFindProc("SetCurrentProcessExplicitAppUserModelID")
+	pPropVariantClear                        = ole32.MustFindProc("PropVariantClear")
+
+	pCLSID_ShellLink    = mustGUID("{00021401-0000-0000-C000-000000000046}")
+	pIID_IShellLinkW    = mustGUID("{000214F9-0000-0000-C000-000000000046}")
+	pIID_IPersistFile   = mustGUID("{0000010B-0000-0000-C000-000000000046}")
+	pIID_IPropertyStore = mustGUID("{886D8EEB-8CF2-4446-8D02-CDBA1DBDCF99}")
+
+	pPKEY_AppUserModel_ID = &pPROPERTYKEY{
+		Fmtid: *mustGUID("{9F4C2855-9F79-4B39-A8D0-E1D42DE1D5F3}"),
+		Pid:   5,
+	}
+)
+
+// Vtable indices for IShellLinkW, IPersistFile and IPropertyStore
+const (
+	pIShellLinkW_GetPath = 3
+	pIShellLinkW_SetPath = 20
+
+	pIPersistFile_Load = 5
+	pIPersistFile_Save = 6
+
+	pIPropertyStore_GetValue = 5
+	pIPropertyStore_SetValue = 6
+	pIPropertyStore_Commit   = 7
+)
+
+const (
+	pVT_LPWSTR    = 31
+	pSTGM_READ    = 0x0
+	pSLGP_RAWPATH = 0x4
+)
+
+type pPROPERTYKEY struct {
+	Fmtid windows.GUID
+	Pid   uint32
+}
+
+type pPROPVARIANT struct {
+	Vt       uint16
+	reserved [3]uint16
+	Val      uintptr
+	pad      uintptr
+}
+
+// SetAppID sets the AppUserModelID of the current process, which determines
+// how its windows are grouped on the taskbar and which shortcut its
+// notifications are attributed to. It should be called before any windows
+// are created.
+func SetAppID(id string) error {
+	if err := checkText("SetAppID", id); err != nil {
+		return err
+	}
+	if hr, _, _ := pSetCurrentProcessExplicitAppUserModelID.Call(
+		uintptr(unsafe.Pointer(utf16PtrFromString(id))),
+	); hr != 0 {
+		return newHRESULTError("SetAppID", "unable to set AppUserModelID", nil, hr)
+	}
+	return nil
+}
+
+// readShortcut returns the target and AppUserModelID of a shortcut.
+func readShortcut(link, file, store *pComObject, path string) (string, string, bool) {
+	if file.call(
+		pIPersistFile_Load,
+		uintptr(unsafe.Pointer(utf16PtrFromString(path))),
+		pSTGM_READ,
+	) != 0 {
+		return "", "", false
+	}
+	buf := make([]uint16, windows.MAX_PATH)
+	if link.call(
+		pIShellLinkW_GetPath,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(len(buf)),
+		0,
+		pSLGP_RAWPATH,
+	) != 0 {
+		return "", "", false
+	}
+	var pv pPROPVARIANT
+	if store.call(
+		pIPropertyStore_GetValue,
+		uintptr(unsafe.Pointer(pPKEY_AppUserModel_ID)),
+		uintptr(unsafe.Pointer(&pv)),
+	) != 0 {
+		return "", "", false
+	}
+	defer pPropVariantClear.Call(uintptr(unsafe.Pointer(&pv)))
+	var appID string
+	if pv.Vt == pVT_LPWSTR {
+		appID = windows.UTF16PtrToString((*uint16)(paramPointer(pv.Val)))
+	}
+	return windows.UTF16ToString(buf), appID, true
+}
+
+// EnsureStartMenuShortcut creates a shortcut to the current executable in
+// the user's Start Menu with the specified name and AppUserModelID, or
+// repairs an existing shortcut that points elsewhere or has a different
+// AppUserModelID. A shortcut carrying the AppUserModelID is required for
+// notifications to be attributed to the application.
+func (w *WinTray) EnsureStartMenuShortcut(name, appID string) error {
+	if err := checkText("EnsureStartMenuShortcut", name, appID); err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return newErrorFrom("EnsureStartMenuShortcut", "unable to determine executable", nil, err)
+	}
+	programs, err := windows.KnownFolderPath(windows.FOLDERID_Programs, 0)
+	if err != nil {
+		return newErrorFrom("EnsureStartMenuShortcut", "unable to find Start Menu", nil, err)
+	}
+	path := filepath.Join(programs, name+".lnk")
+	return w.invoke(func() error {
+		if err := w.initCOM(); err != nil {
+			return err
+		}
+		link, err := createInstance("EnsureStartMenuShortcut", pCLSID_ShellLink, pIID_IShellLinkW)
+		if err != nil {
+			return err
+		}
+		defer link.release()
+		file, hr := link.queryInterface(pIID_IPersistFile)
+		if hr != 0 {
+			return newHRESULTError("EnsureStartMenuShortcut", "unable to query IPersistFile", nil, hr)
+		}
+		defer file.release()
+		store, hr := link.queryInterface(pIID_IPropertyStore)
+		if hr != 0 {
+			return newHRESULTError("EnsureStartMenuShortcut", "unable to query IPropertyStore", nil, hr)
+		}
+		defer store.release()
+
+		// Leave the shortcut alone if it is already correct
+		if target, id, ok := readShortcut(link, file, store, path); ok &&
+			strings.EqualFold(target, exe) && id == appID {
+			return nil
+		}
+
+		if hr := link.call(
+			pIShellLinkW_SetPath,
+			uintptr(unsafe.Pointer(utf16PtrFromString(exe))),
+		); hr != 0 {
+			return newHRESULTError("EnsureStartMenuShortcut", "unable to set shortcut target", nil, hr)
+		}
+		pv := pPROPVARIANT{
+			Vt:  pVT_LPWSTR,
+			Val: uintptr(unsafe.Pointer(utf16PtrFromString(appID))),
+		}
+		if hr := store.call(
+			pIPropertyStore_SetValue,
+			uintptr(unsafe.Pointer(pPKEY_AppUserModel_ID)),
+			uintptr(unsafe.Pointer(&pv)),
+		); hr != 0 {
+			return newHRESULTError("EnsureStartMenuShortcut", "unable to set AppUserModelID", nil, hr)
+		}
+		if hr := store.call(pIPropertyStore_Commit); hr != 0 {
+			return newHRESULTError("EnsureStartMenuShortcut", "unable to set AppUserModelID", nil, hr)
+		}
+		if hr := file.call(
+			pIPersistFile_Save,
+			uintptr(unsafe.Pointer(utf16PtrFromString(path))),
+			1,
+		); hr != 0 {
+			return newHRESULTError("EnsureStartMenuShortcut", "unable to save shortcut", nil, hr)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,40 @@
+package wintray
+
+import (
+	"errors"
+	"unicode/utf16"
+)
+
+var errInvalidArgs = errors.New("malformed argument list")
+
+// encodeArgs encodes a list of arguments as UTF-16 for WM_COPYDATA. Each
+// argument is preceded by its length in UTF-16 code units (as two units, low
+// first) so that empty arguments and an empty list survive the trip.
+func encodeArgs(args []string) []uint16 {
+	var data []uint16
+	for _, a := range args {
+		u := utf16.Encode([]rune(a))
+		data = append(data, uint16(len(u)), uint16(len(u)>>16))
+		data = append(data, u...)
+	}
+	return data
+}
+
+// decodeArgs reverses encodeArgs, returning errInvalidArgs if data was not
+// produced by it.
+func decodeArgs(data []uint16) ([]string, error) {
+	args := []string{}
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errInvalidArgs
+		}
+		n := int(data[0]) | int(data[1])<<16
+		data = data[2:]
+		if n > len(data) {
+			return nil, errInvalidArgs
+		}
+		args = append(args, string(utf16.Decode(data[:n])))
+		data = data[n:]
+	}
+	return args, nil
+}
@@ -0,0 +1,41 @@
+package wintray
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{""},
+		{"", "foo"},
+		{"foo", "", ""},
+		{"--notify", "Build finished", "C:\\Users\\me\\file name.txt"},
+		{"\U0001f600", "a\tb"},
+		{strings.Repeat("x", 70000)},
+	} {
+		got, err := decodeArgs(encodeArgs(args))
+		if err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Fatalf("got %q, want %q", got, args)
+		}
+	}
+}
+
+func TestDecodeArgsInvalid(t *testing.T) {
+	for _, data := range [][]uint16{
+		{1},
+		{2, 0, 'a'},
+		{1, 0, 'a', 5},
+		{0, 1},
+	} {
+		if _, err := decodeArgs(data); !errors.Is(err, errInvalidArgs) {
+			t.Errorf("%v: got %v, want errInvalidArgs", data, err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+//go:build windows
+
+package wintray
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	pClassesKey = `Software\Classes\`
+
+	pSHCNE_ASSOCCHANGED = 0x08000000
+	pSHCNF_IDLIST       = 0x0000
+)
+
+var pSHChangeNotify = shell32.MustFindProc("SHChangeNotify")
+
+// openCommand returns the command that opens the item passed as "%1" with the
+// current executable and the specified arguments, along with the icon of the
+// executable. The item follows "--" so that a link or file name beginning
+// with "--" is not mistaken for an option (see activation.Router.Dispatch).
+func openCommand(args []string) (command, icon string, err error) {
+	c, err := autostartCommand(args)
+	if err != nil {
+		return "", "", err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", "", err
+	}
+	return c + ` -- "%1"`, exe + ",0", nil
+}
+
+// writeClass creates the key for a URI scheme or file type under
+// HKCU\Software\Classes with a command that opens it with the current
+// executable.
+func writeClass(op, name, description string, values map[string]string, args []string) error {
+	command, icon, err := openCommand(args)
+	if err != nil {
+		return newErrorFrom(op, "unable to determine executable", nil, err)
+	}
+	for path, value := range map[string]string{
+		"":                    description,
+		`\DefaultIcon`:        icon,
+		`\shell\open\command`: command,
+	} {
+		if err := setClassValue(pClassesKey+name+path, "", value); err != nil {
+			return newErrorFrom(op, "unable to write registry key", nil, err)
+		}
+	}
+	for value, data := range values {
+		if err := setClassValue(pClassesKey+name, value, data); err != nil {
+			return newErrorFrom(op, "unable to write registry key", nil, err)
+		}
+	}
+	return nil
+}
+
+// setClassValue creates the key at path under HKEY_CURRENT_USER if necessary
+// and sets one of its string values.
+func setClassValue(path, name, value string) error {
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, path, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringValue(name, value)
+}
+
+// deleteKeyTree deletes the key at path under HKEY_CURRENT_USER along with
+// all of its subkeys. It is not an error if the key does not exist.
+func deleteKeyTree(path string) error {
+	k, err := registry.OpenKey(registry.CURRENT_USER, path, registry.ENUMERATE_SUB_KEYS)
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	names, err := k.ReadSubKeyNames(-1)
+	k.Close()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := deleteKeyTree(path + `\` + name); err != nil {
+			return err
+		}
+	}
+	if err := registry.DeleteKey(registry.CURRENT_USER, path); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// associationsChanged tells Explorer to reload file associations.
+func associationsChanged() {
+	pSHChangeNotify.Call(pSHCNE_ASSOCCHANGED, pSHCNF_IDLIST, 0, 0)
+}
+
+// RegisterURLScheme makes links using the scheme (such as "myapp" for
+// "myapp://open?id=1") start the current executable with the specified
+// arguments followed by the URL, for the current user only. Combined with
+// EnsureSingleInstance, a link clicked while the application is running is
+// delivered to the function registered with OnActivate; otherwise, it is in
+// os.Args.
+func RegisterURLScheme(scheme, description string, args ...string) error {
+	if err := checkText("RegisterURLScheme", append([]string{scheme, description}, args...)...); err != nil {
+		return err
+	}
+	if scheme == "" || strings.ContainsAny(scheme, `\/:`) {
+		return newErrorFrom("RegisterURLScheme", "invalid scheme", ErrInvalidText, nil)
+	}
+	if err := writeClass(
+		"RegisterURLScheme",
+		scheme,
+		"URL:"+description,
+		map[string]string{"URL Protocol": ""},
+		args,
+	); err != nil {
+		return err
+	}
+	associationsChanged()
+	return nil
+}
+
+// UnregisterURLScheme removes a scheme registered with RegisterURLScheme. It
+// is not an error if the scheme is not registered.
+func UnregisterURLScheme(scheme string) error {
+	if scheme == "" || strings.ContainsAny(scheme, `\/:`) {
+		return newErrorFrom("UnregisterURLScheme", "invalid scheme", ErrInvalidText, nil)
+	}
+	if err := deleteKeyTree(pClassesKey + scheme); err != nil {
+		return newErrorFrom("UnregisterURLScheme", "unable to delete registry key", nil, err)
+	}
+	associationsChanged()
+	return nil
+}
+
+// RegisterFileType associates files with the extension (such as ".myext")
+// with the current executable for the current user, which is started with
+// the specified arguments followed by the path of the file. progID names the
+// file type (such as "MyApp.Document") and must be unique. The application is
+// offered in the "Open with" menu; Windows only opens files with it by
+// default if no other application is associated with the extension or the
+// user chooses it.
+func RegisterFileType(ext, progID, description string, args ...string) error {
+	if err := checkText("RegisterFileType", append([]string{ext, progID, description}, args...)...); err != nil {
+		return err
+	}
+	if err := checkFileType("RegisterFileType", ext, progID); err != nil {
+		return err
+	}
+	if err := writeClass("RegisterFileType", progID, description, nil, args); err != nil {
+		return err
+	}
+	if err := setClassValue(pClassesKey+ext+`\OpenWithProgids`, progID, ""); err != nil {
+		return newErrorFrom("RegisterFileType", "unable to write registry key", nil, err)
+	}
+
+	// Become the default if the extension is not associated with anything
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, pClassesKey+ext, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return newErrorFrom("RegisterFileType", "unable to write registry key", nil, err)
+	}
+	defer k.Close()
+	if current, _, err := k.GetStringValue(""); err != nil || current == "" {
+		if err := k.SetStringValue("", progID); err != nil {
+			return newErrorFrom("RegisterFileType", "unable to write registry key", nil, err)
+		}
+	}
+	associationsChanged()
+	return nil
+}
+
+// UnregisterFileType removes an association created by RegisterFileType. It
+// is not an error if the association does not exist.
+func UnregisterFileType(ext, progID string) error {
+	if err := checkFileType("UnregisterFileType", ext, progID); err != nil {
+		return err
+	}
+	if err := deleteKeyTree(pClassesKey + progID); err != nil {
+		return newErrorFrom("UnregisterFileType", "unable to delete registry key", nil, err)
+	}
+	k, err := registry.OpenKey(registry.CURRENT_USER, pClassesKey+ext, registry.QUERY_VALUE|registry.SET_VALUE)
+	if err == nil {
+		defer k.Close()
+		if current, _, err := k.GetStringValue(""); err == nil && current == progID {
+			k.DeleteValue("")
+		}
+		if p, err := registry.OpenKey(k, "OpenWithProgids", registry.SET_VALUE); err == nil {
+			p.DeleteValue(progID)
+			p.Close()
+		}
+	}
+	associationsChanged()
+	return nil
+}
+
+// checkFileType validates the arguments to RegisterFileType and
+// UnregisterFileType.
+func checkFileType(op, ext, progID string) error {
+	if len(ext) < 2 || ext[0] != '.' || strings.ContainsAny(ext, `\/`) {
+		return newErrorFrom(op, "extension must begin with a period", ErrInvalidText, nil)
+	}
+	if progID == "" || strings.ContainsAny(progID, `\/`) {
+		return newErrorFrom(op, "invalid ProgID", ErrInvalidText, nil)
+	}
+	return nil
+}
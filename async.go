@@ -0,0 +1,51 @@
+package wintray
+
+// The functions in this file are non-blocking variants of the API functions.
+// Each one queues the request for the UI thread and returns immediately with
+// a channel that receives the result once the request has been processed.
+// Unlike the blocking variants, which run immediately when called from the
+// UI thread (for example, from a hook that runs there), they keep their place
+// in the queue, although the result will not be available until control
+// returns to the event loop.
+
+// SetIconFromBytesAsync is the non-blocking variant of SetIconFromBytes.
+func (w *WinTray) SetIconFromBytesAsync(b []byte) <-chan error {
+	return w.post(&pMessage{
+		Type: pMESSAGE_SET_ICON_FROM_BYTES,
+		Data: b,
+	})
+}
+
+// SetTipAsync is the non-blocking variant of SetTip.
+func (w *WinTray) SetTipAsync(text string) <-chan error {
+	return w.post(&pMessage{
+		Type: pMESSAGE_SET_TIP,
+		Data: text,
+	})
+}
+
+// AddMenuItemAsync is the non-blocking variant of AddMenuItem.
+func (w *WinTray) AddMenuItemAsync(text string, fn func()) <-chan error {
+	return w.post(&pMessage{
+		Type: pMESSAGE_ADD_MENU_ITEM,
+		Data: &pDataAddMenuItem{
+			Text: text,
+			Fn:   fn,
+		},
+	})
+}
+
+// AddMenuSeparatorAsync is the non-blocking variant of AddMenuSeparator.
+func (w *WinTray) AddMenuSeparatorAsync() <-chan error {
+	return w.post(&pMessage{
+		Type: pMESSAGE_ADD_MENU_SEPARATOR,
+	})
+}
+
+// ShowNotificationAsync is the non-blocking variant of ShowNotification.
+func (w *WinTray) ShowNotificationAsync(info, infoTitle string, opts ...NotificationOption) <-chan error {
+	return w.post(&pMessage{
+		Type: pMESSAGE_SHOW_NOTIFICATION,
+		Data: newDataShowNotification(info, infoTitle, opts),
+	})
+}
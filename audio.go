@@ -0,0 +1,149 @@
+//go:build windows
+
+package wintray
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	pCLSID_MMDeviceEnumerator  = mustGUID("{BCDE0395-E52F-467C-8E3D-C4579291692E}")
+	pIID_IMMDeviceEnumerator   = mustGUID("{A95664D2-9614-4F35-A746-DE8DB63617E6}")
+	pIID_IMMNotificationClient = mustGUID("{7991EEC9-7E89-4D85-8390-6C703CEC60C0}")
+	pIID_IUnknown              = mustGUID("{00000000-0000-0000-C000-000000000046}")
+)
+
+const (
+	// Vtable indices for IMMDeviceEnumerator
+	pIMMDeviceEnumerator_RegisterEndpointNotificationCallback   = 6
+	pIMMDeviceEnumerator_UnregisterEndpointNotificationCallback = 7
+
+	// EDataFlow
+	peCapture = 1
+
+	pE_NOINTERFACE = 0x80004002
+)
+
+// pMMNotificationClient implements IMMNotificationClient. Like pComHandler,
+// it is owned by Go and must be kept alive while it is registered.
+type pMMNotificationClient struct {
+	vtbl *[8]uintptr
+	w    *WinTray
+}
+
+var pMMNotificationClientVtbl = [8]uintptr{
+
+	// QueryInterface; the enumerator may ask for other interfaces, such
+	// as IMarshal, which must be refused
+	syscall.NewCallback(func(this *pMMNotificationClient, riid *windows.GUID, ppv *uintptr) uintptr {
+		if *riid != *pIID_IUnknown && *riid != *pIID_IMMNotificationClient {
+			*ppv = 0
+			return pE_NOINTERFACE
+		}
+		*ppv = uintptr(unsafe.Pointer(this))
+		return 0
+	}),
+
+	// AddRef and Release
+	syscall.NewCallback(func(this *pMMNotificationClient) uintptr {
+		return 1
+	}),
+	syscall.NewCallback(func(this *pMMNotificationClient) uintptr {
+		return 1
+	}),
+
+	// OnDeviceStateChanged, OnDeviceAdded, and OnDeviceRemoved
+	syscall.NewCallback(func(this *pMMNotificationClient, id *uint16, state uint32) uintptr {
+		return 0
+	}),
+	syscall.NewCallback(func(this *pMMNotificationClient, id *uint16) uintptr {
+		return 0
+	}),
+	syscall.NewCallback(func(this *pMMNotificationClient, id *uint16) uintptr {
+		return 0
+	}),
+
+	// OnDefaultDeviceChanged
+	syscall.NewCallback(func(this *pMMNotificationClient, flow, role uint32, id *uint16) uintptr {
+		this.w.defaultAudioDeviceChanged(&AudioDeviceEvent{
+			Capture:  flow == peCapture,
+			Role:     AudioRole(role),
+			DeviceID: windows.UTF16PtrToString(id),
+		})
+		return 0
+	}),
+
+	// OnPropertyValueChanged, whose arguments depend on the architecture
+	pOnPropertyValueChangedCallback,
+}
+
+// OnDefaultAudioDeviceChange registers a function to be invoked when the
+// default playback or recording device changes. Windows reports the change
+// once for each role, so applications interested in a single role (usually
+// AudioRoleMultimedia) should ignore the others. Passing nil stops monitoring
+// audio devices.
+func (w *WinTray) OnDefaultAudioDeviceChange(fn func(e *AudioDeviceEvent)) error {
+	w.hooksMutex.Lock()
+	w.onAudioDeviceChange = fn
+	w.hooksMutex.Unlock()
+	return w.invoke(func() error {
+		switch {
+		case fn != nil && w.audioEnumerator == nil:
+			if err := w.initCOM(); err != nil {
+				return err
+			}
+			enumerator, err := createInstance(
+				"OnDefaultAudioDeviceChange",
+				pCLSID_MMDeviceEnumerator,
+				pIID_IMMDeviceEnumerator,
+			)
+			if err != nil {
+				return err
+			}
+			client := &pMMNotificationClient{
+				vtbl: &pMMNotificationClientVtbl,
+				w:    w,
+			}
+			if hr := enumerator.call(
+				pIMMDeviceEnumerator_RegisterEndpointNotificationCallback,
+				uintptr(unsafe.Pointer(client)),
+			); hr != 0 {
+				enumerator.release()
+				return newHRESULTError("OnDefaultAudioDeviceChange", "unable to register for notifications", nil, hr)
+			}
+			w.audioEnumerator = enumerator
+			w.audioClient = client
+		case fn == nil:
+			w.unregisterAudioNotifications()
+		}
+		return nil
+	})
+}
+
+// unregisterAudioNotifications stops monitoring audio devices.
+func (w *WinTray) unregisterAudioNotifications() {
+	if w.audioEnumerator == nil {
+		return
+	}
+	w.audioEnumerator.call(
+		pIMMDeviceEnumerator_UnregisterEndpointNotificationCallback,
+		uintptr(unsafe.Pointer(w.audioClient)),
+	)
+	w.audioEnumerator.release()
+	w.audioEnumerator = nil
+	w.audioClient = nil
+}
+
+// defaultAudioDeviceChanged is invoked on a thread belonging to Windows when
+// the default audio device changes.
+func (w *WinTray) defaultAudioDeviceChanged(e *AudioDeviceEvent) {
+	w.hooksMutex.Lock()
+	fn := w.onAudioDeviceChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		go w.invokeHandler(func() { fn(e) })
+	}
+}
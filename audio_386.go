@@ -0,0 +1,15 @@
+//go:build windows
+
+package wintray
+
+import (
+	"syscall"
+)
+
+// On 386, the PROPERTYKEY passed to IMMNotificationClient::OnPropertyValueChanged
+// occupies five words on the stack, which the callee must remove.
+var pOnPropertyValueChangedCallback = syscall.NewCallback(
+	func(this *pMMNotificationClient, id *uint16, k0, k1, k2, k3, k4 uintptr) uintptr {
+		return 0
+	},
+)
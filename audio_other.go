@@ -0,0 +1,15 @@
+//go:build windows && !386
+
+package wintray
+
+import (
+	"syscall"
+)
+
+// On 64-bit architectures, the PROPERTYKEY passed to
+// IMMNotificationClient::OnPropertyValueChanged is passed by reference.
+var pOnPropertyValueChangedCallback = syscall.NewCallback(
+	func(this *pMMNotificationClient, id *uint16, key uintptr) uintptr {
+		return 0
+	},
+)
@@ -0,0 +1,114 @@
+//go:build windows
+
+package wintray
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const pRunKey = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+// autostartCommand builds the command line for the current executable.
+func autostartCommand(args []string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	parts := []string{windows.EscapeArg(exe)}
+	for _, a := range args {
+		parts = append(parts, windows.EscapeArg(a))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// schtasks runs schtasks.exe without showing a console window.
+func schtasks(args ...string) error {
+	cmd := exec.Command("schtasks.exe", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	return cmd.Run()
+}
+
+// EnableAutostart runs the current executable with the specified arguments
+// when the user logs in. appName identifies the entry and must be unique.
+func EnableAutostart(appName string, args ...string) error {
+	c, err := autostartCommand(args)
+	if err != nil {
+		return newErrorFrom("EnableAutostart", "unable to determine executable", nil, err)
+	}
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, pRunKey, registry.SET_VALUE)
+	if err != nil {
+		return newErrorFrom("EnableAutostart", "unable to open Run key", nil, err)
+	}
+	defer k.Close()
+	if err := k.SetStringValue(appName, c); err != nil {
+		return newErrorFrom("EnableAutostart", "unable to write Run key", nil, err)
+	}
+	return nil
+}
+
+// EnableAutostartElevated is like EnableAutostart but creates a scheduled
+// task that runs with the highest privileges, since entries in the Run key
+// cannot start elevated. The calling process must be elevated.
+func EnableAutostartElevated(appName string, args ...string) error {
+	c, err := autostartCommand(args)
+	if err != nil {
+		return newErrorFrom("EnableAutostartElevated", "unable to determine executable", nil, err)
+	}
+	if err := schtasks(
+		"/Create", "/F",
+		"/TN", appName,
+		"/TR", c,
+		"/SC", "ONLOGON",
+		"/RL", "HIGHEST",
+	); err != nil {
+		return newErrorFrom("EnableAutostartElevated", "unable to create task", nil, err)
+	}
+	return nil
+}
+
+// DisableAutostart removes the entry created by EnableAutostart or
+// EnableAutostartElevated. It is not an error if no entry exists.
+func DisableAutostart(appName string) error {
+	k, err := registry.OpenKey(registry.CURRENT_USER, pRunKey, registry.SET_VALUE)
+	if err == nil {
+		defer k.Close()
+		err = k.DeleteValue(appName)
+	}
+	if err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return newErrorFrom("DisableAutostart", "unable to write Run key", nil, err)
+	}
+	if taskExists(appName) {
+		if err := schtasks("/Delete", "/F", "/TN", appName); err != nil {
+			return newErrorFrom("DisableAutostart", "unable to delete task", nil, err)
+		}
+	}
+	return nil
+}
+
+// taskExists determines whether the scheduled task exists.
+func taskExists(appName string) bool {
+	return schtasks("/Query", "/TN", appName) == nil
+}
+
+// IsAutostartEnabled determines whether an entry exists for appName.
+func IsAutostartEnabled(appName string) (bool, error) {
+	k, err := registry.OpenKey(registry.CURRENT_USER, pRunKey, registry.QUERY_VALUE)
+	if err == nil {
+		defer k.Close()
+		_, _, err = k.GetStringValue(appName)
+		if err == nil {
+			return true, nil
+		}
+	}
+	if !errors.Is(err, registry.ErrNotExist) {
+		return false, newErrorFrom("IsAutostartEnabled", "unable to read Run key", nil, err)
+	}
+	return taskExists(appName), nil
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// setSuspended records whether the computer is suspended.
+func (w *WinTray) setSuspended(suspended bool) {
+	w.suspended = suspended
+	w.awayChanged()
+}
+
+// setLocked records whether the session is locked.
+func (w *WinTray) setLocked(locked bool) {
+	w.locked = locked
+	w.awayChanged()
+}
+
+// awayChanged pauses the icon's timers and schedules while the computer is
+// suspended or the session is locked, since nobody would see their effects,
+// and resumes them once the user returns.
+func (w *WinTray) awayChanged() {
+	away := w.suspended || w.locked
+	if away == w.away {
+		return
+	}
+	w.away = away
+	w.debug("away changed", "away", away)
+	w.holdSchedules(away)
+	if away {
+		win.KillTimer(w.hwnd, pIDT_HEALTH)
+	} else {
+		w.startHealthCheck()
+	}
+}
@@ -0,0 +1,134 @@
+package wintray
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Batch collects operations to be applied in a single trip to the UI thread.
+// It is only valid within the function passed to WinTray.Batch.
+type Batch struct {
+	messages []*pMessage
+}
+
+// BatchError is returned by WinTray.Batch when one or more of the operations
+// fail. It contains the error for each failed operation in order.
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	s := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		s[i] = err.Error()
+	}
+	return fmt.Sprintf("%d operations failed: %s", len(e.Errors), strings.Join(s, "; "))
+}
+
+// Unwrap returns the individual errors.
+func (e *BatchError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether any of the individual errors matches target. Versions of
+// Go before 1.20 do not use Unwrap when it returns more than one error.
+func (e *BatchError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the individual errors that matches target and sets
+// target to that error value.
+func (e *BatchError) As(target any) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Batch) add(m *pMessage) {
+	b.messages = append(b.messages, m)
+}
+
+// SetIconFromBytes queues a call to WinTray.SetIconFromBytes.
+func (b *Batch) SetIconFromBytes(data []byte) {
+	b.add(&pMessage{
+		Type: pMESSAGE_SET_ICON_FROM_BYTES,
+		Data: data,
+	})
+}
+
+// SetTip queues a call to WinTray.SetTip.
+func (b *Batch) SetTip(text string) {
+	b.add(&pMessage{
+		Type: pMESSAGE_SET_TIP,
+		Data: text,
+	})
+}
+
+// AddMenuItem queues a call to WinTray.AddMenuItem.
+func (b *Batch) AddMenuItem(text string, fn func()) {
+	b.add(&pMessage{
+		Type: pMESSAGE_ADD_MENU_ITEM,
+		Data: &pDataAddMenuItem{
+			Text: text,
+			Fn:   fn,
+		},
+	})
+}
+
+// AddMenuSeparator queues a call to WinTray.AddMenuSeparator.
+func (b *Batch) AddMenuSeparator() {
+	b.add(&pMessage{
+		Type: pMESSAGE_ADD_MENU_SEPARATOR,
+	})
+}
+
+// ShowNotification queues a call to WinTray.ShowNotification.
+func (b *Batch) ShowNotification(info, infoTitle string, opts ...NotificationOption) {
+	b.add(&pMessage{
+		Type: pMESSAGE_SHOW_NOTIFICATION,
+		Data: newDataShowNotification(info, infoTitle, opts),
+	})
+}
+
+// handleBatch applies each of the messages in order on the UI thread,
+// combining any errors that occur.
+func (w *WinTray) handleBatch(messages []*pMessage) error {
+	var errs []error
+	for _, m := range messages {
+		if err := w.handleMessage(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
+
+// Batch invokes fn to collect a set of operations and then applies all of
+// them in a single trip to the UI thread. Every operation is attempted even
+// if an earlier one fails; the returned error is a *BatchError describing
+// all of the failures.
+func (w *WinTray) Batch(fn func(b *Batch)) error {
+	b := &Batch{}
+	fn(b)
+	if len(b.messages) == 0 {
+		return nil
+	}
+	return w.call(&pMessage{
+		Type: pMESSAGE_BATCH,
+		Data: b.messages,
+	})
+}
@@ -0,0 +1,164 @@
+//go:build windows
+
+package wintray
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Windows records when each application starts and stops using a capability
+// (such as the microphone) under this key, which also drives the privacy
+// indicator in the taskbar
+const pConsentStoreKey = `Software\Microsoft\Windows\CurrentVersion\CapabilityAccessManager\ConsentStore\`
+
+// capabilityInUse reports whether any application is using the capability
+// represented by k. Packaged applications have a subkey of their own, while
+// other applications are grouped under "NonPackaged".
+func capabilityInUse(k registry.Key, depth int) bool {
+	names, err := k.ReadSubKeyNames(-1)
+	if err != nil {
+		return false
+	}
+	for _, name := range names {
+		sk, err := registry.OpenKey(k, name, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+		if err != nil {
+			continue
+		}
+		var inUse bool
+		if name == "NonPackaged" && depth == 0 {
+			inUse = capabilityInUse(sk, depth+1)
+		} else {
+			start, _, _ := sk.GetIntegerValue("LastUsedTimeStart")
+			stop, _, err := sk.GetIntegerValue("LastUsedTimeStop")
+			inUse = start != 0 && err == nil && stop == 0
+		}
+		sk.Close()
+		if inUse {
+			return true
+		}
+	}
+	return false
+}
+
+// setEventOnClose signals the event when the icon is closed. The returned
+// function must be called before the event is closed.
+func (w *WinTray) setEventOnClose(h windows.Handle) func() {
+	var (
+		done   = make(chan struct{})
+		exited = make(chan struct{})
+	)
+	go func() {
+		defer close(exited)
+		select {
+		case <-w.Done():
+			windows.SetEvent(h)
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// setCapabilityHook stores fn in the hook and starts or stops monitoring the
+// capability accordingly. It must be called with hooksMutex held.
+func (w *WinTray) setCapabilityHook(op, capability string, hook *func(inUse bool), stop *windows.Handle, fn func(inUse bool)) error {
+	*hook = fn
+	switch {
+	case fn != nil && *stop == 0:
+		h, err := windows.CreateEvent(nil, 1, 0, nil)
+		if err != nil {
+			return newErrorFrom(op, "unable to create event", nil, err)
+		}
+		*stop = h
+		go w.watchCapability(op, capability, hook, h)
+	case fn == nil && *stop != 0:
+		windows.SetEvent(*stop)
+		*stop = 0
+	}
+	return nil
+}
+
+// watchCapability waits for the usage of the capability to change until the
+// icon is closed or stop is signaled, invoking the function in hook when the
+// capability comes into use or is no longer used.
+func (w *WinTray) watchCapability(op, capability string, hook *func(inUse bool), stop windows.Handle) {
+	defer windows.CloseHandle(stop)
+
+	// Change notifications are tied to the thread that requested them
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	k, err := registry.OpenKey(
+		registry.CURRENT_USER,
+		pConsentStoreKey+capability,
+		registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS|registry.NOTIFY,
+	)
+	if err != nil {
+		w.reportError(newErrorFrom(op, "unable to open "+capability+" usage", ErrUnsupported, err), nil)
+		return
+	}
+	defer k.Close()
+	changed, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		w.reportError(newErrorFrom(op, "unable to create event", nil, err), nil)
+		return
+	}
+	defer windows.CloseHandle(changed)
+	defer w.setEventOnClose(stop)()
+
+	inUse := capabilityInUse(k, 0)
+	for {
+		if err := windows.RegNotifyChangeKeyValue(
+			windows.Handle(k),
+			true,
+			windows.REG_NOTIFY_CHANGE_NAME|windows.REG_NOTIFY_CHANGE_LAST_SET,
+			changed,
+			true,
+		); err != nil {
+			w.reportError(newErrorFrom(op, "unable to monitor "+capability+" usage", nil, err), nil)
+			return
+		}
+		event, _ := windows.WaitForMultipleObjects(
+			[]windows.Handle{changed, stop},
+			false,
+			windows.INFINITE,
+		)
+		if event != windows.WAIT_OBJECT_0 {
+			return
+		}
+		u := capabilityInUse(k, 0)
+		if u == inUse {
+			continue
+		}
+		inUse = u
+		w.hooksMutex.Lock()
+		fn := *hook
+		w.hooksMutex.Unlock()
+		if fn != nil {
+			w.invokeHandler(func() { fn(u) })
+		}
+	}
+}
+
+// OnMicInUse registers a function to be invoked with true when an application
+// starts using the microphone and with false once none are using it. Passing
+// nil stops monitoring the microphone.
+func (w *WinTray) OnMicInUse(fn func(inUse bool)) error {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	return w.setCapabilityHook("OnMicInUse", "microphone", &w.onMicInUse, &w.micStop, fn)
+}
+
+// OnCameraInUse registers a function to be invoked with true when an
+// application starts using the camera and with false once none are using it.
+// Passing nil stops monitoring the camera.
+func (w *WinTray) OnCameraInUse(fn func(inUse bool)) error {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	return w.setCapabilityHook("OnCameraInUse", "webcam", &w.onCameraInUse, &w.cameraStop, fn)
+}
@@ -0,0 +1,140 @@
+//go:build windows
+
+package wintray
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	pRemoveClipboardFormatListener = user32.MustFindProc("RemoveClipboardFormatListener")
+)
+
+// openClipboard opens the clipboard, retrying briefly since other
+// applications (including clipboard managers) hold it open for short periods.
+func (w *WinTray) openClipboard() error {
+	for i := 0; i < 10; i++ {
+		if win.OpenClipboard(w.hwnd) {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return ErrClipboardBusy
+}
+
+func (w *WinTray) readClipboardText() (string, error) {
+	if !win.IsClipboardFormatAvailable(win.CF_UNICODETEXT) {
+		return "", nil
+	}
+	if err := w.openClipboard(); err != nil {
+		return "", err
+	}
+	defer win.CloseClipboard()
+	h := win.GetClipboardData(win.CF_UNICODETEXT)
+	if h == 0 {
+		return "", newError("ReadClipboardText", "unable to get clipboard data", nil)
+	}
+	p := win.GlobalLock(win.HGLOBAL(h))
+	if p == nil {
+		return "", newError("ReadClipboardText", "unable to lock clipboard data", nil)
+	}
+	defer win.GlobalUnlock(win.HGLOBAL(h))
+	return windows.UTF16PtrToString((*uint16)(p)), nil
+}
+
+func (w *WinTray) writeClipboardText(text string) error {
+	u, err := syscall.UTF16FromString(text)
+	if err != nil {
+		return err
+	}
+	size := uintptr(len(u)) * 2
+
+	// Copy the text into a global memory object
+	h := win.GlobalAlloc(win.GMEM_MOVEABLE, size)
+	if h == 0 {
+		return newError("WriteClipboardText", "unable to allocate memory", nil)
+	}
+	p := win.GlobalLock(h)
+	if p == nil {
+		win.GlobalFree(h)
+		return newError("WriteClipboardText", "unable to lock memory", nil)
+	}
+	win.MoveMemory(p, unsafe.Pointer(&u[0]), size)
+	win.GlobalUnlock(h)
+
+	// Transfer ownership of the memory to the clipboard
+	if err := w.openClipboard(); err != nil {
+		win.GlobalFree(h)
+		return err
+	}
+	defer win.CloseClipboard()
+	win.EmptyClipboard()
+	if win.SetClipboardData(win.CF_UNICODETEXT, win.HANDLE(h)) == 0 {
+		err := newError("WriteClipboardText", "unable to set clipboard data", nil)
+		win.GlobalFree(h)
+		return err
+	}
+	return nil
+}
+
+// ReadClipboardText returns the text currently on the clipboard or an empty
+// string if the clipboard does not contain text.
+func (w *WinTray) ReadClipboardText() (string, error) {
+	var text string
+	err := w.invoke(func() (err error) {
+		text, err = w.readClipboardText()
+		return
+	})
+	return text, err
+}
+
+// WriteClipboardText replaces the contents of the clipboard with text.
+func (w *WinTray) WriteClipboardText(text string) error {
+	return w.invoke(func() error {
+		return w.writeClipboardText(text)
+	})
+}
+
+// OnClipboardChange registers a function to be invoked whenever the contents
+// of the clipboard change (including changes made with WriteClipboardText).
+// Passing nil stops monitoring the clipboard.
+func (w *WinTray) OnClipboardChange(fn func()) error {
+	w.hooksMutex.Lock()
+	w.onClipboardChange = fn
+	w.hooksMutex.Unlock()
+	return w.invoke(func() error {
+		switch {
+		case fn != nil && !w.clipboardListening:
+			if !win.AddClipboardFormatListener(w.hwnd) {
+				return newError("OnClipboardChange", "unable to monitor clipboard", nil)
+			}
+			w.clipboardListening = true
+		case fn == nil && w.clipboardListening:
+			w.removeClipboardListener()
+		}
+		return nil
+	})
+}
+
+func (w *WinTray) removeClipboardListener() {
+	if w.clipboardListening {
+		pRemoveClipboardFormatListener.Call(uintptr(w.hwnd))
+		w.clipboardListening = false
+	}
+}
+
+// clipboardUpdated is invoked on the UI thread when WM_CLIPBOARDUPDATE is
+// received.
+func (w *WinTray) clipboardUpdated() {
+	w.hooksMutex.Lock()
+	fn := w.onClipboardChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		go w.invokeHandler(fn)
+	}
+}
@@ -0,0 +1,84 @@
+//go:build windows
+
+package wintray
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	ole32             = windows.MustLoadDLL("Ole32.dll")
+	pCoCreateInstance = ole32.MustFindProc("CoCreateInstance")
+)
+
+const pCLSCTX_INPROC_SERVER = 0x1
+
+// pComObject is a pointer to a COM interface; methods are invoked by their
+// index in the vtable (the first three are always those of IUnknown).
+type pComObject struct {
+	vtbl *[64]uintptr
+}
+
+func (o *pComObject) call(method int, args ...uintptr) uintptr {
+	r, _, _ := syscall.SyscallN(
+		o.vtbl[method],
+		append([]uintptr{uintptr(unsafe.Pointer(o))}, args...)...,
+	)
+	return r
+}
+
+// queryInterface invokes IUnknown::QueryInterface.
+func (o *pComObject) queryInterface(iid *windows.GUID) (*pComObject, uintptr) {
+	var obj *pComObject
+	hr := o.call(0, uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&obj)))
+	return obj, hr
+}
+
+// release invokes IUnknown::Release.
+func (o *pComObject) release() {
+	o.call(2)
+}
+
+func mustGUID(s string) *windows.GUID {
+	g, err := windows.GUIDFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return &g
+}
+
+// createInstance creates an in-process COM object and returns the requested
+// interface.
+func createInstance(op string, clsid, iid *windows.GUID) (*pComObject, error) {
+	var obj *pComObject
+	if hr, _, _ := pCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(clsid)),
+		0,
+		pCLSCTX_INPROC_SERVER,
+		uintptr(unsafe.Pointer(iid)),
+		uintptr(unsafe.Pointer(&obj)),
+	); hr != 0 {
+		return nil, newHRESULTError(op, "unable to create COM object", nil, hr)
+	}
+	return obj, nil
+}
+
+// initCOM initializes COM for the UI thread the first time it is needed. It
+// is uninitialized when the event loop terminates.
+func (w *WinTray) initCOM() error {
+	if w.comInitialized {
+		return nil
+	}
+	// S_FALSE indicates that COM was already initialized on this thread
+	if err := windows.CoInitializeEx(
+		0,
+		windows.COINIT_APARTMENTTHREADED,
+	); err != nil && err != syscall.Errno(1) {
+		return newErrorFrom("CoInitializeEx", "unable to initialize COM", nil, err)
+	}
+	w.comInitialized = true
+	return nil
+}
@@ -0,0 +1,63 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// OnCopyData registers a function to be invoked when another process sends
+// data to the icon's hidden window with WM_COPYDATA (such as with
+// SendCopyDataTo). The function receives the identifier and a copy of the
+// data. Use WithWindowTitle or WithClassName so that other processes can find
+// the window.
+func (w *WinTray) OnCopyData(fn func(id uint32, data []byte)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onCopyData = fn
+}
+
+// copyDataReceived passes data received with WM_COPYDATA to the function
+// registered with OnCopyData and returns whether it was handled.
+func (w *WinTray) copyDataReceived(cds *pCOPYDATASTRUCT) bool {
+	w.hooksMutex.Lock()
+	fn := w.onCopyData
+	w.hooksMutex.Unlock()
+	if fn == nil {
+		return false
+	}
+
+	// The data is only valid until the message returns
+	data := []byte{}
+	if cds.CbData > 0 {
+		data = append(data, unsafe.Slice((*byte)(paramPointer(cds.LpData)), cds.CbData)...)
+	}
+	id := uint32(cds.DwData)
+	go w.invokeHandler(func() { fn(id, data) })
+	return true
+}
+
+// SendCopyDataTo sends data to the window with the specified title or, if
+// there is none, the specified class name, using WM_COPYDATA. This is
+// intended for sending commands to a running icon from helper processes and
+// installers; the receiving icon delivers the data to the function
+// registered with OnCopyData. The identifier 0x47575441 is reserved.
+func SendCopyDataTo(windowTitleOrClass string, id uint32, data []byte) error {
+	if err := checkText("SendCopyDataTo", windowTitleOrClass); err != nil {
+		return err
+	}
+	name := utf16PtrFromString(windowTitleOrClass)
+	hwnd := win.FindWindow(nil, name)
+	if hwnd == 0 {
+		hwnd = win.FindWindow(name, nil)
+	}
+	if hwnd == 0 {
+		return newError("SendCopyDataTo", "unable to find window", nil)
+	}
+	if err := sendCopyData(hwnd, uintptr(id), data); err != nil {
+		return newErrorFrom("SendCopyDataTo", "unable to send data", nil, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package wintray
+
+import (
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// The first build of Windows 10 to include the dark mode functions
+const pDarkModeMinBuild = 17763
+
+// pPreferredAppModeAllowDark makes menus follow the app theme; on 1809 the
+// same ordinal is AllowDarkModeForApp(BOOL) and TRUE has the same effect
+const pPreferredAppModeAllowDark = 1
+
+// These functions are exported from uxtheme.dll by ordinal only.
+var (
+	darkModeOnce                      sync.Once
+	pAllowDarkModeForWindow           *windows.Proc
+	pSetPreferredAppMode              *windows.Proc
+	pFlushMenuThemes                  *windows.Proc
+	pRefreshImmersiveColorPolicyState *windows.Proc
+)
+
+// loadDarkMode attempts to locate the dark mode functions, returning false if
+// they are not available.
+func loadDarkMode() bool {
+	darkModeOnce.Do(func() {
+		if windows.RtlGetVersion().BuildNumber < pDarkModeMinBuild {
+			return
+		}
+		uxtheme, err := windows.LoadDLL("uxtheme.dll")
+		if err != nil {
+			return
+		}
+		var (
+			refresh, _ = uxtheme.FindProcByOrdinal(104)
+			allow, _   = uxtheme.FindProcByOrdinal(133)
+			setMode, _ = uxtheme.FindProcByOrdinal(135)
+			flush, _   = uxtheme.FindProcByOrdinal(136)
+		)
+		if refresh == nil || allow == nil || setMode == nil || flush == nil {
+			return
+		}
+		pRefreshImmersiveColorPolicyState = refresh
+		pAllowDarkModeForWindow = allow
+		pSetPreferredAppMode = setMode
+		pFlushMenuThemes = flush
+	})
+	return pFlushMenuThemes != nil
+}
+
+// enableDarkModeMenus opts the process and the hidden window into dark menus.
+func (w *WinTray) enableDarkModeMenus() {
+	if !loadDarkMode() {
+		return
+	}
+	pSetPreferredAppMode.Call(pPreferredAppModeAllowDark)
+	pAllowDarkModeForWindow.Call(uintptr(w.hwnd), 1)
+	refreshDarkModeMenus()
+}
+
+// refreshDarkModeMenus updates the menu theme after the app theme changes.
+func refreshDarkModeMenus() {
+	pRefreshImmersiveColorPolicyState.Call()
+	pFlushMenuThemes.Call()
+}
@@ -0,0 +1,124 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	pRegisterDeviceNotificationW  = user32.MustFindProc("RegisterDeviceNotificationW")
+	pUnregisterDeviceNotification = user32.MustFindProc("UnregisterDeviceNotification")
+)
+
+// Device events (WM_DEVICECHANGE)
+const (
+	pDBT_DEVICEARRIVAL        = 0x8000
+	pDBT_DEVICEREMOVECOMPLETE = 0x8004
+
+	pDBT_DEVTYP_VOLUME          = 0x2
+	pDBT_DEVTYP_DEVICEINTERFACE = 0x5
+)
+
+type pDEV_BROADCAST_HDR struct {
+	Size       uint32
+	DeviceType uint32
+	Reserved   uint32
+}
+
+type pDEV_BROADCAST_DEVICEINTERFACE struct {
+	Size       uint32
+	DeviceType uint32
+	Reserved   uint32
+	ClassGuid  windows.GUID
+	Name       [1]uint16
+}
+
+type pDEV_BROADCAST_VOLUME struct {
+	Size       uint32
+	DeviceType uint32
+	Reserved   uint32
+	UnitMask   uint32
+	Flags      uint16
+}
+
+// OnDeviceChange registers a function to be invoked when a device is added
+// or removed. Volumes (such as USB drives) are always reported; other devices
+// are only reported for classes passed to WatchDeviceInterface.
+func (w *WinTray) OnDeviceChange(fn func(e *DeviceEvent)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onDeviceChange = fn
+}
+
+// WatchDeviceInterface causes devices with the specified interface class to
+// be reported to the function registered with OnDeviceChange.
+func (w *WinTray) WatchDeviceInterface(class DeviceInterfaceClass) error {
+	guid, err := windows.GUIDFromString(string(class))
+	if err != nil {
+		return err
+	}
+	class = DeviceInterfaceClass(guid.String())
+	return w.invoke(func() error {
+		if _, ok := w.deviceNotifications[class]; ok {
+			return nil
+		}
+		filter := &pDEV_BROADCAST_DEVICEINTERFACE{
+			Size:       uint32(unsafe.Sizeof(pDEV_BROADCAST_DEVICEINTERFACE{})),
+			DeviceType: pDBT_DEVTYP_DEVICEINTERFACE,
+			ClassGuid:  guid,
+		}
+		h, _, err := pRegisterDeviceNotificationW.Call(
+			uintptr(w.hwnd),
+			uintptr(unsafe.Pointer(filter)),
+			pDEVICE_NOTIFY_WINDOW_HANDLE,
+		)
+		if h == 0 {
+			return newErrorFrom("WatchDeviceInterface", "unable to register for notifications", nil, err)
+		}
+		w.deviceNotifications[class] = h
+		return nil
+	})
+}
+
+func (w *WinTray) unregisterDeviceNotifications() {
+	for class, h := range w.deviceNotifications {
+		pUnregisterDeviceNotification.Call(h)
+		delete(w.deviceNotifications, class)
+	}
+}
+
+// deviceChange is invoked on the UI thread when WM_DEVICECHANGE is received.
+func (w *WinTray) deviceChange(wparam, lparam uintptr) {
+	if wparam != pDBT_DEVICEARRIVAL && wparam != pDBT_DEVICEREMOVECOMPLETE {
+		return
+	}
+	w.hooksMutex.Lock()
+	fn := w.onDeviceChange
+	w.hooksMutex.Unlock()
+	if fn == nil || lparam == 0 {
+		return
+	}
+	var (
+		hdr = (*pDEV_BROADCAST_HDR)(paramPointer(lparam))
+		e   = &DeviceEvent{Arrival: wparam == pDBT_DEVICEARRIVAL}
+	)
+	switch hdr.DeviceType {
+	case pDBT_DEVTYP_DEVICEINTERFACE:
+		di := (*pDEV_BROADCAST_DEVICEINTERFACE)(unsafe.Pointer(hdr))
+		e.Class = DeviceInterfaceClass(di.ClassGuid.String())
+		e.Path = windows.UTF16PtrToString(&di.Name[0])
+	case pDBT_DEVTYP_VOLUME:
+		v := (*pDEV_BROADCAST_VOLUME)(unsafe.Pointer(hdr))
+		for i := 0; i < 26; i++ {
+			if v.UnitMask&(1<<i) != 0 {
+				e.Drives = append(e.Drives, string(rune('A'+i))+":")
+			}
+		}
+	default:
+		return
+	}
+	go w.invokeHandler(func() { fn(e) })
+}
@@ -0,0 +1,198 @@
+//go:build windows
+
+package wintray
+
+import (
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+// TaskDialogIndirect is only available when version 6 of the common controls
+// is activated by the application manifest
+var pTaskDialogIndirect = windows.NewLazySystemDLL("comctl32.dll").NewProc("TaskDialogIndirect")
+
+const (
+	pTDF_ENABLE_HYPERLINKS = 0x1
+	pTDF_USE_HICON_MAIN    = 0x2
+	pTDCBF_OK_BUTTON       = 0x1
+	pTDN_HYPERLINK_CLICKED = 3
+)
+
+var (
+	taskDialogCallbackOnce sync.Once
+	taskDialogCallback     uintptr
+)
+
+// ShowMessageBox displays a modal message box and waits for it to be closed.
+// The icon continues to process other API calls while the message box is
+// open.
+func (w *WinTray) ShowMessageBox(title, text string, style MessageBoxStyle) (MessageBoxResult, error) {
+	if err := checkText("ShowMessageBox", title, text); err != nil {
+		return 0, err
+	}
+	var r int32
+	if err := w.invoke(func() error {
+		r = win.MessageBox(
+			w.hwnd,
+			utf16PtrFromString(text),
+			utf16PtrFromString(title),
+			uint32(style)|win.MB_SETFOREGROUND,
+		)
+		if r == 0 {
+			return newError("ShowMessageBox", "unable to show message box", nil)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return MessageBoxResult(r), nil
+}
+
+// ShowAbout displays a modal About dialog for the application and waits for
+// it to be closed. The current icon is shown in the dialog and URL is
+// displayed as a link.
+func (w *WinTray) ShowAbout(info AppInfo) error {
+	if err := checkText(
+		"ShowAbout",
+		info.Name,
+		info.Version,
+		info.Description,
+		info.Copyright,
+		info.URL,
+	); err != nil {
+		return err
+	}
+	var (
+		instruction = strings.TrimSpace(info.Name + " " + info.Version)
+		content     = info.Description
+	)
+	if info.Copyright != "" {
+		if content != "" {
+			content += "\n\n"
+		}
+		content += info.Copyright
+	}
+
+	// Fall back to a message box if task dialogs are not available
+	if pTaskDialogIndirect.Find() != nil {
+		text := instruction
+		for _, s := range []string{content, info.URL} {
+			if s != "" {
+				text += "\n\n" + s
+			}
+		}
+		_, err := w.ShowMessageBox(
+			fmt.Sprintf(w.translate(KeyAboutTitle), info.Name),
+			text,
+			MessageBoxOK|MessageBoxIconInfo,
+		)
+		return err
+	}
+
+	return w.invoke(func() error {
+		win.SetForegroundWindow(w.hwnd)
+		var (
+			title   = utf16FromString(fmt.Sprintf(w.translate(KeyAboutTitle), info.Name))
+			instr   = utf16FromString(instruction)
+			body    = utf16FromString(content)
+			footer  []uint16
+			flags   uint32
+			tdc     = &pPackedWriter{}
+			pFooter uintptr
+		)
+		if info.URL != "" {
+			footer = utf16FromString(
+				`<a href="` + info.URL + `">` + info.URL + `</a>`,
+			)
+			pFooter = uintptr(unsafe.Pointer(&footer[0]))
+			flags |= pTDF_ENABLE_HYPERLINKS
+		}
+		if w.hicon != 0 {
+			flags |= pTDF_USE_HICON_MAIN
+		}
+
+		// TASKDIALOGCONFIG is packed, so it is assembled field by field; the
+		// size is filled in once the length is known
+		tdc.u32(0)
+		tdc.ptr(uintptr(w.hwnd))
+		tdc.ptr(uintptr(hinstance))
+		tdc.u32(flags)
+		tdc.u32(pTDCBF_OK_BUTTON)
+		tdc.ptr(uintptr(unsafe.Pointer(&title[0])))
+		tdc.ptr(uintptr(w.hicon))
+		tdc.ptr(uintptr(unsafe.Pointer(&instr[0])))
+		tdc.ptr(uintptr(unsafe.Pointer(&body[0])))
+		tdc.u32(0) // cButtons
+		tdc.ptr(0)
+		tdc.u32(0) // nDefaultButton
+		tdc.u32(0) // cRadioButtons
+		tdc.ptr(0)
+		tdc.u32(0) // nDefaultRadioButton
+		tdc.ptr(0) // pszVerificationText
+		tdc.ptr(0) // pszExpandedInformation
+		tdc.ptr(0) // pszExpandedControlText
+		tdc.ptr(0) // pszCollapsedControlText
+		tdc.ptr(0) // hFooterIcon
+		tdc.ptr(pFooter)
+		tdc.ptr(getTaskDialogCallback())
+		tdc.ptr(0)
+		tdc.u32(0) // cxWidth
+		binary.LittleEndian.PutUint32(tdc.b, uint32(len(tdc.b)))
+
+		hr, _, _ := pTaskDialogIndirect.Call(
+			uintptr(unsafe.Pointer(&tdc.b[0])),
+			0,
+			0,
+			0,
+		)
+		runtime.KeepAlive(title)
+		runtime.KeepAlive(instr)
+		runtime.KeepAlive(body)
+		runtime.KeepAlive(footer)
+		if hr != 0 {
+			return newHRESULTError("ShowAbout", "unable to show dialog", nil, hr)
+		}
+		return nil
+	})
+}
+
+// getTaskDialogCallback returns the callback shared by all task dialogs,
+// which opens links when they are clicked.
+func getTaskDialogCallback() uintptr {
+	taskDialogCallbackOnce.Do(func() {
+		taskDialogCallback = syscall.NewCallback(
+			func(hwnd win.HWND, msg uint32, wparam, lparam, data uintptr) uintptr {
+				if msg == pTDN_HYPERLINK_CLICKED {
+					OpenURL(windows.UTF16PtrToString((*uint16)(paramPointer(lparam))))
+				}
+				return 0
+			},
+		)
+	})
+	return taskDialogCallback
+}
+
+// pPackedWriter assembles structures declared with 1-byte packing.
+type pPackedWriter struct {
+	b []byte
+}
+
+func (p *pPackedWriter) u32(v uint32) {
+	p.b = binary.LittleEndian.AppendUint32(p.b, v)
+}
+
+func (p *pPackedWriter) ptr(v uintptr) {
+	if unsafe.Sizeof(v) == 8 {
+		p.b = binary.LittleEndian.AppendUint64(p.b, uint64(v))
+	} else {
+		p.u32(uint32(v))
+	}
+}
@@ -0,0 +1,130 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+const pUSER_DEFAULT_SCREEN_DPI = 96
+
+var (
+	pGetDpiForWindow        = findUser32Proc("GetDpiForWindow")
+	pGetSystemMetricsForDpi = findUser32Proc("GetSystemMetricsForDpi")
+)
+
+// findUser32Proc returns the named function or nil if it is not available on
+// this version of Windows.
+func findUser32Proc(name string) *windows.Proc {
+	p, _ := user32.FindProc(name)
+	return p
+}
+
+// dpi returns the DPI of the hidden window.
+func (w *WinTray) dpi() uint32 {
+	if pGetDpiForWindow != nil {
+		if r, _, _ := pGetDpiForWindow.Call(uintptr(w.hwnd)); r != 0 {
+			return uint32(r)
+		}
+	}
+	return pUSER_DEFAULT_SCREEN_DPI
+}
+
+// systemMetric returns the value of a system metric for the current DPI.
+func (w *WinTray) systemMetric(index int32) int32 {
+	if pGetSystemMetricsForDpi != nil {
+		if r, _, _ := pGetSystemMetricsForDpi.Call(
+			uintptr(index),
+			uintptr(w.dpi()),
+		); r != 0 {
+			return int32(r)
+		}
+	}
+	return win.GetSystemMetrics(index)
+}
+
+// smallIconSize returns the size of icons in the notification area for the
+// current DPI.
+func (w *WinTray) smallIconSize() int32 {
+	return w.systemMetric(win.SM_CXSMICON)
+}
+
+// largeIconSize returns the size of icons in notifications for the current
+// DPI.
+func (w *WinTray) largeIconSize() int32 {
+	return w.systemMetric(win.SM_CXICON)
+}
+
+// largeIcon returns the current icon at the size for notifications, loading
+// it the first time it is needed. Zero is returned if there is no icon.
+func (w *WinTray) largeIcon() win.HICON {
+	if w.hiconLarge == 0 && w.iconData != nil {
+		hicon, err := w.loadIcon(w.displayedIcon(w.iconData), w.largeIconSize())
+		if err != nil {
+			return 0
+		}
+		w.hiconLarge = hicon
+	}
+	return w.hiconLarge
+}
+
+// destroyLargeIcon releases the icon loaded by largeIcon.
+func (w *WinTray) destroyLargeIcon() {
+	if w.hiconLarge != 0 {
+		win.DestroyIcon(w.hiconLarge)
+		w.hiconLarge = 0
+	}
+}
+
+// reloadIcon loads the current icon again at the size for the current DPI.
+func (w *WinTray) reloadIcon() {
+	if w.iconData != nil {
+		w.setIcon(w.hwnd, w.iconId, w.iconData)
+	}
+}
+
+// OnDisplayChange registers a function to be invoked when the resolution of
+// the primary display changes or monitors are added or removed. The function
+// receives the new resolution of the primary display.
+func (w *WinTray) OnDisplayChange(fn func(width, height int)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onDisplayChange = fn
+}
+
+// OnDPIChange registers a function to be invoked when the DPI of the display
+// changes. The icon is reloaded at the appropriate size automatically.
+func (w *WinTray) OnDPIChange(fn func(dpi int)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onDPIChange = fn
+}
+
+// displayChanged is invoked on the UI thread when WM_DISPLAYCHANGE is
+// received.
+func (w *WinTray) displayChanged(lparam uintptr) {
+	w.reloadIcon()
+	w.hooksMutex.Lock()
+	fn := w.onDisplayChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		var (
+			width  = int(win.LOWORD(uint32(lparam)))
+			height = int(win.HIWORD(uint32(lparam)))
+		)
+		go w.invokeHandler(func() { fn(width, height) })
+	}
+}
+
+// dpiChanged is invoked on the UI thread when WM_DPICHANGED is received.
+func (w *WinTray) dpiChanged(wparam uintptr) {
+	w.reloadIcon()
+	w.hooksMutex.Lock()
+	fn := w.onDPIChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		dpi := int(win.LOWORD(uint32(wparam)))
+		go w.invokeHandler(func() { fn(dpi) })
+	}
+}
@@ -0,0 +1,189 @@
+//go:build windows
+
+package wintray
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// pIDT_DRAG identifies the timer that checks for a drag over the icon
+	pIDT_DRAG = 2
+
+	pDragPollInterval = 100 * time.Millisecond
+
+	// The overlay is almost transparent; a window that is fully transparent
+	// does not receive mouse input and so cannot be a drop target
+	pDropTargetAlpha = 1
+
+	pVK_LBUTTON = 0x01
+	pVK_RBUTTON = 0x02
+
+	pMSGFLT_ALLOW      = 1
+	pWM_COPYGLOBALDATA = 0x0049
+)
+
+var (
+	pDragQueryFileW              = shell32.MustFindProc("DragQueryFileW")
+	pDragFinish                  = shell32.MustFindProc("DragFinish")
+	pGetAsyncKeyState            = user32.MustFindProc("GetAsyncKeyState")
+	pSetLayeredWindowAttributes  = user32.MustFindProc("SetLayeredWindowAttributes")
+	pChangeWindowMessageFilterEx = findUser32Proc("ChangeWindowMessageFilterEx")
+)
+
+// pDropTarget is an almost invisible window that is placed over the icon
+// while the mouse button is held down, so that files dragged onto the icon
+// are dropped on it instead of on the taskbar.
+type pDropTarget struct {
+	w    *WinTray
+	hwnd win.HWND
+}
+
+func (d *pDropTarget) tray() *WinTray {
+	return d.w
+}
+
+func (d *pDropTarget) wndProc(hwnd win.HWND, msg uint32, wparam, lparam uintptr) uintptr {
+	if msg == win.WM_DROPFILES {
+		paths := droppedFiles(wparam)
+		win.ShowWindow(hwnd, win.SW_HIDE)
+		d.w.hooksMutex.Lock()
+		fn := d.w.onFilesDropped
+		d.w.hooksMutex.Unlock()
+		if fn != nil && len(paths) > 0 {
+			go d.w.invokeHandler(func() { fn(paths) })
+		}
+		return 0
+	}
+	return win.DefWindowProc(hwnd, msg, wparam, lparam)
+}
+
+// droppedFiles returns the paths in the HDROP passed with WM_DROPFILES and
+// releases it.
+func droppedFiles(hdrop uintptr) []string {
+	defer pDragFinish.Call(hdrop)
+	count, _, _ := pDragQueryFileW.Call(hdrop, 0xffffffff, 0, 0)
+	paths := make([]string, 0, count)
+	for i := uintptr(0); i < count; i++ {
+		n, _, _ := pDragQueryFileW.Call(hdrop, i, 0, 0)
+		buf := make([]uint16, n+1)
+		pDragQueryFileW.Call(hdrop, i, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+		paths = append(paths, windows.UTF16ToString(buf))
+	}
+	return paths
+}
+
+// OnFilesDropped registers a function to be invoked with the paths of files
+// that the user drags from Explorer (or another application) and drops onto
+// the icon. Windows does not support dropping onto notification area icons,
+// so while a mouse button is held down over the icon, an invisible window is
+// placed over it to receive the files. Icons in the overflow area cannot
+// receive files. Passing nil stops accepting files.
+func (w *WinTray) OnFilesDropped(fn func(paths []string)) error {
+	w.hooksMutex.Lock()
+	w.onFilesDropped = fn
+	w.hooksMutex.Unlock()
+	return w.invoke(func() error {
+		if fn == nil {
+			win.KillTimer(w.hwnd, pIDT_DRAG)
+			if w.dropTarget != nil {
+				win.ShowWindow(w.dropTarget.hwnd, win.SW_HIDE)
+			}
+			return nil
+		}
+		if w.dropTarget == nil {
+			d, err := w.newDropTarget()
+			if err != nil {
+				return err
+			}
+			w.dropTarget = d
+		}
+		if win.SetTimer(
+			w.hwnd,
+			pIDT_DRAG,
+			uint32(pDragPollInterval/time.Millisecond),
+			0,
+		) == 0 {
+			return newError("OnFilesDropped", "unable to create timer", nil)
+		}
+		return nil
+	})
+}
+
+// newDropTarget creates the hidden window that receives dropped files.
+func (w *WinTray) newDropTarget() (*pDropTarget, error) {
+	className := w.className + "_DropTarget"
+	if err := registerClass(className, 0, win.GetSysColorBrush(win.COLOR_WINDOW)); err != nil {
+		return nil, err
+	}
+	w.dropTargetClassRegistered = true
+	d := &pDropTarget{w: w}
+	hwnd, err := createWindow(
+		d,
+		className,
+		"",
+		win.WS_EX_TOPMOST|win.WS_EX_TOOLWINDOW|win.WS_EX_NOACTIVATE|win.WS_EX_LAYERED|win.WS_EX_ACCEPTFILES,
+		win.WS_POPUP,
+		0,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.hwnd = hwnd
+	pSetLayeredWindowAttributes.Call(uintptr(hwnd), 0, pDropTargetAlpha, win.LWA_ALPHA)
+
+	// Allow files to be dropped from Explorer when running elevated
+	if pChangeWindowMessageFilterEx != nil {
+		for _, msg := range []uintptr{win.WM_DROPFILES, win.WM_COPYDATA, pWM_COPYGLOBALDATA} {
+			pChangeWindowMessageFilterEx.Call(uintptr(hwnd), msg, pMSGFLT_ALLOW, 0)
+		}
+	}
+	return d, nil
+}
+
+// checkDrag is invoked on the UI thread by the timer, showing the drop target
+// over the icon while a mouse button is held down over it (as it is during a
+// drag) and hiding it otherwise.
+func (w *WinTray) checkDrag() {
+	if w.dropTarget == nil {
+		return
+	}
+	var (
+		l, _, _ = pGetAsyncKeyState.Call(pVK_LBUTTON)
+		r, _, _ = pGetAsyncKeyState.Call(pVK_RBUTTON)
+		down    = (l|r)&0x8000 != 0
+		visible = win.IsWindowVisible(w.dropTarget.hwnd)
+	)
+	if !down {
+		if visible {
+			win.ShowWindow(w.dropTarget.hwnd, win.SW_HIDE)
+		}
+		return
+	}
+	rc, err := w.iconRect()
+	if err != nil {
+		return
+	}
+	var pt win.POINT
+	if !win.GetCursorPos(&pt) {
+		return
+	}
+	over := pt.X >= rc.Left && pt.X < rc.Right && pt.Y >= rc.Top && pt.Y < rc.Bottom
+	if over && !visible {
+		win.SetWindowPos(
+			w.dropTarget.hwnd,
+			win.HWND_TOPMOST,
+			rc.Left,
+			rc.Top,
+			rc.Right-rc.Left,
+			rc.Bottom-rc.Top,
+			win.SWP_NOACTIVATE|win.SWP_SHOWWINDOW,
+		)
+	} else if !over && visible {
+		win.ShowWindow(w.dropTarget.hwnd, win.SW_HIDE)
+	}
+}
@@ -0,0 +1,126 @@
+//go:build windows
+
+package wintray
+
+import (
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+var pSHGetStockIconInfo = shell32.MustFindProc("SHGetStockIconInfo")
+
+const (
+	pSIID_SHIELD     = 77
+	pSHGSI_ICON      = 0x100
+	pSHGSI_SMALLICON = 0x1
+)
+
+type pSHSTOCKICONINFO struct {
+	CbSize         uint32
+	HIcon          win.HICON
+	ISysImageIndex int32
+	IIcon          int32
+	SzPath         [windows.MAX_PATH]uint16
+}
+
+// IsElevated determines whether the current process is running with
+// administrator privileges.
+func IsElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
+
+// RelaunchElevated starts a new instance of the current executable with
+// administrator privileges, displaying the UAC prompt. The caller should exit
+// once it returns successfully. If the user declines the prompt, the error
+// wraps windows.ERROR_CANCELLED.
+func RelaunchElevated(args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return newErrorFrom("RelaunchElevated", "unable to determine executable", nil, err)
+	}
+	cwd, _ := os.Getwd()
+	escaped := make([]string, len(args))
+	for i, a := range args {
+		escaped[i] = windows.EscapeArg(a)
+	}
+	return shellExecute("RelaunchElevated", "runas", exe, strings.Join(escaped, " "), cwd)
+}
+
+// shieldBitmap returns a bitmap of the UAC shield suitable for menu items,
+// creating it the first time it is needed.
+func (w *WinTray) shieldBitmap() (win.HBITMAP, error) {
+	if w.hbmShield != 0 {
+		return w.hbmShield, nil
+	}
+	sii := &pSHSTOCKICONINFO{}
+	sii.CbSize = uint32(unsafe.Sizeof(*sii))
+	if hr, _, _ := pSHGetStockIconInfo.Call(
+		pSIID_SHIELD,
+		pSHGSI_ICON|pSHGSI_SMALLICON,
+		uintptr(unsafe.Pointer(sii)),
+	); hr != 0 {
+		return 0, newHRESULTError("AddShieldMenuItem", "unable to load shield icon", nil, hr)
+	}
+	defer win.DestroyIcon(sii.HIcon)
+
+	// Menus require a 32-bit bitmap with alpha in order to draw the icon
+	// without a background
+	var (
+		size = w.smallIconSize()
+		bits unsafe.Pointer
+		bih  = &win.BITMAPINFOHEADER{
+			BiWidth:    size,
+			BiHeight:   -size,
+			BiPlanes:   1,
+			BiBitCount: 32,
+		}
+	)
+	bih.BiSize = uint32(unsafe.Sizeof(*bih))
+	hdc := win.CreateCompatibleDC(0)
+	defer win.DeleteDC(hdc)
+	hbm := win.CreateDIBSection(hdc, bih, win.DIB_RGB_COLORS, &bits, 0, 0)
+	if hbm == 0 {
+		return 0, newError("AddShieldMenuItem", "unable to create bitmap", nil)
+	}
+	old := win.SelectObject(hdc, win.HGDIOBJ(hbm))
+	win.DrawIconEx(hdc, 0, 0, sii.HIcon, size, size, 0, 0, win.DI_NORMAL)
+	win.SelectObject(hdc, old)
+	w.hbmShield = hbm
+	return hbm, nil
+}
+
+// AddShieldMenuItem adds an item to the menu that displays the UAC shield,
+// indicating that the action requires elevation.
+func (w *WinTray) AddShieldMenuItem(text string, fn func()) error {
+	text, err := normalizeText("AddShieldMenuItem", text)
+	if err != nil {
+		return err
+	}
+	return w.invoke(func() error {
+		hbm, err := w.shieldBitmap()
+		if err != nil {
+			return err
+		}
+		id, err := w.newMenuId("AddShieldMenuItem")
+		if err != nil {
+			return err
+		}
+		if err := w.addMenuItem(id, text); err != nil {
+			return err
+		}
+		w.menuFns[id] = fn
+		mii := &win.MENUITEMINFO{
+			FMask:    win.MIIM_BITMAP,
+			HbmpItem: hbm,
+		}
+		mii.CbSize = uint32(unsafe.Sizeof(*mii))
+		if !win.SetMenuItemInfo(w.hmenu, id, false, mii) {
+			return newError("AddShieldMenuItem", "unable to set menu item bitmap", nil)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,144 @@
+package wintray
+
+import (
+	"errors"
+	"syscall"
+)
+
+var (
+	// ErrShellNotRunning indicates that the taskbar (and therefore the
+	// notification area) is not currently running, such as during login or
+	// after Explorer has crashed.
+	ErrShellNotRunning = errors.New("shell is not running")
+
+	// ErrShellRejected indicates that the shell is running but refused the
+	// request, such as when the notification area is full or busy.
+	ErrShellRejected = errors.New("shell rejected the request")
+
+	// ErrInvalidImage indicates that the provided image could not be loaded.
+	ErrInvalidImage = errors.New("invalid image")
+
+	// ErrTruncated indicates that text was too long and was truncated; the
+	// operation otherwise succeeded.
+	ErrTruncated = errors.New("text was truncated")
+
+	// ErrInvalidText indicates that text could not be displayed because it
+	// contains a null character or is not valid UTF-8 (such as a string
+	// containing an unpaired surrogate).
+	ErrInvalidText = errors.New("invalid text")
+
+	// ErrInvalidMenu indicates that a menu definition passed to
+	// LoadMenuFromJSON could not be parsed or refers to an unknown action.
+	ErrInvalidMenu = errors.New("invalid menu definition")
+
+	// ErrMenuFull indicates that no more items can be added to the menu
+	// because every command ID has been used.
+	ErrMenuFull = errors.New("menu is full")
+
+	// ErrNoMenuItem indicates that no menu item has the specified ID.
+	ErrNoMenuItem = errors.New("no such menu item")
+
+	// ErrNoStatus indicates that no status was defined with the specified
+	// name.
+	ErrNoStatus = errors.New("no such status")
+
+	// ErrSuppressed indicates that a notification was passed to the shell
+	// but will not be displayed, such as when the user is presenting or has
+	// turned off notifications.
+	ErrSuppressed = errors.New("notification was suppressed")
+
+	// ErrClipboardBusy indicates that another application has the clipboard
+	// open.
+	ErrClipboardBusy = errors.New("clipboard is in use by another application")
+
+	// ErrWebView2Unavailable indicates that WebView2Loader.dll could not be
+	// found or the WebView2 runtime is not installed.
+	ErrWebView2Unavailable = errors.New("WebView2 is not available")
+
+	// ErrAlreadyRunning indicates that another instance is already running.
+	ErrAlreadyRunning = errors.New("another instance is already running")
+
+	// ErrNoSingleInstance indicates that EnsureSingleInstance was not called.
+	ErrNoSingleInstance = errors.New("EnsureSingleInstance has not been called")
+
+	// ErrTempDirNotPrivate indicates that an icon could not be loaded because
+	// the directory used for temporary icon files already exists but is owned
+	// by another user or accessible to others.
+	ErrTempDirNotPrivate = errors.New("temporary directory is not private")
+
+	// ErrForegroundDenied indicates that Windows did not allow a window to be
+	// brought to the foreground.
+	ErrForegroundDenied = errors.New("window could not be brought to the foreground")
+
+	// ErrUnsupported is returned by every function on platforms other than
+	// Windows (except for icons created with NewFake).
+	ErrUnsupported = errors.New("not supported on this platform")
+)
+
+// Error describes an operation that failed along with the error code
+// reported by Windows. Use errors.Is to compare it against ErrShellNotRunning,
+// ErrShellRejected, ErrInvalidImage, or a specific windows.Errno.
+type Error struct {
+
+	// Op is the name of the operation that failed, such as "SetTip".
+	Op string
+
+	// Code is the value of GetLastError when the failure occurred; it is zero
+	// if Windows did not provide a code.
+	Code syscall.Errno
+
+	// Msg describes the failure.
+	Msg string
+
+	kind error
+}
+
+func (e *Error) Error() string {
+	s := "wintray: " + e.Op + ": " + e.Msg
+	if e.Code != 0 {
+		s += ": " + e.Code.Error()
+	}
+	return s
+}
+
+// Unwrap returns the Windows error code, if any.
+func (e *Error) Unwrap() error {
+	if e.Code == 0 {
+		return nil
+	}
+	return e.Code
+}
+
+// Is reports whether the error belongs to the category described by target.
+func (e *Error) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+// newErrorFrom creates an Error from an error returned by windows.Proc.Call or
+// a similar function.
+func newErrorFrom(op, msg string, kind error, err error) *Error {
+	e := &Error{
+		Op:   op,
+		Msg:  msg,
+		kind: kind,
+	}
+	if errno, ok := err.(syscall.Errno); ok {
+		e.Code = errno
+	}
+	return e
+}
+
+// newHRESULTError creates an Error from an HRESULT returned by a function. When
+// the HRESULT wraps a Win32 error code, the code is extracted.
+func newHRESULTError(op, msg string, kind error, hr uintptr) *Error {
+	code := syscall.Errno(uint32(hr))
+	if uint32(hr)&0xffff0000 == 0x80070000 {
+		code = syscall.Errno(uint32(hr) & 0xffff)
+	}
+	return &Error{
+		Op:   op,
+		Code: code,
+		Msg:  msg,
+		kind: kind,
+	}
+}
@@ -0,0 +1,92 @@
+package wintray
+
+// EventType identifies the kind of an Event.
+type EventType int
+
+const (
+
+	// EventIconClicked indicates that the icon was clicked with the left
+	// mouse button or selected with the keyboard.
+	EventIconClicked EventType = iota
+
+	// EventMenuOpened indicates that the context menu was opened.
+	EventMenuOpened
+
+	// EventItemSelected indicates that a menu item was selected; Item is
+	// the text of the item.
+	EventItemSelected
+
+	// EventNotificationClicked indicates that a notification was clicked.
+	EventNotificationClicked
+
+	// EventThemeChanged indicates that the Windows theme changed; Theme is
+	// the new theme.
+	EventThemeChanged
+
+	// EventExplorerRestarted indicates that the taskbar was recreated (such
+	// as after Explorer crashed) and the icon was added to it again.
+	EventExplorerRestarted
+)
+
+// eventBufferSize is the number of events that are buffered before further
+// events are dropped.
+const eventBufferSize = 32
+
+// Event describes activity on the icon, as delivered by Events.
+type Event struct {
+	Type  EventType
+	Item  string
+	Theme ThemeInfo
+}
+
+// Events returns a channel that receives the activity on the icon, as an
+// alternative to registering callbacks (which continue to be invoked as
+// well). Each call returns the same channel, which is closed when the icon
+// is closed. Events are buffered; if the application falls behind, further
+// events are dropped rather than blocking the icon.
+func (w *WinTray) Events() <-chan Event {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	if w.events == nil {
+		w.events = make(chan Event, eventBufferSize)
+		if w.eventsClosed {
+			close(w.events)
+		}
+	}
+	return w.events
+}
+
+// emit delivers an event to the channel returned by Events, if any.
+func (w *WinTray) emit(e Event) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	if w.events == nil || w.eventsClosed {
+		return
+	}
+	select {
+	case w.events <- e:
+	default:
+		w.debug("event dropped", "type", e.Type)
+	}
+}
+
+// closeEvents closes the channel returned by Events once the icon is closed.
+func (w *WinTray) closeEvents() {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	if w.events != nil && !w.eventsClosed {
+		close(w.events)
+	}
+	w.eventsClosed = true
+}
+
+// itemSelected emits EventItemSelected and invokes the function for the menu
+// item with the specified ID. It must be called on the UI thread.
+func (w *WinTray) itemSelected(id uint32, text string) {
+	fn, ok := w.menuFns[id]
+	if !ok {
+		return
+	}
+	w.emit(Event{Type: EventItemSelected, Item: text})
+	go w.invokeHandler(fn)
+}
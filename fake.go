@@ -0,0 +1,362 @@
+package wintray
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeCall records a call made to the backend of a fake icon.
+type FakeCall struct {
+
+	// Op is the name of the API function, such as "SetTip".
+	Op string
+
+	// Args contains the arguments passed to the function.
+	Args []any
+}
+
+// FakeMenuItem describes an item added to the menu of a fake icon.
+type FakeMenuItem struct {
+	Text      string
+	Separator bool
+	Disabled  bool
+	Checked   bool
+
+	id uint32
+}
+
+// FakeNotification describes a notification shown by a fake icon.
+type FakeNotification struct {
+	Info      string
+	InfoTitle string
+	Duration  time.Duration
+	Tag       string
+	Sound     Sound
+}
+
+// Fake is an in-memory backend for a WinTray created with NewFake. It
+// records the changes made to the icon and simulates user interaction so
+// that code using WinTray can be tested without a desktop session, including
+// on platforms other than Windows. Only the functions for the icon, tooltip,
+// menu, and notifications (and their Async and Batch variants) are supported.
+type Fake struct {
+	w         *WinTray
+	wakeChan  chan struct{}
+	closeChan chan struct{}
+
+	mutex         sync.Mutex
+	calls         []FakeCall
+	icon          []byte
+	tip           string
+	menuItems     []FakeMenuItem
+	hasHeader     bool
+	notifications []FakeNotification
+	suppressed    bool
+}
+
+// NewFake creates an icon that uses an in-memory backend instead of the
+// notification area. The returned Fake is used to inspect the icon and
+// simulate user interaction.
+func NewFake(opts ...Option) (*WinTray, *Fake) {
+	var (
+		f = &Fake{
+			wakeChan:  make(chan struct{}, 1),
+			closeChan: make(chan struct{}),
+		}
+		w = newWinTray(opts, func(w *WinTray) trayBackend {
+			f.w = w
+			return f
+		})
+		errChan = make(chan error)
+	)
+	go w.run(errChan)
+	<-errChan
+	return w, f
+}
+
+func (f *Fake) record(op string, args ...any) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, FakeCall{Op: op, Args: args})
+}
+
+// create applies the initial icon and tooltip set by options, which are not
+// recorded as calls.
+func (f *Fake) create() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.icon = f.w.initIcon
+	f.tip = f.w.initTip
+	return nil
+}
+
+func (f *Fake) loop() {
+	for {
+		select {
+		case <-f.wakeChan:
+			f.w.processMessages()
+		case <-f.closeChan:
+			return
+		}
+	}
+}
+
+func (f *Fake) wake() {
+	select {
+	case f.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// Handlers run synchronously on the calling goroutine, which is never the
+// goroutine running the loop
+func (f *Fake) onUIThread() bool {
+	return false
+}
+
+func (f *Fake) requestClose() {
+	f.record("Close")
+	close(f.closeChan)
+}
+
+func (f *Fake) setIcon(b []byte) error {
+	f.record("SetIconFromBytes", b)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.icon = b
+	return nil
+}
+
+func (f *Fake) setTip(text string) error {
+	f.record("SetTip", text)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.tip = text
+	return nil
+}
+
+func (f *Fake) addMenuItem(id uint32, text string) error {
+	f.record("AddMenuItem", text)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.menuItems = append(f.menuItems, FakeMenuItem{Text: text, id: id})
+	return nil
+}
+
+func (f *Fake) addMenuSeparator() error {
+	f.record("AddMenuSeparator")
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.menuItems = append(f.menuItems, FakeMenuItem{Separator: true})
+	return nil
+}
+
+// setMenuHeader mirrors the real backend by keeping the header and a
+// separator at the top of the menu.
+func (f *Fake) setMenuHeader(text string) error {
+	f.record("SetMenuHeader", text)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.hasHeader {
+		f.menuItems[0].Text = text
+		return nil
+	}
+	f.menuItems = append([]FakeMenuItem{
+		{Text: text, Disabled: true},
+		{Separator: true},
+	}, f.menuItems...)
+	f.hasHeader = true
+	return nil
+}
+
+func (f *Fake) showNotification(n *pDataShowNotification) error {
+	f.record("ShowNotification", n.Info, n.InfoTitle)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	notification := FakeNotification{
+		Info:      n.Info,
+		InfoTitle: n.InfoTitle,
+		Duration:  n.Duration,
+		Tag:       n.Tag,
+		Sound:     n.Sound,
+	}
+
+	// Like the real backend, a notification replaces the most recent one if
+	// they have the same tag
+	if last := len(f.notifications) - 1; n.Tag != "" && last >= 0 && f.notifications[last].Tag == n.Tag {
+		f.notifications[last] = notification
+	} else {
+		f.notifications = append(f.notifications, notification)
+	}
+	if f.suppressed {
+		return ErrSuppressed
+	}
+	return nil
+}
+
+func (f *Fake) findMenuItem(label string) (uint32, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, item := range f.menuItems {
+		if !item.Separator && !item.Disabled && item.Text == label {
+			return item.id, true
+		}
+	}
+	return 0, false
+}
+
+func (f *Fake) setMenuItemText(id uint32, text string) error {
+	f.record("SetMenuItemText", text)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, item := range f.menuItems {
+		if item.id == id {
+			f.menuItems[i].Text = text
+		}
+	}
+	return nil
+}
+
+func (f *Fake) checkMenuItem(id uint32, checked bool) error {
+	f.record("CheckMenuItem", id, checked)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, item := range f.menuItems {
+		if !item.Separator && item.id == id {
+			f.menuItems[i].Checked = checked
+			return nil
+		}
+	}
+	return newErrorFrom("AddSettingMenuItem", "unable to check menu item", ErrNoMenuItem, nil)
+}
+
+func (f *Fake) removeMenuItem(id uint32) error {
+	f.record("RemoveMenuItem", id)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, item := range f.menuItems {
+		if !item.Separator && item.id == id {
+			f.menuItems = append(f.menuItems[:i], f.menuItems[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *Fake) listMenuItems() []MenuItemInfo {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	items := make([]MenuItemInfo, len(f.menuItems))
+	for i, item := range f.menuItems {
+		items[i] = MenuItemInfo{
+			ID:        item.id,
+			Label:     item.Text,
+			Separator: item.Separator,
+			Enabled:   !item.Disabled,
+			Checked:   item.Checked,
+			Position:  i,
+		}
+	}
+	return items
+}
+
+func (f *Fake) simulate(event int, id uint32) error {
+	switch event {
+	case pSIMULATE_MENU_ITEM:
+		f.w.itemSelected(id, f.menuItemText(id))
+	case pSIMULATE_ICON_CLICK:
+		f.record("ClickIcon")
+		f.w.emit(Event{Type: EventIconClicked})
+	case pSIMULATE_NOTIFICATION_CLICK:
+		go f.w.notificationClicked()
+	}
+	return nil
+}
+
+func (f *Fake) menuItemText(id uint32) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, item := range f.menuItems {
+		if item.id == id {
+			return item.Text
+		}
+	}
+	return ""
+}
+
+// Calls returns the calls made to the backend in the order they were
+// processed.
+func (f *Fake) Calls() []FakeCall {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// Icon returns the data passed to the most recent call to SetIconFromBytes.
+func (f *Fake) Icon() []byte {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.icon
+}
+
+// Tip returns the current tooltip.
+func (f *Fake) Tip() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.tip
+}
+
+// MenuItems returns the items in the menu.
+func (f *Fake) MenuItems() []FakeMenuItem {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]FakeMenuItem(nil), f.menuItems...)
+}
+
+// Notifications returns the notifications that have been shown, excluding
+// those replaced by a later notification with the same tag.
+func (f *Fake) Notifications() []FakeNotification {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]FakeNotification(nil), f.notifications...)
+}
+
+// SetSuppressed simulates notifications being suppressed. While suppressed,
+// notifications are still recorded but ShowNotification returns
+// ErrSuppressed.
+func (f *Fake) SetSuppressed(suppressed bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.suppressed = suppressed
+}
+
+// ClickMenuItem simulates selecting the first menu item with the specified
+// text. The item's function runs on the calling goroutine and ClickMenuItem
+// returns once it completes; panics are handled as they would be for a real
+// icon.
+func (f *Fake) ClickMenuItem(text string) error {
+	id, ok := f.findMenuItem(text)
+	if !ok {
+		return fmt.Errorf("wintray: no menu item %q", text)
+	}
+	var fn func()
+	if err := f.w.invoke(func() error {
+		fn = f.w.menuFns[id]
+		return nil
+	}); err != nil {
+		return err
+	}
+	f.w.debug("menu item selected", "id", id)
+	if fn != nil {
+		f.w.emit(Event{Type: EventItemSelected, Item: text})
+		f.w.invokeHandler(fn)
+	}
+	return nil
+}
+
+// ClickNotification simulates clicking a notification, invoking the function
+// registered with OnNotificationClick on the calling goroutine.
+func (f *Fake) ClickNotification() {
+	f.w.notificationClicked()
+}
@@ -0,0 +1,141 @@
+package wintray_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nathan-osman/go-wintray"
+)
+
+func TestFakeClickMenuItem(t *testing.T) {
+	w, f := wintray.NewFake()
+	defer w.Close()
+	var clicked []string
+	for _, text := range []string{"Open", "Quit"} {
+		text := text
+		if err := w.AddMenuItem(text, func() {
+			clicked = append(clicked, text)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.ClickMenuItem("Quit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ClickMenuItem("Open"); err != nil {
+		t.Fatal(err)
+	}
+	if len(clicked) != 2 || clicked[0] != "Quit" || clicked[1] != "Open" {
+		t.Fatalf("clicked %v, want [Quit Open]", clicked)
+	}
+	if err := f.ClickMenuItem("Missing"); err == nil {
+		t.Fatal("clicking a missing item succeeded")
+	}
+}
+
+func TestFakeNotificationTag(t *testing.T) {
+	w, f := wintray.NewFake()
+	defer w.Close()
+	for _, n := range []struct {
+		info string
+		tag  string
+	}{
+		{"Downloading 10%", "progress"},
+		{"Downloading 50%", "progress"},
+		{"Connected", ""},
+		{"Downloading 90%", "progress"},
+		{"Downloading 100%", "progress"},
+	} {
+		if err := w.ShowNotification(n.info, "Title", wintray.WithTag(n.tag)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the most recent notification is replaced by one with the same tag
+	notifications := f.Notifications()
+	want := []string{"Downloading 50%", "Connected", "Downloading 100%"}
+	if len(notifications) != len(want) {
+		t.Fatalf("got %d notifications, want %d", len(notifications), len(want))
+	}
+	for i, n := range notifications {
+		if n.Info != want[i] {
+			t.Errorf("notification %d is %q, want %q", i, n.Info, want[i])
+		}
+	}
+}
+
+func TestFakeSetSuppressed(t *testing.T) {
+	w, f := wintray.NewFake()
+	defer w.Close()
+	f.SetSuppressed(true)
+	if err := w.ShowNotification("Suppressed", "Title"); !errors.Is(err, wintray.ErrSuppressed) {
+		t.Fatalf("got %v, want ErrSuppressed", err)
+	}
+	f.SetSuppressed(false)
+	if err := w.ShowNotification("Shown", "Title"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Suppressed notifications are still recorded
+	if n := len(f.Notifications()); n != 2 {
+		t.Fatalf("got %d notifications, want 2", n)
+	}
+}
+
+// silenceMenuItem returns whether the menu item added by AddSilenceMenuItem
+// is checked. Calling IsSilenced first ensures that updates posted by the
+// settings watcher have been applied.
+func silenceMenuItem(t *testing.T, w *wintray.WinTray, f *wintray.Fake) (silenced, checked bool) {
+	t.Helper()
+	silenced = w.IsSilenced()
+	for _, item := range f.MenuItems() {
+		if item.Text == "Mute notifications" {
+			return silenced, item.Checked
+		}
+	}
+	t.Fatal("menu item not found")
+	return
+}
+
+func TestFakeSilenceMenuItem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	s, err := wintray.OpenSettingsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, f := wintray.NewFake()
+	defer w.Close()
+	if err := w.AddSilenceMenuItem("Mute notifications", s); err != nil {
+		t.Fatal(err)
+	}
+	if silenced, checked := silenceMenuItem(t, w, f); silenced || checked {
+		t.Fatalf("got silenced = %v, checked = %v initially", silenced, checked)
+	}
+
+	// Calling SetSilenced updates the check mark and the stored setting
+	if err := w.SetSilenced(true); err != nil {
+		t.Fatal(err)
+	}
+	if silenced, checked := silenceMenuItem(t, w, f); !silenced || !checked {
+		t.Fatalf("got silenced = %v, checked = %v after SetSilenced", silenced, checked)
+	}
+	reopened, err := wintray.OpenSettingsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.Bool(wintray.SilencedKey, false) {
+		t.Fatal("SetSilenced was not stored in the settings")
+	}
+
+	// Selecting the item toggles both
+	if err := f.ClickMenuItem("Mute notifications"); err != nil {
+		t.Fatal(err)
+	}
+	if silenced, checked := silenceMenuItem(t, w, f); silenced || checked {
+		t.Fatalf("got silenced = %v, checked = %v after click", silenced, checked)
+	}
+	if s.Bool(wintray.SilencedKey, true) {
+		t.Fatal("click was not stored in the settings")
+	}
+}
@@ -0,0 +1,257 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	pSHCreateItemFromParsingName = shell32.MustFindProc("SHCreateItemFromParsingName")
+
+	pCLSID_FileOpenDialog = mustGUID("{DC1C5A9C-E88A-4DDE-A5A1-60F82A20AEF7}")
+	pCLSID_FileSaveDialog = mustGUID("{C0B4E2F3-BA21-4773-8DBA-335EC946EB8B}")
+	pIID_IFileOpenDialog  = mustGUID("{D57C7288-D4AD-4768-BE02-9D969532D960}")
+	pIID_IFileSaveDialog  = mustGUID("{84BCCD23-5FDE-4CDB-AEA4-AF64B83D78AB}")
+	pIID_IShellItem       = mustGUID("{43826D1E-E718-42EE-BC55-A1E261C37BFE}")
+)
+
+// Vtable indices for IFileDialog, IFileOpenDialog, IShellItem and
+// IShellItemArray
+const (
+	pIFileDialog_Show                = 3
+	pIFileDialog_SetFileTypes        = 4
+	pIFileDialog_SetOptions          = 9
+	pIFileDialog_GetOptions          = 10
+	pIFileDialog_SetFolder           = 12
+	pIFileDialog_SetFileName         = 15
+	pIFileDialog_SetTitle            = 17
+	pIFileDialog_GetResult           = 20
+	pIFileDialog_SetDefaultExtension = 22
+	pIFileOpenDialog_GetResults      = 27
+
+	pIShellItem_GetDisplayName = 5
+
+	pIShellItemArray_GetCount  = 7
+	pIShellItemArray_GetItemAt = 8
+)
+
+const (
+	pFOS_OVERWRITEPROMPT  = 0x2
+	pFOS_PICKFOLDERS      = 0x20
+	pFOS_FORCEFILESYSTEM  = 0x40
+	pFOS_ALLOWMULTISELECT = 0x200
+	pFOS_PATHMUSTEXIST    = 0x800
+	pFOS_FILEMUSTEXIST    = 0x1000
+
+	pSIGDN_FILESYSPATH = 0x80058000
+
+	// HRESULT_FROM_WIN32(ERROR_CANCELLED)
+	pHRESULT_CANCELLED = 0x800704C7
+)
+
+type pCOMDLG_FILTERSPEC struct {
+	PszName *uint16
+	PszSpec *uint16
+}
+
+// shellItemPath returns the file system path of an IShellItem.
+func shellItemPath(op string, item *pComObject) (string, error) {
+	var p *uint16
+	if hr := item.call(
+		pIShellItem_GetDisplayName,
+		pSIGDN_FILESYSPATH,
+		uintptr(unsafe.Pointer(&p)),
+	); hr != 0 {
+		return "", newHRESULTError(op, "unable to retrieve path", nil, hr)
+	}
+	defer windows.CoTaskMemFree(unsafe.Pointer(p))
+	return windows.UTF16PtrToString(p), nil
+}
+
+// configure applies the options shared by all file dialogs.
+func (o *FileDialogOptions) configure(op string, d *pComObject, flags uintptr) error {
+	if err := checkText(op, o.Title, o.Folder, o.FileName, o.DefaultExtension); err != nil {
+		return err
+	}
+	for _, f := range o.Filters {
+		if err := checkText(op, f.Name, f.Pattern); err != nil {
+			return err
+		}
+	}
+	var current uintptr
+	if hr := d.call(pIFileDialog_GetOptions, uintptr(unsafe.Pointer(&current))); hr != 0 {
+		return newHRESULTError(op, "unable to read dialog options", nil, hr)
+	}
+	if hr := d.call(pIFileDialog_SetOptions, current|flags|pFOS_FORCEFILESYSTEM); hr != 0 {
+		return newHRESULTError(op, "unable to set dialog options", nil, hr)
+	}
+	if o.Title != "" {
+		d.call(pIFileDialog_SetTitle, uintptr(unsafe.Pointer(utf16PtrFromString(o.Title))))
+	}
+	if o.FileName != "" {
+		d.call(pIFileDialog_SetFileName, uintptr(unsafe.Pointer(utf16PtrFromString(o.FileName))))
+	}
+	if o.DefaultExtension != "" {
+		d.call(pIFileDialog_SetDefaultExtension, uintptr(unsafe.Pointer(utf16PtrFromString(o.DefaultExtension))))
+	}
+	if len(o.Filters) > 0 {
+		specs := make([]pCOMDLG_FILTERSPEC, len(o.Filters))
+		for i, f := range o.Filters {
+			specs[i] = pCOMDLG_FILTERSPEC{
+				PszName: utf16PtrFromString(f.Name),
+				PszSpec: utf16PtrFromString(f.Pattern),
+			}
+		}
+		if hr := d.call(
+			pIFileDialog_SetFileTypes,
+			uintptr(len(specs)),
+			uintptr(unsafe.Pointer(&specs[0])),
+		); hr != 0 {
+			return newHRESULTError(op, "unable to set file types", nil, hr)
+		}
+	}
+
+	// A folder that does not exist is ignored rather than treated as an error
+	if o.Folder != "" {
+		var item *pComObject
+		if hr, _, _ := pSHCreateItemFromParsingName.Call(
+			uintptr(unsafe.Pointer(utf16PtrFromString(o.Folder))),
+			0,
+			uintptr(unsafe.Pointer(pIID_IShellItem)),
+			uintptr(unsafe.Pointer(&item)),
+		); hr == 0 {
+			d.call(pIFileDialog_SetFolder, uintptr(unsafe.Pointer(item)))
+			item.release()
+		}
+	}
+	return nil
+}
+
+// showFileDialog displays a dialog and returns the selected paths; ok is
+// false if the dialog was cancelled. It must be called on the UI thread.
+func (w *WinTray) showFileDialog(op string, clsid, iid *windows.GUID, opts *FileDialogOptions, flags uintptr) ([]string, bool, error) {
+	if opts == nil {
+		opts = &FileDialogOptions{}
+	}
+	if err := w.initCOM(); err != nil {
+		return nil, false, err
+	}
+	d, err := createInstance(op, clsid, iid)
+	if err != nil {
+		return nil, false, err
+	}
+	defer d.release()
+	if err := opts.configure(op, d, flags); err != nil {
+		return nil, false, err
+	}
+
+	switch hr := d.call(pIFileDialog_Show, uintptr(w.hwnd)); uint32(hr) {
+	case 0:
+	case pHRESULT_CANCELLED:
+		return nil, false, nil
+	default:
+		return nil, false, newHRESULTError(op, "unable to show dialog", nil, hr)
+	}
+
+	// Open dialogs may return more than one item
+	if iid == pIID_IFileOpenDialog {
+		var items *pComObject
+		if hr := d.call(pIFileOpenDialog_GetResults, uintptr(unsafe.Pointer(&items))); hr != 0 {
+			return nil, false, newHRESULTError(op, "unable to retrieve selection", nil, hr)
+		}
+		defer items.release()
+		var count uint32
+		items.call(pIShellItemArray_GetCount, uintptr(unsafe.Pointer(&count)))
+		paths := make([]string, 0, count)
+		for i := uint32(0); i < count; i++ {
+			var item *pComObject
+			if hr := items.call(
+				pIShellItemArray_GetItemAt,
+				uintptr(i),
+				uintptr(unsafe.Pointer(&item)),
+			); hr != 0 {
+				return nil, false, newHRESULTError(op, "unable to retrieve selection", nil, hr)
+			}
+			p, err := shellItemPath(op, item)
+			item.release()
+			if err != nil {
+				return nil, false, err
+			}
+			paths = append(paths, p)
+		}
+		return paths, true, nil
+	}
+
+	var item *pComObject
+	if hr := d.call(pIFileDialog_GetResult, uintptr(unsafe.Pointer(&item))); hr != 0 {
+		return nil, false, newHRESULTError(op, "unable to retrieve selection", nil, hr)
+	}
+	defer item.release()
+	p, err := shellItemPath(op, item)
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{p}, true, nil
+}
+
+// PickFile displays a dialog for selecting one or more existing files and
+// waits for it to be closed. ok is false if the user cancelled the dialog.
+func (w *WinTray) PickFile(opts *FileDialogOptions) (paths []string, ok bool, err error) {
+	var flags uintptr = pFOS_FILEMUSTEXIST | pFOS_PATHMUSTEXIST
+	if opts != nil && opts.Multiple {
+		flags |= pFOS_ALLOWMULTISELECT
+	}
+	err = w.invoke(func() error {
+		paths, ok, err = w.showFileDialog(
+			"PickFile",
+			pCLSID_FileOpenDialog,
+			pIID_IFileOpenDialog,
+			opts,
+			flags,
+		)
+		return err
+	})
+	return
+}
+
+// PickFolder displays a dialog for selecting a folder and waits for it to be
+// closed. ok is false if the user cancelled the dialog.
+func (w *WinTray) PickFolder(opts *FileDialogOptions) (path string, ok bool, err error) {
+	err = w.invoke(func() error {
+		paths, pathsOk, err := w.showFileDialog(
+			"PickFolder",
+			pCLSID_FileOpenDialog,
+			pIID_IFileOpenDialog,
+			opts,
+			pFOS_PICKFOLDERS|pFOS_PATHMUSTEXIST,
+		)
+		if pathsOk && len(paths) > 0 {
+			path, ok = paths[0], true
+		}
+		return err
+	})
+	return
+}
+
+// SaveFileAs displays a dialog for choosing where to save a file and waits
+// for it to be closed. The user is asked to confirm overwriting an existing
+// file. ok is false if the user cancelled the dialog.
+func (w *WinTray) SaveFileAs(opts *FileDialogOptions) (path string, ok bool, err error) {
+	err = w.invoke(func() error {
+		paths, pathsOk, err := w.showFileDialog(
+			"SaveFileAs",
+			pCLSID_FileSaveDialog,
+			pIID_IFileSaveDialog,
+			opts,
+			pFOS_OVERWRITEPROMPT|pFOS_PATHMUSTEXIST,
+		)
+		if pathsOk && len(paths) > 0 {
+			path, ok = paths[0], true
+		}
+		return err
+	})
+	return
+}
@@ -0,0 +1,71 @@
+package wintray
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	firstRunMutex   sync.Mutex
+	firstRunResults = make(map[string]bool)
+	welcomeShown    = make(map[string]bool)
+)
+
+// IsFirstRun reports whether the application with the specified name is
+// being run by the current user for the first time. A marker is stored
+// alongside the application's settings (see OpenSettings) the first time
+// IsFirstRun is called, and the result is remembered, so every call made by
+// the same process returns the same value. If the marker cannot be stored,
+// IsFirstRun returns false so that onboarding is not repeated on every run.
+func IsFirstRun(appName string) bool {
+	firstRunMutex.Lock()
+	defer firstRunMutex.Unlock()
+	if v, ok := firstRunResults[appName]; ok {
+		return v
+	}
+	v := createFirstRunMarker(appName)
+	firstRunResults[appName] = v
+	return v
+}
+
+// createFirstRunMarker creates the marker file, returning true if it did not
+// already exist. O_EXCL ensures that only one of several instances started
+// at the same time sees the first run.
+func createFirstRunMarker(appName string) bool {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return false
+	}
+	dir = filepath.Join(dir, appName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false
+	}
+	f, err := os.OpenFile(
+		filepath.Join(dir, "firstrun"),
+		os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+		0600,
+	)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// ShowWelcomeNotification displays a notification the first time the
+// application is run by the current user, as determined by IsFirstRun, and
+// does nothing afterwards.
+func (w *WinTray) ShowWelcomeNotification(appName, info, infoTitle string, opts ...NotificationOption) error {
+	if !IsFirstRun(appName) {
+		return nil
+	}
+	firstRunMutex.Lock()
+	shown := welcomeShown[appName]
+	welcomeShown[appName] = true
+	firstRunMutex.Unlock()
+	if shown {
+		return nil
+	}
+	return w.ShowNotification(info, infoTitle, opts...)
+}
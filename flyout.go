@@ -0,0 +1,208 @@
+//go:build windows
+
+package wintray
+
+import (
+	"image"
+	"time"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// If the icon is clicked while a flyout is open, the flyout loses focus (and
+// is dismissed) before the click arrives; clicks within this interval of a
+// dismissal are ignored so that clicking the icon toggles the flyout
+const flyoutToggleInterval = 250 * time.Millisecond
+
+// Flyout is a small borderless window that is displayed next to the icon and
+// is hidden automatically when it loses focus, similar to the volume and
+// network flyouts in the notification area. Flyouts run on the same thread as
+// the icon they belong to and are destroyed when the icon is closed.
+type Flyout struct {
+	w    *WinTray
+	opts FlyoutOptions
+
+	// These are only accessed from the UI thread
+	hwnd        win.HWND
+	dismissedAt time.Time
+	resized     func()
+	destroyed   func()
+}
+
+func (f *Flyout) tray() *WinTray {
+	return f.w
+}
+
+func (f *Flyout) wndProc(hwnd win.HWND, msg uint32, wparam, lparam uintptr) uintptr {
+	switch msg {
+
+	// Hide the flyout when it loses focus
+	case win.WM_ACTIVATE:
+		if win.LOWORD(uint32(wparam)) == win.WA_INACTIVE && win.IsWindowVisible(hwnd) {
+			win.ShowWindow(hwnd, win.SW_HIDE)
+			f.dismissedAt = time.Now()
+			if f.opts.OnDismiss != nil {
+				go f.w.invokeHandler(f.opts.OnDismiss)
+			}
+		}
+		return 0
+
+	// Render the contents using the callback
+	case win.WM_PAINT:
+		if f.opts.Draw == nil {
+			break
+		}
+		var (
+			ps  = &win.PAINTSTRUCT{}
+			hdc = win.BeginPaint(hwnd, ps)
+			rc  = win.RECT{}
+		)
+		win.GetClientRect(hwnd, &rc)
+		f.opts.Draw(
+			uintptr(hdc),
+			image.Rect(
+				int(rc.Left),
+				int(rc.Top),
+				int(rc.Right),
+				int(rc.Bottom),
+			),
+		)
+		win.EndPaint(hwnd, ps)
+		return 0
+
+	// Allow hosted content to fill the window
+	case win.WM_SIZE:
+		if f.resized != nil {
+			f.resized()
+		}
+		return 0
+
+	case win.WM_DESTROY:
+		if f.destroyed != nil {
+			f.destroyed()
+		}
+
+	case win.WM_NCDESTROY:
+		delete(f.w.flyouts, f)
+	}
+	return win.DefWindowProc(hwnd, msg, wparam, lparam)
+}
+
+// show positions the flyout next to the icon and displays it.
+func (f *Flyout) show() {
+	var (
+		anchor = f.w.anchorRect()
+		width  = int32(f.opts.Width)
+		height = int32(f.opts.Height)
+		pt     = popupPosition(&anchor, width, height)
+	)
+	win.SetWindowPos(
+		f.hwnd,
+		win.HWND_TOPMOST,
+		pt.X,
+		pt.Y,
+		width,
+		height,
+		win.SWP_SHOWWINDOW,
+	)
+	win.SetForegroundWindow(f.hwnd)
+}
+
+// toggle shows the flyout unless it is visible or was just dismissed.
+func (f *Flyout) toggle() {
+	if win.IsWindowVisible(f.hwnd) {
+		win.ShowWindow(f.hwnd, win.SW_HIDE)
+		return
+	}
+	if time.Since(f.dismissedAt) < flyoutToggleInterval {
+		return
+	}
+	f.show()
+}
+
+// NewFlyout creates a flyout for the icon. The flyout is hidden until Show is
+// called (or the icon is clicked, if ShowOnClick is set).
+func (w *WinTray) NewFlyout(opts *FlyoutOptions) (*Flyout, error) {
+	f := &Flyout{
+		w:    w,
+		opts: *opts,
+	}
+	if err := w.invoke(func() error {
+
+		// All of the flyouts for an icon share a single class
+		className := w.className + "_Flyout"
+		if !w.flyoutClassRegistered {
+			if err := registerClass(
+				className,
+				win.CS_DROPSHADOW,
+				win.GetSysColorBrush(win.COLOR_WINDOW),
+			); err != nil {
+				return err
+			}
+			w.flyoutClassRegistered = true
+		}
+
+		hwnd, err := createWindow(
+			f,
+			className,
+			"",
+			win.WS_EX_TOPMOST|win.WS_EX_TOOLWINDOW,
+			win.WS_POPUP,
+			0,
+		)
+		if err != nil {
+			return err
+		}
+		f.hwnd = hwnd
+		w.flyouts[f] = struct{}{}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Show displays the flyout next to the icon and gives it focus.
+func (f *Flyout) Show() error {
+	return f.w.invoke(func() error {
+		f.show()
+		return nil
+	})
+}
+
+// Hide hides the flyout without invoking OnDismiss.
+func (f *Flyout) Hide() error {
+	return f.w.invoke(func() error {
+		win.ShowWindow(f.hwnd, win.SW_HIDE)
+		return nil
+	})
+}
+
+// Invalidate causes the flyout to be redrawn.
+func (f *Flyout) Invalidate() error {
+	return f.w.invoke(func() error {
+		win.InvalidateRect(f.hwnd, nil, true)
+		return nil
+	})
+}
+
+// SetSize changes the size of the flyout. If the flyout is visible, it is
+// repositioned next to the icon.
+func (f *Flyout) SetSize(width, height int) error {
+	return f.w.invoke(func() error {
+		f.opts.Width = width
+		f.opts.Height = height
+		if win.IsWindowVisible(f.hwnd) {
+			f.show()
+		}
+		return nil
+	})
+}
+
+// Destroy destroys the flyout. It cannot be used afterwards.
+func (f *Flyout) Destroy() error {
+	return f.w.invoke(func() error {
+		win.DestroyWindow(f.hwnd)
+		return nil
+	})
+}
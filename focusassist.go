@@ -0,0 +1,102 @@
+//go:build windows
+
+package wintray
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// pNtQueryWnfStateData is undocumented, so it is loaded lazily in case a
+// future version of Windows removes it.
+var pNtQueryWnfStateData = windows.NewLazySystemDLL("ntdll.dll").NewProc("NtQueryWnfStateData")
+
+const (
+	// WNF_SHEL_QUIETHOURS_ACTIVE_PROFILE_CHANGED holds the active Focus
+	// Assist profile as a DWORD
+	pWNF_SHEL_QUIETHOURS_ACTIVE_PROFILE_CHANGED uint64 = 0x0D83063EA3BF1C75
+
+	// Windows does not broadcast a message when Focus Assist changes, so the
+	// state is polled while a callback is registered
+	pFocusAssistPollInterval = 2 * time.Second
+)
+
+// FocusAssistState returns the current Focus Assist (Do Not Disturb in
+// Windows 11) setting, including when it was turned on automatically because
+// the user is presenting or playing a game. ErrUnsupported is returned on
+// versions of Windows without Focus Assist.
+func FocusAssistState() (FocusAssist, error) {
+	if err := pNtQueryWnfStateData.Find(); err != nil {
+		return FocusAssistOff, newErrorFrom("FocusAssistState", "unable to query Focus Assist", ErrUnsupported, err)
+	}
+	var (
+		stateName = pWNF_SHEL_QUIETHOURS_ACTIVE_PROFILE_CHANGED
+		stamp     uint32
+		data      uint32
+		size      = uint32(unsafe.Sizeof(data))
+	)
+	if r, _, _ := pNtQueryWnfStateData.Call(
+		uintptr(unsafe.Pointer(&stateName)),
+		0,
+		0,
+		uintptr(unsafe.Pointer(&stamp)),
+		uintptr(unsafe.Pointer(&data)),
+		uintptr(unsafe.Pointer(&size)),
+	); r != 0 {
+		var kind error
+		if windows.NTStatus(r) == windows.STATUS_OBJECT_NAME_NOT_FOUND {
+			kind = ErrUnsupported
+		}
+		return FocusAssistOff, newErrorFrom("FocusAssistState", "unable to query Focus Assist", kind, windows.NTStatus(r))
+	}
+	if size == 0 {
+		return FocusAssistOff, nil
+	}
+	return FocusAssist(data), nil
+}
+
+// OnFocusAssistChange registers a function to be invoked when Focus Assist is
+// turned on or off or changes mode. This can be used to hold back
+// notifications until the user is no longer busy. Passing nil stops
+// monitoring Focus Assist.
+func (w *WinTray) OnFocusAssistChange(fn func(state FocusAssist)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onFocusAssistChange = fn
+	if fn != nil && !w.watchingFocusAssist {
+		w.watchingFocusAssist = true
+		go w.watchFocusAssist()
+	}
+}
+
+// watchFocusAssist polls the Focus Assist state until the icon is closed or
+// the callback is removed.
+func (w *WinTray) watchFocusAssist() {
+	state, _ := FocusAssistState()
+	t := time.NewTicker(pFocusAssistPollInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.Done():
+			return
+		case <-t.C:
+		}
+		w.hooksMutex.Lock()
+		fn := w.onFocusAssistChange
+		if fn == nil {
+			w.watchingFocusAssist = false
+		}
+		w.hooksMutex.Unlock()
+		if fn == nil {
+			return
+		}
+		s, err := FocusAssistState()
+		if err != nil || s == state {
+			continue
+		}
+		state = s
+		w.invokeHandler(func() { fn(s) })
+	}
+}
@@ -0,0 +1,80 @@
+//go:build windows
+
+package wintray
+
+import (
+	"runtime"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+// BringWindowToFront shows, restores, and activates a window, such as the
+// application's main window when a menu item is selected. Windows only lets
+// a process take the foreground in certain circumstances and otherwise
+// ignores SetForegroundWindow without reporting an error, so if the first
+// attempt fails, the calling thread's input is briefly attached to that of
+// the foreground window and the attempt is repeated. If the window belongs to
+// another process, that process is also allowed to take the foreground
+// itself. ErrForegroundDenied is returned if the window still could not be
+// activated, in which case Windows flashes its taskbar button instead.
+func BringWindowToFront(hwnd uintptr) error {
+	h := win.HWND(hwnd)
+	if !win.IsWindow(h) {
+		return newErrorFrom("BringWindowToFront", "invalid window handle", nil, windows.ERROR_INVALID_WINDOW_HANDLE)
+	}
+	if win.IsIconic(h) {
+		win.ShowWindow(h, win.SW_RESTORE)
+	} else {
+		win.ShowWindow(h, win.SW_SHOW)
+	}
+
+	var pid uint32
+	targetThread, _ := windows.GetWindowThreadProcessId(windows.HWND(h), &pid)
+	if pid != windows.GetCurrentProcessId() {
+		pAllowSetForegroundWindow.Call(uintptr(pid))
+	}
+	if win.SetForegroundWindow(h) && win.GetForegroundWindow() == h {
+		return nil
+	}
+
+	// Input is attached per thread, so the goroutine must not move to
+	// another thread until it is detached
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	var (
+		currentThread = windows.GetCurrentThreadId()
+		fgThread, _   = windows.GetWindowThreadProcessId(windows.HWND(win.GetForegroundWindow()), nil)
+	)
+	for _, thread := range []uint32{fgThread, targetThread} {
+		if thread != 0 && thread != currentThread && win.AttachThreadInput(currentThread, thread, true) {
+			defer win.AttachThreadInput(currentThread, thread, false)
+		}
+	}
+	win.BringWindowToTop(h)
+	win.SetForegroundWindow(h)
+	if win.GetForegroundWindow() != h {
+		return newErrorFrom("BringWindowToFront", "window could not be activated", ErrForegroundDenied, nil)
+	}
+	return nil
+}
+
+// ToggleWindow hides a window if it is visible and not minimized and
+// otherwise brings it to the front with BringWindowToFront, which suits an
+// icon that shows and hides the application's main window when clicked. The
+// foreground window is not considered, since clicking the icon makes the
+// taskbar the foreground window. It reports whether the window is now shown.
+func ToggleWindow(hwnd uintptr) (bool, error) {
+	h := win.HWND(hwnd)
+	if !win.IsWindow(h) {
+		return false, newErrorFrom("ToggleWindow", "invalid window handle", nil, windows.ERROR_INVALID_WINDOW_HANDLE)
+	}
+	if win.IsWindowVisible(h) && !win.IsIconic(h) {
+		win.ShowWindow(h, win.SW_HIDE)
+		return false, nil
+	}
+	if err := BringWindowToFront(hwnd); err != nil {
+		return true, err
+	}
+	return true, nil
+}
@@ -0,0 +1,70 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// The distance between the points that CleanupGhostIcons moves the mouse to,
+// which is well below the size of an icon at any DPI
+const pGhostSweepStep = 4
+
+// CleanupGhostIcons removes stale icons left in the notification area by
+// applications (including earlier runs of this one) that ended without
+// removing their icons, such as after a crash. Explorer only notices that an
+// icon's window is gone when the mouse passes over it, so this simulates the
+// mouse moving over every icon in the notification area and the overflow
+// area. ErrUnsupported is returned if neither can be found, as on versions of
+// Windows whose taskbar is not built from toolbar controls (Windows 11 and
+// newer).
+func CleanupGhostIcons() error {
+	toolbars := notificationToolbars()
+	if len(toolbars) == 0 {
+		if win.FindWindow(utf16PtrFromString("Shell_TrayWnd"), nil) == 0 {
+			return newErrorFrom("CleanupGhostIcons", "unable to find the taskbar", ErrShellNotRunning, nil)
+		}
+		return newErrorFrom("CleanupGhostIcons", "unable to find the notification area", ErrUnsupported, nil)
+	}
+	for _, hwnd := range toolbars {
+		var rc win.RECT
+		if !win.GetClientRect(hwnd, &rc) {
+			continue
+		}
+		for y := rc.Top; y < rc.Bottom; y += pGhostSweepStep {
+			for x := rc.Left; x < rc.Right; x += pGhostSweepStep {
+				win.SendMessage(hwnd, win.WM_MOUSEMOVE, 0, uintptr(uint16(x))|uintptr(uint16(y))<<16)
+			}
+		}
+	}
+	return nil
+}
+
+// notificationToolbars returns the toolbar controls that hold the icons in
+// the notification area and the overflow area.
+func notificationToolbars() []win.HWND {
+	var (
+		toolbars []win.HWND
+		class    = utf16PtrFromString("ToolbarWindow32")
+	)
+
+	// The notification area holds its icons in a pager on Windows 7 and
+	// newer, and directly on older versions
+	if tray := win.FindWindow(utf16PtrFromString("Shell_TrayWnd"), nil); tray != 0 {
+		if notify := win.FindWindowEx(tray, 0, utf16PtrFromString("TrayNotifyWnd"), nil); notify != 0 {
+			parent := win.FindWindowEx(notify, 0, utf16PtrFromString("SysPager"), nil)
+			if parent == 0 {
+				parent = notify
+			}
+			for hwnd := win.FindWindowEx(parent, 0, class, nil); hwnd != 0; hwnd = win.FindWindowEx(parent, hwnd, class, nil) {
+				toolbars = append(toolbars, hwnd)
+			}
+		}
+	}
+	if overflow := win.FindWindow(utf16PtrFromString("NotifyIconOverflowWindow"), nil); overflow != 0 {
+		if hwnd := win.FindWindowEx(overflow, 0, class, nil); hwnd != 0 {
+			toolbars = append(toolbars, hwnd)
+		}
+	}
+	return toolbars
+}
@@ -2,7 +2,4 @@ module github.com/nathan-osman/go-wintray
 
 go 1.18
 
-require (
-	github.com/lxn/win v0.0.0-20210218163916-a377121e959e
-	golang.org/x/sys v0.12.0
-)
+require golang.org/x/sys v0.12.0
@@ -0,0 +1,83 @@
+package wintray
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// OnHandlerError registers a function to be invoked when a menu or
+// notification handler panics. The panic is recovered and converted into an
+// error, which is passed to fn along with the stack trace of the panicking
+// goroutine. It also receives errors from background work that has no caller
+// to return them to, in which case stack is nil. If no function is
+// registered, the error is logged instead.
+func (w *WinTray) OnHandlerError(fn func(err error, stack []byte)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onHandlerError = fn
+}
+
+// invokeHandler runs a user-provided handler, recovering from any panic that
+// occurs. It is normally run on its own goroutine.
+func (w *WinTray) invokeHandler(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.reportError(panicError("handler panicked", r), debug.Stack())
+		}
+	}()
+	fn()
+}
+
+// panicError converts the value passed to panic into an error.
+func panicError(msg string, r any) error {
+	if e, ok := r.(error); ok {
+		return fmt.Errorf("%s: %w", msg, e)
+	}
+	return fmt.Errorf("%s: %v", msg, r)
+}
+
+// OnFatal registers a function to be invoked if the UI thread panics (for
+// example, in a function passed to a Flyout). The icon cannot continue
+// safely, so it is removed and the event loop ends, after which Err returns
+// the error passed to fn. The function runs on a separate goroutine. If no
+// function is registered, the error is passed to the function registered with
+// OnHandlerError or logged.
+func (w *WinTray) OnFatal(fn func(err error, stack []byte)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onFatal = fn
+}
+
+// fatal is invoked on the UI thread when it recovers from a panic. Only the
+// first panic is reported, since shutting down may trigger more.
+func (w *WinTray) fatal(r any, stack []byte) {
+	w.fatalOnce.Do(func() {
+		err := panicError("UI thread panicked", r)
+		w.logError("UI thread panicked", "err", err)
+		w.setErr(err)
+		w.hooksMutex.Lock()
+		fn := w.onFatal
+		w.hooksMutex.Unlock()
+		if fn != nil {
+			go w.invokeHandler(func() { fn(err, stack) })
+		} else {
+			go w.reportError(err, stack)
+		}
+		w.requestClose()
+	})
+}
+
+// reportError passes an error that cannot be returned to the caller to the
+// function registered with OnHandlerError or logs it.
+func (w *WinTray) reportError(err error, stack []byte) {
+	w.logError("handler error", "err", err)
+	w.hooksMutex.Lock()
+	onHandlerError := w.onHandlerError
+	w.hooksMutex.Unlock()
+	if onHandlerError != nil {
+		onHandlerError(err, stack)
+	} else {
+		log.Printf("wintray: %s\n%s", err, stack)
+	}
+}
@@ -0,0 +1,88 @@
+//go:build windows
+
+package wintray
+
+import (
+	"time"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+const (
+	// pIDT_HEALTH identifies the timer that runs the health check
+	pIDT_HEALTH = 3
+)
+
+// Verify checks that the icon is still in the notification area. The shell
+// can lose icons without notice, such as when Explorer hangs and is restarted
+// without broadcasting TaskbarCreated, so long-running applications may wish
+// to check periodically (see WithHealthCheck) and call Repair if the icon is
+// missing.
+func (w *WinTray) Verify() error {
+	return w.invoke(func() error {
+		return w.verify()
+	})
+}
+
+// Repair removes the icon from the notification area, if it is still there,
+// and adds it again along with the current icon and tooltip.
+func (w *WinTray) Repair() error {
+	return w.invoke(func() error {
+		return w.repair()
+	})
+}
+
+// verify modifies the icon without changing anything, which only succeeds if
+// the shell still knows about it.
+func (w *WinTray) verify() error {
+	nid := &win.NOTIFYICONDATA{
+		HWnd: w.hwnd,
+		UID:  w.iconId,
+	}
+	if !w.shellNotifyIcon(win.NIM_MODIFY, nid) {
+		w.setState(StateDegraded)
+		return newShellError("Verify", "icon is missing from the notification area")
+	}
+	if w.State() == StateDegraded {
+		w.setState(StateReady)
+	}
+	return nil
+}
+
+func (w *WinTray) repair() error {
+	w.destroyTrayIcon(w.hwnd, w.iconId)
+	if err := w.createTrayIcon(w.hwnd, w.iconId); err != nil {
+		w.setState(StateDegraded)
+		return err
+	}
+	w.setState(StateReady)
+	return nil
+}
+
+// startHealthCheck starts the timer requested by WithHealthCheck. The icon is
+// still usable without it, so failure is only logged.
+func (w *WinTray) startHealthCheck() {
+	if w.healthInterval <= 0 {
+		return
+	}
+	if win.SetTimer(
+		w.hwnd,
+		pIDT_HEALTH,
+		uint32(w.healthInterval/time.Millisecond),
+		0,
+	) == 0 {
+		w.logError("unable to create timer", "err", newError("New", "unable to create timer", nil))
+	}
+}
+
+// checkHealth is invoked on the UI thread by the timer, repairing the icon if
+// it is missing.
+func (w *WinTray) checkHealth() {
+	if w.verify() == nil {
+		return
+	}
+	w.debug("icon is missing; repairing")
+	if err := w.repair(); err != nil {
+		w.reportError(err, nil)
+	}
+}
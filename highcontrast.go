@@ -0,0 +1,58 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// highContrastEnabled determines whether a high contrast theme is active.
+func highContrastEnabled() bool {
+	hc := &win.HIGHCONTRAST{}
+	hc.CbSize = uint32(unsafe.Sizeof(*hc))
+	if !win.SystemParametersInfo(win.SPI_GETHIGHCONTRAST, hc.CbSize, unsafe.Pointer(hc), 0) {
+		return false
+	}
+	return hc.DwFlags&win.HCF_HIGHCONTRASTON != 0
+}
+
+// OnHighContrastChange registers a function to be invoked when a high
+// contrast theme is turned on or off. The icon passed to WithHighContrastIcon
+// (if any) is swapped in automatically.
+func (w *WinTray) OnHighContrastChange(fn func(enabled bool)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onHighContrastChange = fn
+}
+
+// displayedIcon returns the icon data to display in place of b, which is the
+// high contrast variant while a high contrast theme is active.
+func (w *WinTray) displayedIcon(b []byte) []byte {
+	if w.highContrast && w.highContrastIcon != nil {
+		return w.highContrastIcon
+	}
+	return b
+}
+
+// highContrastChanged is invoked on the UI thread when the theme may have
+// changed, swapping the icon and notifying the application if high contrast
+// was turned on or off.
+func (w *WinTray) highContrastChanged() {
+	enabled := highContrastEnabled()
+	if enabled == w.highContrast {
+		return
+	}
+	w.highContrast = enabled
+	if w.highContrastIcon != nil {
+		w.reloadIcon()
+	}
+	w.hooksMutex.Lock()
+	fn := w.onHighContrastChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		go w.invokeHandler(func() { fn(enabled) })
+	}
+	w.themeChanged()
+}
@@ -0,0 +1,139 @@
+package wintray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+	errInvalidIcon = errors.New("not an ICO file or PNG image")
+)
+
+// pIconDirEntry is an entry in the directory at the start of an ICO file; the
+// image data follows the directory.
+type pIconDirEntry struct {
+	Width      uint8
+	Height     uint8
+	ColorCount uint8
+	Reserved   uint8
+	Planes     uint16
+	BitCount   uint16
+	BytesInRes uint32
+	Offset     uint32
+}
+
+// pIconImage is an image taken from an ICO file or PNG image.
+type pIconImage struct {
+	entry pIconDirEntry
+	data  []byte
+}
+
+// iconImages returns the images in an ICO file or the PNG image in b.
+func iconImages(b []byte) ([]pIconImage, error) {
+	if bytes.HasPrefix(b, pngSignature) {
+
+		// The dimensions are at the start of the IHDR chunk; sizes of 256
+		// pixels and above are stored as zero in the directory
+		if len(b) < 24 {
+			return nil, errInvalidIcon
+		}
+		var (
+			width  = binary.BigEndian.Uint32(b[16:20])
+			height = binary.BigEndian.Uint32(b[20:24])
+		)
+		if width > 256 {
+			width = 256
+		}
+		if height > 256 {
+			height = 256
+		}
+		return []pIconImage{{
+			entry: pIconDirEntry{
+				Width:    uint8(width),
+				Height:   uint8(height),
+				Planes:   1,
+				BitCount: 32,
+			},
+			data: b,
+		}}, nil
+	}
+	if len(b) < 6 ||
+		binary.LittleEndian.Uint16(b[0:2]) != 0 ||
+		binary.LittleEndian.Uint16(b[2:4]) != 1 {
+		return nil, errInvalidIcon
+	}
+	var (
+		count  = int(binary.LittleEndian.Uint16(b[4:6]))
+		images = make([]pIconImage, 0, count)
+	)
+	if len(b) < 6+16*count {
+		return nil, errInvalidIcon
+	}
+	for i := 0; i < count; i++ {
+		var e pIconDirEntry
+		if err := binary.Read(
+			bytes.NewReader(b[6+16*i:]),
+			binary.LittleEndian,
+			&e,
+		); err != nil {
+			return nil, err
+		}
+		end := uint64(e.Offset) + uint64(e.BytesInRes)
+		if end > uint64(len(b)) {
+			return nil, errInvalidIcon
+		}
+		images = append(images, pIconImage{
+			entry: e,
+			data:  b[e.Offset:end],
+		})
+	}
+	return images, nil
+}
+
+// mergeIcons combines the images in several ICO files and PNG images into a
+// single ICO file.
+func mergeIcons(icons [][]byte) ([]byte, error) {
+	var images []pIconImage
+	for _, b := range icons {
+		i, err := iconImages(b)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, i...)
+	}
+	if len(images) == 0 || len(images) > 0xffff {
+		return nil, errInvalidIcon
+	}
+	var (
+		buf    = &bytes.Buffer{}
+		offset = 6 + 16*len(images)
+	)
+	binary.Write(buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))})
+	for _, i := range images {
+		e := i.entry
+		e.BytesInRes = uint32(len(i.data))
+		e.Offset = uint32(offset)
+		binary.Write(buf, binary.LittleEndian, &e)
+		offset += len(i.data)
+	}
+	for _, i := range images {
+		buf.Write(i.data)
+	}
+	return buf.Bytes(), nil
+}
+
+// SetIcons sets the icon from several images of different sizes, each of
+// which is the contents of either an ICO file (which may itself contain
+// several sizes) or a PNG image. The size that best fits the notification
+// area at the current DPI is displayed (and chosen again if the DPI changes),
+// and notifications shown with WithTrayIcon use the size that best fits them.
+func (w *WinTray) SetIcons(icons ...[]byte) error {
+	b, err := mergeIcons(icons)
+	if err != nil {
+		return newErrorFrom("SetIcons", "unable to combine icons", ErrInvalidImage, err)
+	}
+	return w.SetIconFromBytes(b)
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package wintray
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+const (
+	// pIDT_IDLE identifies the timer that checks for user activity
+	pIDT_IDLE = 1
+
+	pIdlePollInterval = time.Second
+)
+
+// idleDuration returns the time since the last keyboard or mouse input in the
+// current session.
+func idleDuration(op string) (time.Duration, error) {
+	lii := &win.LASTINPUTINFO{
+		CbSize: uint32(unsafe.Sizeof(win.LASTINPUTINFO{})),
+	}
+	if !win.GetLastInputInfo(lii) {
+		return 0, newError(op, "unable to get last input time", nil)
+	}
+
+	// The tick count wraps around after 49.7 days, which the unsigned
+	// subtraction accounts for
+	return time.Duration(win.GetTickCount()-lii.DwTime) * time.Millisecond, nil
+}
+
+// LastInputTime returns the time of the last keyboard or mouse input in the
+// current session.
+func LastInputTime() (time.Time, error) {
+	d, err := idleDuration("LastInputTime")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-d), nil
+}
+
+// OnIdle registers a function to be invoked with true once the user has not
+// used the keyboard or mouse for the specified duration, and with false when
+// they do so again. Activity is checked once per second. Passing nil stops
+// monitoring activity.
+func (w *WinTray) OnIdle(threshold time.Duration, fn func(idle bool)) error {
+	w.hooksMutex.Lock()
+	w.onIdle = fn
+	w.hooksMutex.Unlock()
+	return w.invoke(func() error {
+		w.idleThreshold = threshold
+		w.idle = false
+		if fn == nil {
+			win.KillTimer(w.hwnd, pIDT_IDLE)
+			return nil
+		}
+		if win.SetTimer(
+			w.hwnd,
+			pIDT_IDLE,
+			uint32(pIdlePollInterval/time.Millisecond),
+			0,
+		) == 0 {
+			return newError("OnIdle", "unable to create timer", nil)
+		}
+		return nil
+	})
+}
+
+// checkIdle is invoked on the UI thread by the timer, notifying the
+// application when the user becomes idle or active.
+func (w *WinTray) checkIdle() {
+	d, err := idleDuration("OnIdle")
+	if err != nil {
+		return
+	}
+	idle := d >= w.idleThreshold
+	if idle == w.idle {
+		return
+	}
+	w.idle = idle
+	w.hooksMutex.Lock()
+	fn := w.onIdle
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		go w.invokeHandler(func() { fn(idle) })
+	}
+}
@@ -0,0 +1,201 @@
+//go:build windows
+
+package wintray
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+const (
+
+	// pActivateMagic identifies WM_COPYDATA messages sent by
+	// EnsureSingleInstance; it was changed along with the encoding of the
+	// arguments so that older instances ignore the new format
+	pActivateMagic = 0x47575442
+
+	pSMTO_ABORTIFHUNG = 0x2
+
+	// How long a second instance waits for the first to create its window
+	pActivateTimeout = 5 * time.Second
+)
+
+var (
+	pSetWindowTextW           = user32.MustFindProc("SetWindowTextW")
+	pSendMessageTimeoutW      = user32.MustFindProc("SendMessageTimeoutW")
+	pAllowSetForegroundWindow = user32.MustFindProc("AllowSetForegroundWindow")
+)
+
+var (
+	instanceMutex sync.Mutex
+	instanceId    string
+)
+
+type pCOPYDATASTRUCT struct {
+	DwData uintptr
+	CbData uint32
+	LpData uintptr
+}
+
+// activationTitle returns the title of the window that receives activations.
+func activationTitle(id string) string {
+	return "GoWinTray_Activate_" + id
+}
+
+// EnsureSingleInstance ensures that only one instance of the application
+// identified by id is running in the current session. If another instance is
+// running, the command-line arguments are forwarded to it (and delivered to
+// the function registered with OnActivate) and ErrAlreadyRunning is
+// returned; the caller should then exit.
+func EnsureSingleInstance(id string) error {
+	if err := checkText("EnsureSingleInstance", id); err != nil {
+		return err
+	}
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+	if instanceId == id {
+		return nil
+	}
+	_, err := windows.CreateMutex(
+		nil,
+		false,
+		utf16PtrFromString(`Local\GoWinTray_`+id),
+	)
+	switch {
+	case err == nil:
+
+		// The handle is intentionally leaked so that the mutex lives for
+		// as long as the process
+		instanceId = id
+		return nil
+	case errors.Is(err, windows.ERROR_ALREADY_EXISTS):
+		if err := forwardActivation(id, os.Args[1:]); err != nil {
+			return err
+		}
+		return ErrAlreadyRunning
+	default:
+		return newErrorFrom("EnsureSingleInstance", "unable to create mutex", nil, err)
+	}
+}
+
+// forwardActivation sends the arguments to the running instance, waiting for
+// it to create its window if necessary.
+func forwardActivation(id string, args []string) error {
+	title := utf16PtrFromString(activationTitle(id))
+	var hwnd win.HWND
+	for deadline := time.Now().Add(pActivateTimeout); ; {
+		if hwnd = win.FindWindow(nil, title); hwnd != 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			return newError("EnsureSingleInstance", "running instance did not respond", ErrAlreadyRunning)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Allow the running instance to bring its windows to the foreground
+	var pid uint32
+	windows.GetWindowThreadProcessId(windows.HWND(hwnd), &pid)
+	pAllowSetForegroundWindow.Call(uintptr(pid))
+
+	var (
+		data = encodeArgs(args)
+		b    []byte
+	)
+	if len(data) > 0 {
+		b = unsafe.Slice((*byte)(unsafe.Pointer(&data[0])), len(data)*2)
+	}
+	if err := sendCopyData(hwnd, pActivateMagic, b); err != nil {
+		return newErrorFrom("EnsureSingleInstance", "unable to contact running instance", ErrAlreadyRunning, err)
+	}
+	return nil
+}
+
+// sendCopyData sends WM_COPYDATA to a window, giving up if the window does
+// not respond within pActivateTimeout.
+func sendCopyData(hwnd win.HWND, dwData uintptr, data []byte) error {
+	cds := &pCOPYDATASTRUCT{
+		DwData: dwData,
+		CbData: uint32(len(data)),
+	}
+	if len(data) > 0 {
+		cds.LpData = uintptr(unsafe.Pointer(&data[0]))
+	}
+	var result uintptr
+	if r, _, err := pSendMessageTimeoutW.Call(
+		uintptr(hwnd),
+		win.WM_COPYDATA,
+		0,
+		uintptr(unsafe.Pointer(cds)),
+		pSMTO_ABORTIFHUNG,
+		uintptr(pActivateTimeout/time.Millisecond),
+		uintptr(unsafe.Pointer(&result)),
+	); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// OnActivate registers a function to be invoked when another instance of the
+// application is launched. The function receives the command-line arguments
+// of the new instance (excluding the executable). EnsureSingleInstance must
+// have been called first.
+func (w *WinTray) OnActivate(fn func(args []string)) error {
+	instanceMutex.Lock()
+	id := instanceId
+	instanceMutex.Unlock()
+	if id == "" {
+		return ErrNoSingleInstance
+	}
+	w.hooksMutex.Lock()
+	w.onActivate = fn
+	w.hooksMutex.Unlock()
+	return w.invoke(func() error {
+		title := ""
+		if fn != nil {
+			title = activationTitle(id)
+		}
+		if r, _, _ := pSetWindowTextW.Call(
+			uintptr(w.hwnd),
+			uintptr(unsafe.Pointer(utf16PtrFromString(title))),
+		); r == 0 {
+			return newError("OnActivate", "unable to set window title", nil)
+		}
+		return nil
+	})
+}
+
+// copyData is invoked on the UI thread when WM_COPYDATA is received and
+// returns whether the message was handled.
+func (w *WinTray) copyData(lparam uintptr) bool {
+	cds := (*pCOPYDATASTRUCT)(paramPointer(lparam))
+	if cds.DwData != pActivateMagic {
+		return w.copyDataReceived(cds)
+	}
+	w.hooksMutex.Lock()
+	fn := w.onActivate
+	w.hooksMutex.Unlock()
+	if fn == nil {
+		return false
+	}
+
+	// The data is only valid until the message returns, so it is decoded
+	// here rather than in the handler
+	var data []uint16
+	if cds.CbData > 0 {
+		data = unsafe.Slice((*uint16)(paramPointer(cds.LpData)), cds.CbData/2)
+	}
+	args, err := decodeArgs(data)
+	if err != nil {
+		w.debug("ignoring activation", "err", err)
+		return false
+	}
+	go w.invokeHandler(func() { fn(args) })
+	return true
+}
@@ -0,0 +1,271 @@
+//go:build windows
+
+package win
+
+// Window messages
+const (
+	WM_NULL            = 0x0000
+	WM_CREATE          = 0x0001
+	WM_DESTROY         = 0x0002
+	WM_SIZE            = 0x0005
+	WM_ACTIVATE        = 0x0006
+	WM_GETTEXT         = 0x000D
+	WM_GETTEXTLENGTH   = 0x000E
+	WM_PAINT           = 0x000F
+	WM_CLOSE           = 0x0010
+	WM_QUIT            = 0x0012
+	WM_SETTINGCHANGE   = 0x001A
+	WM_TIMECHANGE      = 0x001E
+	WM_DRAWITEM        = 0x002B
+	WM_MEASUREITEM     = 0x002C
+	WM_SETFONT         = 0x0030
+	WM_COPYDATA        = 0x004A
+	WM_CONTEXTMENU     = 0x007B
+	WM_DISPLAYCHANGE   = 0x007E
+	WM_NCDESTROY       = 0x0082
+	WM_COMMAND         = 0x0111
+	WM_TIMER           = 0x0113
+	WM_MENUSELECT      = 0x011F
+	WM_MOUSEMOVE       = 0x0200
+	WM_LBUTTONUP       = 0x0202
+	WM_RBUTTONUP       = 0x0205
+	WM_POWERBROADCAST  = 0x0218
+	WM_DEVICECHANGE    = 0x0219
+	WM_DROPFILES       = 0x0233
+	WM_DPICHANGED      = 0x02E0
+	WM_THEMECHANGED    = 0x031A
+	WM_CLIPBOARDUPDATE = 0x031D
+	WM_USER            = 0x0400
+	WM_APP             = 0x8000
+)
+
+// Sent to windows registered with WTSRegisterSessionNotification
+const (
+	WM_WTSSESSION_CHANGE = 0x02B1
+)
+
+// SetLayeredWindowAttributes
+const (
+	LWA_ALPHA = 0x00000002
+)
+
+// WM_ACTIVATE state
+const (
+	WA_INACTIVE = 0
+)
+
+// Window styles
+const (
+	WS_POPUP   = 0x80000000
+	WS_CHILD   = 0x40000000
+	WS_VISIBLE = 0x10000000
+	WS_CAPTION = 0x00C00000
+	WS_BORDER  = 0x00800000
+	WS_SYSMENU = 0x00080000
+	WS_TABSTOP = 0x00010000
+)
+
+// Extended window styles
+const (
+	WS_EX_DLGMODALFRAME = 0x00000001
+	WS_EX_TOPMOST       = 0x00000008
+	WS_EX_ACCEPTFILES   = 0x00000010
+	WS_EX_TOOLWINDOW    = 0x00000080
+	WS_EX_LAYERED       = 0x00080000
+	WS_EX_NOACTIVATE    = 0x08000000
+)
+
+// Class styles
+const (
+	CS_DROPSHADOW = 0x00020000
+)
+
+// Button and edit control styles and messages
+const (
+	BS_PUSHBUTTON    = 0x00000000
+	BS_DEFPUSHBUTTON = 0x00000001
+	ES_AUTOHSCROLL   = 0x0080
+	EM_SETSEL        = 0x00B1
+)
+
+// Dialog box command IDs
+const (
+	IDOK     = 1
+	IDCANCEL = 2
+)
+
+// MessageBox flags
+const (
+	MB_SETFOREGROUND = 0x00010000
+)
+
+// ShowWindow commands
+const (
+	SW_HIDE    = 0
+	SW_SHOW    = 5
+	SW_RESTORE = 9
+)
+
+// SetWindowPos
+const (
+	HWND_TOPMOST   = ^HWND(0)
+	SWP_NOACTIVATE = 0x0010
+	SWP_SHOWWINDOW = 0x0040
+)
+
+// PeekMessage
+const (
+	PM_REMOVE = 0x0001
+)
+
+// System colors
+const (
+	COLOR_MENU          = 4
+	COLOR_WINDOW        = 5
+	COLOR_MENUTEXT      = 7
+	COLOR_HIGHLIGHT     = 13
+	COLOR_HIGHLIGHTTEXT = 14
+	COLOR_BTNFACE       = 15
+	COLOR_GRAYTEXT      = 17
+	COLOR_INFOTEXT      = 23
+	COLOR_INFOBK        = 24
+)
+
+// System metrics
+const (
+	SM_CXICON            = 11
+	SM_CYMENU            = 15
+	SM_MENUDROPALIGNMENT = 40
+	SM_CXSMICON          = 49
+	SM_CXMENUCHECK       = 71
+	SM_REMOTESESSION     = 0x1000
+)
+
+// SystemParametersInfo
+const (
+	SPI_GETNONCLIENTMETRICS = 0x0029
+	SPI_GETHIGHCONTRAST     = 0x0042
+	SPI_SETHIGHCONTRAST     = 0x0043
+	SPI_GETMESSAGEDURATION  = 0x2016
+)
+
+// HIGHCONTRAST flags
+const (
+	HCF_HIGHCONTRASTON = 0x00000001
+)
+
+// Menus
+const (
+	MF_BYCOMMAND    = 0x00000000
+	MF_UNCHECKED    = 0x00000000
+	MF_CHECKED      = 0x00000008
+	MF_POPUP        = 0x00000010
+	MF_MENUBARBREAK = 0x00000020
+	MF_MENUBREAK    = 0x00000040
+	MF_SEPARATOR    = 0x00000800
+
+	MFT_STRING    = 0x00000000
+	MFT_OWNERDRAW = 0x00000100
+	MFT_SEPARATOR = MF_SEPARATOR
+
+	ODT_MENU = 1
+
+	ODS_SELECTED = 0x0001
+	ODS_GRAYED   = 0x0002
+	ODS_DISABLED = 0x0004
+	ODS_CHECKED  = 0x0008
+	ODS_NOACCEL  = 0x0100
+
+	MFS_DISABLED = 0x00000003
+	MFS_CHECKED  = 0x00000008
+
+	MIIM_STATE   = 0x00000001
+	MIIM_ID      = 0x00000002
+	MIIM_SUBMENU = 0x00000004
+	MIIM_STRING  = 0x00000040
+	MIIM_BITMAP  = 0x00000080
+	MIIM_FTYPE   = 0x00000100
+
+	TPM_LEFTALIGN    = 0x0000
+	TPM_CENTERALIGN  = 0x0004
+	TPM_RIGHTALIGN   = 0x0008
+	TPM_TOPALIGN     = 0x0000
+	TPM_VCENTERALIGN = 0x0010
+	TPM_BOTTOMALIGN  = 0x0020
+	TPM_HORIZONTAL   = 0x0000
+	TPM_VERTICAL     = 0x0040
+	TPM_LAYOUTRTL    = 0x8000
+)
+
+// Cursors and images
+const (
+	IDC_ARROW       = 32512
+	IMAGE_ICON      = 1
+	LR_LOADFROMFILE = 0x00000010
+	DI_NORMAL       = 0x0003
+)
+
+// GDI
+const (
+	LOGPIXELSY     = 90
+	TRANSPARENT    = 1
+	DIB_RGB_COLORS = 0
+	FW_BOLD        = 700
+)
+
+// DrawText formats
+const (
+	DT_LEFT       = 0x00000000
+	DT_CENTER     = 0x00000001
+	DT_RIGHT      = 0x00000002
+	DT_VCENTER    = 0x00000004
+	DT_SINGLELINE = 0x00000020
+	DT_EXPANDTABS = 0x00000040
+	DT_CALCRECT   = 0x00000400
+	DT_NOPREFIX   = 0x00000800
+	DT_HIDEPREFIX = 0x00100000
+)
+
+// Monitors
+const (
+	MONITOR_DEFAULTTONEAREST = 0x00000002
+)
+
+// Clipboard and global memory
+const (
+	CF_UNICODETEXT = 13
+	GMEM_MOVEABLE  = 0x0002
+)
+
+// Shell_NotifyIcon
+const (
+	NIM_ADD        = 0x00000000
+	NIM_MODIFY     = 0x00000001
+	NIM_DELETE     = 0x00000002
+	NIM_SETVERSION = 0x00000004
+
+	NIF_MESSAGE = 0x00000001
+	NIF_ICON    = 0x00000002
+	NIF_TIP     = 0x00000004
+	NIF_INFO    = 0x00000010
+	NIF_GUID    = 0x00000020
+	NIF_SHOWTIP = 0x00000080
+
+	NIIF_USER       = 0x00000004
+	NIIF_NOSOUND    = 0x00000010
+	NIIF_LARGE_ICON = 0x00000020
+
+	NOTIFYICON_VERSION   = 3
+	NOTIFYICON_VERSION_4 = 4
+)
+
+// SHQueryUserNotificationState
+const (
+	QUNS_NOT_PRESENT             = 1
+	QUNS_BUSY                    = 2
+	QUNS_RUNNING_D3D_FULL_SCREEN = 3
+	QUNS_PRESENTATION_MODE       = 4
+	QUNS_ACCEPTS_NOTIFICATIONS   = 5
+	QUNS_QUIET_TIME              = 6
+	QUNS_APP                     = 7
+)
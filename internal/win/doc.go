@@ -0,0 +1,5 @@
+// Package win contains the Win32 bindings used by go-wintray. Only the
+// functions, structures, and constants that the package needs are declared
+// here, and names follow the Windows SDK so that the MSDN documentation
+// applies directly.
+package win
@@ -0,0 +1,81 @@
+//go:build windows
+
+package win
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	gdi32 = windows.NewLazySystemDLL("gdi32.dll")
+
+	procCreateCompatibleDC  = gdi32.NewProc("CreateCompatibleDC")
+	procCreateDIBSection    = gdi32.NewProc("CreateDIBSection")
+	procCreateFontIndirectW = gdi32.NewProc("CreateFontIndirectW")
+	procCreateSolidBrush    = gdi32.NewProc("CreateSolidBrush")
+	procDeleteDC            = gdi32.NewProc("DeleteDC")
+	procDeleteObject        = gdi32.NewProc("DeleteObject")
+	procGetDeviceCaps       = gdi32.NewProc("GetDeviceCaps")
+	procSelectObject        = gdi32.NewProc("SelectObject")
+	procSetBkMode           = gdi32.NewProc("SetBkMode")
+	procSetTextColor        = gdi32.NewProc("SetTextColor")
+)
+
+func CreateCompatibleDC(hdc HDC) HDC {
+	r, _, _ := procCreateCompatibleDC.Call(uintptr(hdc))
+	return HDC(r)
+}
+
+func CreateDIBSection(hdc HDC, pbmih *BITMAPINFOHEADER, iUsage uint32, ppvBits *unsafe.Pointer, hSection HANDLE, dwOffset uint32) HBITMAP {
+	r, _, _ := procCreateDIBSection.Call(
+		uintptr(hdc),
+		uintptr(unsafe.Pointer(pbmih)),
+		uintptr(iUsage),
+		uintptr(unsafe.Pointer(ppvBits)),
+		uintptr(hSection),
+		uintptr(dwOffset),
+	)
+	return HBITMAP(r)
+}
+
+func CreateFontIndirect(lplf *LOGFONT) HFONT {
+	r, _, _ := procCreateFontIndirectW.Call(uintptr(unsafe.Pointer(lplf)))
+	return HFONT(r)
+}
+
+func CreateSolidBrush(color COLORREF) HBRUSH {
+	r, _, _ := procCreateSolidBrush.Call(uintptr(color))
+	return HBRUSH(r)
+}
+
+func DeleteDC(hdc HDC) bool {
+	r, _, _ := procDeleteDC.Call(uintptr(hdc))
+	return r != 0
+}
+
+func DeleteObject(hObject HGDIOBJ) bool {
+	r, _, _ := procDeleteObject.Call(uintptr(hObject))
+	return r != 0
+}
+
+func GetDeviceCaps(hdc HDC, nIndex int32) int32 {
+	r, _, _ := procGetDeviceCaps.Call(uintptr(hdc), uintptr(nIndex))
+	return int32(r)
+}
+
+func SelectObject(hdc HDC, hgdiobj HGDIOBJ) HGDIOBJ {
+	r, _, _ := procSelectObject.Call(uintptr(hdc), uintptr(hgdiobj))
+	return HGDIOBJ(r)
+}
+
+func SetBkMode(hdc HDC, iBkMode int32) int32 {
+	r, _, _ := procSetBkMode.Call(uintptr(hdc), uintptr(iBkMode))
+	return int32(r)
+}
+
+func SetTextColor(hdc HDC, crColor COLORREF) COLORREF {
+	r, _, _ := procSetTextColor.Call(uintptr(hdc), uintptr(crColor))
+	return COLORREF(r)
+}
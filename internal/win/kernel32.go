@@ -0,0 +1,56 @@
+//go:build windows
+
+package win
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procGetModuleHandleW = kernel32.NewProc("GetModuleHandleW")
+	procGetTickCount     = kernel32.NewProc("GetTickCount")
+	procGlobalAlloc      = kernel32.NewProc("GlobalAlloc")
+	procGlobalFree       = kernel32.NewProc("GlobalFree")
+	procGlobalLock       = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock     = kernel32.NewProc("GlobalUnlock")
+	procMulDiv           = kernel32.NewProc("MulDiv")
+)
+
+func GetModuleHandle(lpModuleName *uint16) HINSTANCE {
+	r, _, _ := procGetModuleHandleW.Call(uintptr(unsafe.Pointer(lpModuleName)))
+	return HINSTANCE(r)
+}
+
+func GetTickCount() uint32 {
+	r, _, _ := procGetTickCount.Call()
+	return uint32(r)
+}
+
+func GlobalAlloc(uFlags uint32, dwBytes uintptr) HGLOBAL {
+	r, _, _ := procGlobalAlloc.Call(uintptr(uFlags), dwBytes)
+	return HGLOBAL(r)
+}
+
+func GlobalFree(hMem HGLOBAL) HGLOBAL {
+	r, _, _ := procGlobalFree.Call(uintptr(hMem))
+	return HGLOBAL(r)
+}
+
+func GlobalLock(hMem HGLOBAL) unsafe.Pointer {
+	r, _, _ := procGlobalLock.Call(uintptr(hMem))
+	return *(*unsafe.Pointer)(unsafe.Pointer(&r))
+}
+
+func GlobalUnlock(hMem HGLOBAL) bool {
+	r, _, _ := procGlobalUnlock.Call(uintptr(hMem))
+	return r != 0
+}
+
+func MulDiv(nNumber, nNumerator, nDenominator int32) int32 {
+	r, _, _ := procMulDiv.Call(uintptr(nNumber), uintptr(nNumerator), uintptr(nDenominator))
+	return int32(r)
+}
@@ -0,0 +1,43 @@
+//go:build windows
+
+package win
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func LOWORD(dw uint32) uint16 {
+	return uint16(dw)
+}
+
+func HIWORD(dw uint32) uint16 {
+	return uint16(dw >> 16)
+}
+
+func GET_X_LPARAM(lp uintptr) int32 {
+	return int32(int16(LOWORD(uint32(lp))))
+}
+
+func GET_Y_LPARAM(lp uintptr) int32 {
+	return int32(int16(HIWORD(uint32(lp))))
+}
+
+// MAKEINTRESOURCE converts an integer resource identifier into the pointer
+// form expected by functions such as LoadCursor.
+func MAKEINTRESOURCE(id uintptr) *uint16 {
+	return *(**uint16)(unsafe.Pointer(&id))
+}
+
+func UTF16PtrToString(s *uint16) string {
+	return windows.UTF16PtrToString(s)
+}
+
+// MoveMemory copies length bytes from source to destination.
+func MoveMemory(destination, source unsafe.Pointer, length uintptr) {
+	copy(
+		unsafe.Slice((*byte)(destination), length),
+		unsafe.Slice((*byte)(source), length),
+	)
+}
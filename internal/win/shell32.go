@@ -0,0 +1,26 @@
+//go:build windows
+
+package win
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	shell32 = windows.NewLazySystemDLL("shell32.dll")
+
+	procSHQueryUserNotificationState = shell32.NewProc("SHQueryUserNotificationState")
+	procShell_NotifyIconW            = shell32.NewProc("Shell_NotifyIconW")
+)
+
+func SHQueryUserNotificationState(pquns *int32) uintptr {
+	r, _, _ := procSHQueryUserNotificationState.Call(uintptr(unsafe.Pointer(pquns)))
+	return r
+}
+
+func Shell_NotifyIcon(dwMessage uint32, lpdata *NOTIFYICONDATA) bool {
+	r, _, _ := procShell_NotifyIconW.Call(uintptr(dwMessage), uintptr(unsafe.Pointer(lpdata)))
+	return r != 0
+}
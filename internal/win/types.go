@@ -0,0 +1,214 @@
+//go:build windows
+
+package win
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+type (
+	ATOM      uint16
+	BOOL      int32
+	COLORREF  uint32
+	HANDLE    uintptr
+	HGDIOBJ   HANDLE
+	HBITMAP   HGDIOBJ
+	HBRUSH    HGDIOBJ
+	HFONT     HGDIOBJ
+	HDC       HANDLE
+	HCURSOR   HANDLE
+	HGLOBAL   HANDLE
+	HICON     HANDLE
+	HINSTANCE HANDLE
+	HMENU     HANDLE
+	HMONITOR  HANDLE
+	HWND      HANDLE
+)
+
+const (
+	FALSE = 0
+	TRUE  = 1
+)
+
+const LF_FACESIZE = 32
+
+type POINT struct {
+	X, Y int32
+}
+
+type RECT struct {
+	Left, Top, Right, Bottom int32
+}
+
+type MSG struct {
+	HWnd    HWND
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      POINT
+}
+
+type WNDCLASSEX struct {
+	CbSize        uint32
+	Style         uint32
+	LpfnWndProc   uintptr
+	CbClsExtra    int32
+	CbWndExtra    int32
+	HInstance     HINSTANCE
+	HIcon         HICON
+	HCursor       HCURSOR
+	HbrBackground HBRUSH
+	LpszMenuName  *uint16
+	LpszClassName *uint16
+	HIconSm       HICON
+}
+
+type PAINTSTRUCT struct {
+	Hdc         HDC
+	FErase      BOOL
+	RcPaint     RECT
+	FRestore    BOOL
+	FIncUpdate  BOOL
+	RgbReserved [32]byte
+}
+
+type MEASUREITEMSTRUCT struct {
+	CtlType    uint32
+	CtlID      uint32
+	ItemID     uint32
+	ItemWidth  uint32
+	ItemHeight uint32
+	ItemData   uintptr
+}
+
+type DRAWITEMSTRUCT struct {
+	CtlType    uint32
+	CtlID      uint32
+	ItemID     uint32
+	ItemAction uint32
+	ItemState  uint32
+	HwndItem   HWND
+	HDC        HDC
+	RcItem     RECT
+	ItemData   uintptr
+}
+
+type MENUITEMINFO struct {
+	CbSize        uint32
+	FMask         uint32
+	FType         uint32
+	FState        uint32
+	WID           uint32
+	HSubMenu      HMENU
+	HbmpChecked   HBITMAP
+	HbmpUnchecked HBITMAP
+	DwItemData    uintptr
+	DwTypeData    *uint16
+	Cch           uint32
+	HbmpItem      HBITMAP
+}
+
+type LASTINPUTINFO struct {
+	CbSize uint32
+	DwTime uint32
+}
+
+type TPMPARAMS struct {
+	CbSize    uint32
+	RcExclude RECT
+}
+
+type MONITORINFO struct {
+	CbSize    uint32
+	RcMonitor RECT
+	RcWork    RECT
+	DwFlags   uint32
+}
+
+type LOGFONT struct {
+	LfHeight         int32
+	LfWidth          int32
+	LfEscapement     int32
+	LfOrientation    int32
+	LfWeight         int32
+	LfItalic         byte
+	LfUnderline      byte
+	LfStrikeOut      byte
+	LfCharSet        byte
+	LfOutPrecision   byte
+	LfClipPrecision  byte
+	LfQuality        byte
+	LfPitchAndFamily byte
+	LfFaceName       [LF_FACESIZE]uint16
+}
+
+// NONCLIENTMETRICS omits iPaddedBorderWidth, which means that CbSize is
+// accepted by every version of Windows.
+type NONCLIENTMETRICS struct {
+	CbSize           uint32
+	IBorderWidth     int32
+	IScrollWidth     int32
+	IScrollHeight    int32
+	ICaptionWidth    int32
+	ICaptionHeight   int32
+	LfCaptionFont    LOGFONT
+	ISmCaptionWidth  int32
+	ISmCaptionHeight int32
+	LfSmCaptionFont  LOGFONT
+	IMenuWidth       int32
+	IMenuHeight      int32
+	LfMenuFont       LOGFONT
+	LfStatusFont     LOGFONT
+	LfMessageFont    LOGFONT
+}
+
+type HIGHCONTRAST struct {
+	CbSize            uint32
+	DwFlags           uint32
+	LpszDefaultScheme *uint16
+}
+
+type BITMAPINFOHEADER struct {
+	BiSize          uint32
+	BiWidth         int32
+	BiHeight        int32
+	BiPlanes        uint16
+	BiBitCount      uint16
+	BiCompression   uint32
+	BiSizeImage     uint32
+	BiXPelsPerMeter int32
+	BiYPelsPerMeter int32
+	BiClrUsed       uint32
+	BiClrImportant  uint32
+}
+
+type DRAWTEXTPARAMS struct {
+	CbSize        uint32
+	ITabLength    int32
+	ILeftMargin   int32
+	IRightMargin  int32
+	UiLengthDrawn uint32
+}
+
+// NOTIFYICONDATA is the full Vista and newer structure, so CbSize should be
+// set to its size. UTimeoutOrVersion is the DUMMYUNIONNAME union: it holds
+// the balloon timeout in milliseconds for NIM_ADD and NIM_MODIFY, and the
+// version for NIM_SETVERSION.
+type NOTIFYICONDATA struct {
+	CbSize            uint32
+	HWnd              HWND
+	UID               uint32
+	UFlags            uint32
+	UCallbackMessage  uint32
+	HIcon             HICON
+	SzTip             [128]uint16
+	DwState           uint32
+	DwStateMask       uint32
+	SzInfo            [256]uint16
+	UTimeoutOrVersion uint32
+	SzInfoTitle       [64]uint16
+	DwInfoFlags       uint32
+	GuidItem          windows.GUID
+	HBalloonIcon      HICON
+}
@@ -0,0 +1,539 @@
+//go:build windows
+
+package win
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32 = windows.NewLazySystemDLL("user32.dll")
+
+	procAddClipboardFormatListener = user32.NewProc("AddClipboardFormatListener")
+	procAttachThreadInput          = user32.NewProc("AttachThreadInput")
+	procBeginPaint                 = user32.NewProc("BeginPaint")
+	procBringWindowToTop           = user32.NewProc("BringWindowToTop")
+	procCloseClipboard             = user32.NewProc("CloseClipboard")
+	procCreatePopupMenu            = user32.NewProc("CreatePopupMenu")
+	procCreateWindowExW            = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW             = user32.NewProc("DefWindowProcW")
+	procDeleteMenu                 = user32.NewProc("DeleteMenu")
+	procDestroyIcon                = user32.NewProc("DestroyIcon")
+	procDestroyMenu                = user32.NewProc("DestroyMenu")
+	procDestroyWindow              = user32.NewProc("DestroyWindow")
+	procDispatchMessageW           = user32.NewProc("DispatchMessageW")
+	procDrawIconEx                 = user32.NewProc("DrawIconEx")
+	procDrawTextExW                = user32.NewProc("DrawTextExW")
+	procEmptyClipboard             = user32.NewProc("EmptyClipboard")
+	procEndPaint                   = user32.NewProc("EndPaint")
+	procFillRect                   = user32.NewProc("FillRect")
+	procFindWindowW                = user32.NewProc("FindWindowW")
+	procFindWindowExW              = user32.NewProc("FindWindowExW")
+	procGetClientRect              = user32.NewProc("GetClientRect")
+	procGetClipboardData           = user32.NewProc("GetClipboardData")
+	procGetCursorPos               = user32.NewProc("GetCursorPos")
+	procGetDC                      = user32.NewProc("GetDC")
+	procGetForegroundWindow        = user32.NewProc("GetForegroundWindow")
+	procGetLastInputInfo           = user32.NewProc("GetLastInputInfo")
+	procGetMenuItemCount           = user32.NewProc("GetMenuItemCount")
+	procGetMenuItemID              = user32.NewProc("GetMenuItemID")
+	procGetMenuItemInfoW           = user32.NewProc("GetMenuItemInfoW")
+	procGetMenuItemRect            = user32.NewProc("GetMenuItemRect")
+	procGetMessageW                = user32.NewProc("GetMessageW")
+	procGetMonitorInfoW            = user32.NewProc("GetMonitorInfoW")
+	procGetSubMenu                 = user32.NewProc("GetSubMenu")
+	procGetSysColor                = user32.NewProc("GetSysColor")
+	procGetSysColorBrush           = user32.NewProc("GetSysColorBrush")
+	procGetSystemMetrics           = user32.NewProc("GetSystemMetrics")
+	procGetWindowRect              = user32.NewProc("GetWindowRect")
+	procInsertMenuItemW            = user32.NewProc("InsertMenuItemW")
+	procInvalidateRect             = user32.NewProc("InvalidateRect")
+	procIsClipboardFormatAvailable = user32.NewProc("IsClipboardFormatAvailable")
+	procIsDialogMessageW           = user32.NewProc("IsDialogMessageW")
+	procIsIconic                   = user32.NewProc("IsIconic")
+	procIsWindow                   = user32.NewProc("IsWindow")
+	procIsWindowVisible            = user32.NewProc("IsWindowVisible")
+	procKillTimer                  = user32.NewProc("KillTimer")
+	procLoadCursorW                = user32.NewProc("LoadCursorW")
+	procLoadImageW                 = user32.NewProc("LoadImageW")
+	procMessageBoxW                = user32.NewProc("MessageBoxW")
+	procOpenClipboard              = user32.NewProc("OpenClipboard")
+	procPeekMessageW               = user32.NewProc("PeekMessageW")
+	procPostMessageW               = user32.NewProc("PostMessageW")
+	procPostQuitMessage            = user32.NewProc("PostQuitMessage")
+	procRegisterClassExW           = user32.NewProc("RegisterClassExW")
+	procRegisterWindowMessageW     = user32.NewProc("RegisterWindowMessageW")
+	procReleaseDC                  = user32.NewProc("ReleaseDC")
+	procSendMessageW               = user32.NewProc("SendMessageW")
+	procSetClipboardData           = user32.NewProc("SetClipboardData")
+	procSetFocus                   = user32.NewProc("SetFocus")
+	procSetForegroundWindow        = user32.NewProc("SetForegroundWindow")
+	procSetMenuItemInfoW           = user32.NewProc("SetMenuItemInfoW")
+	procSetTimer                   = user32.NewProc("SetTimer")
+	procSetWindowPos               = user32.NewProc("SetWindowPos")
+	procShowWindow                 = user32.NewProc("ShowWindow")
+	procSystemParametersInfoW      = user32.NewProc("SystemParametersInfoW")
+	procTrackPopupMenu             = user32.NewProc("TrackPopupMenu")
+	procTrackPopupMenuEx           = user32.NewProc("TrackPopupMenuEx")
+	procTranslateMessage           = user32.NewProc("TranslateMessage")
+)
+
+func boolToUintptr(b bool) uintptr {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func AddClipboardFormatListener(hwnd HWND) bool {
+	r, _, _ := procAddClipboardFormatListener.Call(uintptr(hwnd))
+	return r != 0
+}
+
+func AttachThreadInput(idAttach, idAttachTo uint32, fAttach bool) bool {
+	r, _, _ := procAttachThreadInput.Call(
+		uintptr(idAttach),
+		uintptr(idAttachTo),
+		boolToUintptr(fAttach),
+	)
+	return r != 0
+}
+
+func BeginPaint(hwnd HWND, lpPaint *PAINTSTRUCT) HDC {
+	r, _, _ := procBeginPaint.Call(uintptr(hwnd), uintptr(unsafe.Pointer(lpPaint)))
+	return HDC(r)
+}
+
+func BringWindowToTop(hWnd HWND) bool {
+	r, _, _ := procBringWindowToTop.Call(uintptr(hWnd))
+	return r != 0
+}
+
+func CloseClipboard() bool {
+	r, _, _ := procCloseClipboard.Call()
+	return r != 0
+}
+
+func CreatePopupMenu() HMENU {
+	r, _, _ := procCreatePopupMenu.Call()
+	return HMENU(r)
+}
+
+func CreateWindowEx(dwExStyle uint32, lpClassName, lpWindowName *uint16, dwStyle uint32, x, y, nWidth, nHeight int32, hWndParent HWND, hMenu HMENU, hInstance HINSTANCE, lpParam unsafe.Pointer) HWND {
+	r, _, _ := procCreateWindowExW.Call(
+		uintptr(dwExStyle),
+		uintptr(unsafe.Pointer(lpClassName)),
+		uintptr(unsafe.Pointer(lpWindowName)),
+		uintptr(dwStyle),
+		uintptr(x),
+		uintptr(y),
+		uintptr(nWidth),
+		uintptr(nHeight),
+		uintptr(hWndParent),
+		uintptr(hMenu),
+		uintptr(hInstance),
+		uintptr(lpParam),
+	)
+	return HWND(r)
+}
+
+func DefWindowProc(hWnd HWND, Msg uint32, wParam, lParam uintptr) uintptr {
+	r, _, _ := procDefWindowProcW.Call(uintptr(hWnd), uintptr(Msg), wParam, lParam)
+	return r
+}
+
+func DeleteMenu(hMenu HMENU, uPosition, uFlags uint32) bool {
+	r, _, _ := procDeleteMenu.Call(uintptr(hMenu), uintptr(uPosition), uintptr(uFlags))
+	return r != 0
+}
+
+func DestroyIcon(hIcon HICON) bool {
+	r, _, _ := procDestroyIcon.Call(uintptr(hIcon))
+	return r != 0
+}
+
+func DestroyMenu(hMenu HMENU) bool {
+	r, _, _ := procDestroyMenu.Call(uintptr(hMenu))
+	return r != 0
+}
+
+func DestroyWindow(hWnd HWND) bool {
+	r, _, _ := procDestroyWindow.Call(uintptr(hWnd))
+	return r != 0
+}
+
+func DispatchMessage(msg *MSG) uintptr {
+	r, _, _ := procDispatchMessageW.Call(uintptr(unsafe.Pointer(msg)))
+	return r
+}
+
+func DrawIconEx(hdc HDC, xLeft, yTop int32, hIcon HICON, cxWidth, cyWidth int32, istepIfAniCur uint32, hbrFlickerFreeDraw HBRUSH, diFlags uint32) bool {
+	r, _, _ := procDrawIconEx.Call(
+		uintptr(hdc),
+		uintptr(xLeft),
+		uintptr(yTop),
+		uintptr(hIcon),
+		uintptr(cxWidth),
+		uintptr(cyWidth),
+		uintptr(istepIfAniCur),
+		uintptr(hbrFlickerFreeDraw),
+		uintptr(diFlags),
+	)
+	return r != 0
+}
+
+func DrawTextEx(hdc HDC, lpchText *uint16, cchText int32, lprc *RECT, dwDTFormat uint32, lpDTParams *DRAWTEXTPARAMS) int32 {
+	r, _, _ := procDrawTextExW.Call(
+		uintptr(hdc),
+		uintptr(unsafe.Pointer(lpchText)),
+		uintptr(cchText),
+		uintptr(unsafe.Pointer(lprc)),
+		uintptr(dwDTFormat),
+		uintptr(unsafe.Pointer(lpDTParams)),
+	)
+	return int32(r)
+}
+
+func EmptyClipboard() bool {
+	r, _, _ := procEmptyClipboard.Call()
+	return r != 0
+}
+
+func EndPaint(hwnd HWND, lpPaint *PAINTSTRUCT) bool {
+	r, _, _ := procEndPaint.Call(uintptr(hwnd), uintptr(unsafe.Pointer(lpPaint)))
+	return r != 0
+}
+
+func FillRect(hDC HDC, lprc *RECT, hbr HBRUSH) bool {
+	r, _, _ := procFillRect.Call(uintptr(hDC), uintptr(unsafe.Pointer(lprc)), uintptr(hbr))
+	return r != 0
+}
+
+func FindWindow(lpClassName, lpWindowName *uint16) HWND {
+	r, _, _ := procFindWindowW.Call(
+		uintptr(unsafe.Pointer(lpClassName)),
+		uintptr(unsafe.Pointer(lpWindowName)),
+	)
+	return HWND(r)
+}
+
+func FindWindowEx(hWndParent, hWndChildAfter HWND, lpszClass, lpszWindow *uint16) HWND {
+	r, _, _ := procFindWindowExW.Call(
+		uintptr(hWndParent),
+		uintptr(hWndChildAfter),
+		uintptr(unsafe.Pointer(lpszClass)),
+		uintptr(unsafe.Pointer(lpszWindow)),
+	)
+	return HWND(r)
+}
+
+func GetClientRect(hWnd HWND, rect *RECT) bool {
+	r, _, _ := procGetClientRect.Call(uintptr(hWnd), uintptr(unsafe.Pointer(rect)))
+	return r != 0
+}
+
+func GetClipboardData(uFormat uint32) HANDLE {
+	r, _, _ := procGetClipboardData.Call(uintptr(uFormat))
+	return HANDLE(r)
+}
+
+func GetCursorPos(lpPoint *POINT) bool {
+	r, _, _ := procGetCursorPos.Call(uintptr(unsafe.Pointer(lpPoint)))
+	return r != 0
+}
+
+func GetDC(hWnd HWND) HDC {
+	r, _, _ := procGetDC.Call(uintptr(hWnd))
+	return HDC(r)
+}
+
+func GetForegroundWindow() HWND {
+	r, _, _ := procGetForegroundWindow.Call()
+	return HWND(r)
+}
+
+func GetLastInputInfo(plii *LASTINPUTINFO) bool {
+	r, _, _ := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(plii)))
+	return r != 0
+}
+
+func GetMenuItemCount(hMenu HMENU) int32 {
+	r, _, _ := procGetMenuItemCount.Call(uintptr(hMenu))
+	return int32(r)
+}
+
+func GetMenuItemID(hMenu HMENU, nPos int32) uint32 {
+	r, _, _ := procGetMenuItemID.Call(uintptr(hMenu), uintptr(nPos))
+	return uint32(r)
+}
+
+func GetMenuItemInfo(hmenu HMENU, item uint32, fByPosition BOOL, lpmii *MENUITEMINFO) bool {
+	r, _, _ := procGetMenuItemInfoW.Call(
+		uintptr(hmenu),
+		uintptr(item),
+		uintptr(fByPosition),
+		uintptr(unsafe.Pointer(lpmii)),
+	)
+	return r != 0
+}
+
+func GetMenuItemRect(hWnd HWND, hMenu HMENU, uItem uint32, lprcItem *RECT) bool {
+	r, _, _ := procGetMenuItemRect.Call(
+		uintptr(hWnd),
+		uintptr(hMenu),
+		uintptr(uItem),
+		uintptr(unsafe.Pointer(lprcItem)),
+	)
+	return r != 0
+}
+
+func GetMessage(msg *MSG, hWnd HWND, msgFilterMin, msgFilterMax uint32) BOOL {
+	r, _, _ := procGetMessageW.Call(
+		uintptr(unsafe.Pointer(msg)),
+		uintptr(hWnd),
+		uintptr(msgFilterMin),
+		uintptr(msgFilterMax),
+	)
+	return BOOL(r)
+}
+
+func GetMonitorInfo(hMonitor HMONITOR, lpmi *MONITORINFO) bool {
+	r, _, _ := procGetMonitorInfoW.Call(uintptr(hMonitor), uintptr(unsafe.Pointer(lpmi)))
+	return r != 0
+}
+
+func GetSubMenu(hMenu HMENU, nPos int32) HMENU {
+	r, _, _ := procGetSubMenu.Call(uintptr(hMenu), uintptr(nPos))
+	return HMENU(r)
+}
+
+func GetSysColor(nIndex int) uint32 {
+	r, _, _ := procGetSysColor.Call(uintptr(nIndex))
+	return uint32(r)
+}
+
+func GetSysColorBrush(nIndex int) HBRUSH {
+	r, _, _ := procGetSysColorBrush.Call(uintptr(nIndex))
+	return HBRUSH(r)
+}
+
+func GetSystemMetrics(nIndex int32) int32 {
+	r, _, _ := procGetSystemMetrics.Call(uintptr(nIndex))
+	return int32(r)
+}
+
+func GetWindowRect(hWnd HWND, rect *RECT) bool {
+	r, _, _ := procGetWindowRect.Call(uintptr(hWnd), uintptr(unsafe.Pointer(rect)))
+	return r != 0
+}
+
+func InsertMenuItem(hMenu HMENU, uItem uint32, fByPosition bool, lpmii *MENUITEMINFO) bool {
+	r, _, _ := procInsertMenuItemW.Call(
+		uintptr(hMenu),
+		uintptr(uItem),
+		boolToUintptr(fByPosition),
+		uintptr(unsafe.Pointer(lpmii)),
+	)
+	return r != 0
+}
+
+func InvalidateRect(hWnd HWND, lpRect *RECT, bErase bool) bool {
+	r, _, _ := procInvalidateRect.Call(
+		uintptr(hWnd),
+		uintptr(unsafe.Pointer(lpRect)),
+		boolToUintptr(bErase),
+	)
+	return r != 0
+}
+
+func IsClipboardFormatAvailable(format uint32) bool {
+	r, _, _ := procIsClipboardFormatAvailable.Call(uintptr(format))
+	return r != 0
+}
+
+func IsDialogMessage(hWnd HWND, msg *MSG) bool {
+	r, _, _ := procIsDialogMessageW.Call(uintptr(hWnd), uintptr(unsafe.Pointer(msg)))
+	return r != 0
+}
+
+func IsIconic(hWnd HWND) bool {
+	r, _, _ := procIsIconic.Call(uintptr(hWnd))
+	return r != 0
+}
+
+func IsWindow(hWnd HWND) bool {
+	r, _, _ := procIsWindow.Call(uintptr(hWnd))
+	return r != 0
+}
+
+func IsWindowVisible(hWnd HWND) bool {
+	r, _, _ := procIsWindowVisible.Call(uintptr(hWnd))
+	return r != 0
+}
+
+func KillTimer(hWnd HWND, uIDEvent uintptr) bool {
+	r, _, _ := procKillTimer.Call(uintptr(hWnd), uIDEvent)
+	return r != 0
+}
+
+func LoadCursor(hInstance HINSTANCE, lpCursorName *uint16) HCURSOR {
+	r, _, _ := procLoadCursorW.Call(uintptr(hInstance), uintptr(unsafe.Pointer(lpCursorName)))
+	return HCURSOR(r)
+}
+
+func LoadImage(hinst HINSTANCE, lpszName *uint16, uType uint32, cxDesired, cyDesired int32, fuLoad uint32) HANDLE {
+	r, _, _ := procLoadImageW.Call(
+		uintptr(hinst),
+		uintptr(unsafe.Pointer(lpszName)),
+		uintptr(uType),
+		uintptr(cxDesired),
+		uintptr(cyDesired),
+		uintptr(fuLoad),
+	)
+	return HANDLE(r)
+}
+
+func MessageBox(hWnd HWND, lpText, lpCaption *uint16, uType uint32) int32 {
+	r, _, _ := procMessageBoxW.Call(
+		uintptr(hWnd),
+		uintptr(unsafe.Pointer(lpText)),
+		uintptr(unsafe.Pointer(lpCaption)),
+		uintptr(uType),
+	)
+	return int32(r)
+}
+
+func OpenClipboard(hWndNewOwner HWND) bool {
+	r, _, _ := procOpenClipboard.Call(uintptr(hWndNewOwner))
+	return r != 0
+}
+
+func PeekMessage(lpMsg *MSG, hWnd HWND, wMsgFilterMin, wMsgFilterMax, wRemoveMsg uint32) bool {
+	r, _, _ := procPeekMessageW.Call(
+		uintptr(unsafe.Pointer(lpMsg)),
+		uintptr(hWnd),
+		uintptr(wMsgFilterMin),
+		uintptr(wMsgFilterMax),
+		uintptr(wRemoveMsg),
+	)
+	return r != 0
+}
+
+func PostMessage(hWnd HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	r, _, _ := procPostMessageW.Call(uintptr(hWnd), uintptr(msg), wParam, lParam)
+	return r
+}
+
+func PostQuitMessage(exitCode int32) {
+	procPostQuitMessage.Call(uintptr(exitCode))
+}
+
+func RegisterClassEx(windowClass *WNDCLASSEX) ATOM {
+	r, _, _ := procRegisterClassExW.Call(uintptr(unsafe.Pointer(windowClass)))
+	return ATOM(r)
+}
+
+func RegisterWindowMessage(lpString *uint16) uint32 {
+	r, _, _ := procRegisterWindowMessageW.Call(uintptr(unsafe.Pointer(lpString)))
+	return uint32(r)
+}
+
+func ReleaseDC(hWnd HWND, hDC HDC) bool {
+	r, _, _ := procReleaseDC.Call(uintptr(hWnd), uintptr(hDC))
+	return r != 0
+}
+
+func SendMessage(hWnd HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	r, _, _ := procSendMessageW.Call(uintptr(hWnd), uintptr(msg), wParam, lParam)
+	return r
+}
+
+func SetClipboardData(uFormat uint32, hMem HANDLE) HANDLE {
+	r, _, _ := procSetClipboardData.Call(uintptr(uFormat), uintptr(hMem))
+	return HANDLE(r)
+}
+
+func SetFocus(hWnd HWND) HWND {
+	r, _, _ := procSetFocus.Call(uintptr(hWnd))
+	return HWND(r)
+}
+
+func SetForegroundWindow(hWnd HWND) bool {
+	r, _, _ := procSetForegroundWindow.Call(uintptr(hWnd))
+	return r != 0
+}
+
+func SetMenuItemInfo(hMenu HMENU, uItem uint32, fByPosition bool, lpmii *MENUITEMINFO) bool {
+	r, _, _ := procSetMenuItemInfoW.Call(
+		uintptr(hMenu),
+		uintptr(uItem),
+		boolToUintptr(fByPosition),
+		uintptr(unsafe.Pointer(lpmii)),
+	)
+	return r != 0
+}
+
+func SetTimer(hWnd HWND, nIDEvent uintptr, uElapse uint32, lpTimerFunc uintptr) uintptr {
+	r, _, _ := procSetTimer.Call(uintptr(hWnd), nIDEvent, uintptr(uElapse), lpTimerFunc)
+	return r
+}
+
+func SetWindowPos(hWnd, hWndInsertAfter HWND, x, y, width, height int32, flags uint32) bool {
+	r, _, _ := procSetWindowPos.Call(
+		uintptr(hWnd),
+		uintptr(hWndInsertAfter),
+		uintptr(x),
+		uintptr(y),
+		uintptr(width),
+		uintptr(height),
+		uintptr(flags),
+	)
+	return r != 0
+}
+
+func ShowWindow(hWnd HWND, nCmdShow int32) bool {
+	r, _, _ := procShowWindow.Call(uintptr(hWnd), uintptr(nCmdShow))
+	return r != 0
+}
+
+func SystemParametersInfo(uiAction, uiParam uint32, pvParam unsafe.Pointer, fWinIni uint32) bool {
+	r, _, _ := procSystemParametersInfoW.Call(
+		uintptr(uiAction),
+		uintptr(uiParam),
+		uintptr(pvParam),
+		uintptr(fWinIni),
+	)
+	return r != 0
+}
+
+func TrackPopupMenu(hMenu HMENU, uFlags uint32, x, y int32, nReserved int32, hWnd HWND, prcRect *RECT) uint32 {
+	r, _, _ := procTrackPopupMenu.Call(
+		uintptr(hMenu),
+		uintptr(uFlags),
+		uintptr(x),
+		uintptr(y),
+		uintptr(nReserved),
+		uintptr(hWnd),
+		uintptr(unsafe.Pointer(prcRect)),
+	)
+	return uint32(r)
+}
+
+func TrackPopupMenuEx(hMenu HMENU, fuFlags uint32, x, y int32, hWnd HWND, lptpm *TPMPARAMS) BOOL {
+	r, _, _ := procTrackPopupMenuEx.Call(
+		uintptr(hMenu),
+		uintptr(fuFlags),
+		uintptr(x),
+		uintptr(y),
+		uintptr(hWnd),
+		uintptr(unsafe.Pointer(lptpm)),
+	)
+	return BOOL(r)
+}
+
+func TranslateMessage(msg *MSG) bool {
+	r, _, _ := procTranslateMessage.Call(uintptr(unsafe.Pointer(msg)))
+	return r != 0
+}
@@ -0,0 +1,35 @@
+package wintray
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleInterrupt closes the icon gracefully when the process is asked to
+// stop, so that tools run from a console do not leave a ghost icon behind in
+// the notification area. This covers Ctrl+C and Ctrl+Break, closing the
+// console window, logging off, and shutting down (which Go delivers as
+// os.Interrupt and syscall.SIGTERM). The function registered with OnQuit is
+// run before the icon is closed, just as if the item added by AddQuitItem
+// were selected.
+//
+// The application is expected to exit once the icon has been closed (for
+// example, by waiting on Done). Once it is closed, signals are no longer
+// handled, so a second Ctrl+C ends the process immediately.
+func (w *WinTray) HandleInterrupt() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(c)
+		select {
+		case sig := <-c:
+			w.debug("received signal", "signal", sig)
+			w.invokeHandler(w.quit)
+
+			// quit closes the icon itself unless OnQuit's function panicked
+			w.Close()
+		case <-w.Done():
+		}
+	}()
+}
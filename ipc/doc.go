@@ -0,0 +1,26 @@
+// Package ipc lets other processes control a running tray icon over a named
+// pipe, such as a command-line companion to a tray application.
+//
+// The pipe is only accessible to the current user (and SYSTEM), and its name
+// includes the user's SID so that each user on a machine has their own.
+// Since SIDs are not secret, Listen fails if the pipe already exists and Send
+// only writes to a pipe served by a process running as the current user. Each
+// request names a command and its arguments; the built-in commands are
+// "notify" (text and an optional title), "set-tip" (text), and "quit".
+// Applications can add their own with Server.Handle.
+//
+// In the tray application:
+//
+//	w := wintray.New()
+//	s, err := ipc.Listen(w, "MyApp")
+//	if err != nil {
+//		return err
+//	}
+//	go s.Serve()
+//
+// In the command-line tool:
+//
+//	if _, err := ipc.Send("MyApp", "notify", "Build finished"); err != nil {
+//		return err
+//	}
+package ipc
@@ -0,0 +1,197 @@
+//go:build windows
+
+package ipc
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nathan-osman/go-wintray"
+	"github.com/nathan-osman/go-wintray/agent"
+	"golang.org/x/sys/windows"
+)
+
+const dialTimeout = 5 * time.Second
+
+// pipeName returns the name of the pipe for the current user.
+func pipeName(name string) (string, error) {
+	sid, err := currentUserSID()
+	if err != nil {
+		return "", err
+	}
+	return name + "." + sid, nil
+}
+
+func currentUserSID() (string, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return user.User.Sid.String(), nil
+}
+
+// Server accepts commands for a tray icon.
+type Server struct {
+	w        *wintray.WinTray
+	s        *agent.Server
+	mutex    sync.Mutex
+	handlers map[string]Handler
+}
+
+// Listen creates the pipe for the application with the specified name. The
+// built-in commands control w. If another process (such as one run by a
+// different user) has already created the pipe, agent.ErrInUse is returned.
+func Listen(w *wintray.WinTray, name string) (*Server, error) {
+	path, err := pipeName(name)
+	if err != nil {
+		return nil, err
+	}
+	sid, err := currentUserSID()
+	if err != nil {
+		return nil, err
+	}
+	as, err := agent.ListenSDDL(path, "D:P(A;;GA;;;SY)(A;;GA;;;"+sid+")")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		w: w,
+		s: as,
+	}
+	s.handlers = map[string]Handler{
+		CommandNotify: s.notify,
+		CommandSetTip: s.setTip,
+		CommandQuit:   s.quit,
+	}
+	return s, nil
+}
+
+func (s *Server) notify(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", ErrMissingArgument
+	}
+	title := ""
+	if len(args) > 1 {
+		title = args[1]
+	}
+	return "", s.w.ShowNotification(args[0], title)
+}
+
+func (s *Server) setTip(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", ErrMissingArgument
+	}
+	return "", s.w.SetTip(args[0])
+}
+
+func (s *Server) quit([]string) (string, error) {
+	s.w.Close()
+	return "", nil
+}
+
+// Handle registers a function for a command, replacing the built-in handler
+// if there is one. Passing nil removes the handler.
+func (s *Server) Handle(command string, fn Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if fn == nil {
+		delete(s.handlers, command)
+	} else {
+		s.handlers[command] = fn
+	}
+}
+
+// Serve accepts connections until Close is called or the icon is closed,
+// processing each one on its own goroutine. It returns agent.ErrClosed once
+// the server is closed.
+func (s *Server) Serve() error {
+	go func() {
+		<-s.w.Done()
+		s.Close()
+	}()
+	for {
+		c, err := s.s.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serve(c)
+	}
+}
+
+// serve processes requests from a single client until it disconnects.
+func (s *Server) serve(c *agent.Conn) {
+	defer c.Close()
+	for {
+		m, err := c.Receive()
+		if err != nil {
+			return
+		}
+		var args []string
+		if len(m.Data) > 0 {
+			if err := json.Unmarshal(m.Data, &args); err != nil {
+				c.Send(&agent.Message{Type: replyError, Text: err.Error()})
+				continue
+			}
+		}
+		s.mutex.Lock()
+		fn := s.handlers[m.Type]
+		s.mutex.Unlock()
+		if fn == nil {
+			c.Send(&agent.Message{Type: replyError, Text: ErrUnknownCommand.Error()})
+			continue
+		}
+		output, err := fn(args)
+		if err != nil {
+			c.Send(&agent.Message{Type: replyError, Text: err.Error()})
+			continue
+		}
+		if err := c.Send(&agent.Message{Type: replyOK, Text: output}); err != nil {
+			return
+		}
+	}
+}
+
+// Close stops accepting connections.
+func (s *Server) Close() error {
+	return s.s.Close()
+}
+
+// Send connects to the application with the specified name, sends a command,
+// and returns its output. If the command fails, the error reported by the
+// application is returned. Nothing is sent unless the application is running
+// as the current user; agent.ErrUntrustedServer is returned otherwise.
+func Send(name, command string, args ...string) (string, error) {
+	path, err := pipeName(name)
+	if err != nil {
+		return "", err
+	}
+	sid, err := currentUserSID()
+	if err != nil {
+		return "", err
+	}
+	c, err := agent.DialAs(path, dialTimeout, sid)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+	if user, err := c.ServerUser(); err != nil || user != sid {
+		return "", agent.ErrUntrustedServer
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	if err := c.Send(&agent.Message{Type: command, Data: data}); err != nil {
+		return "", err
+	}
+	m, err := c.Receive()
+	if err != nil {
+		return "", err
+	}
+	if m.Type == replyError {
+		return "", errors.New(m.Text)
+	}
+	return m.Text, nil
+}
@@ -0,0 +1,39 @@
+package ipc
+
+import (
+	"errors"
+)
+
+// Built-in commands.
+const (
+
+	// CommandNotify shows a notification. The first argument is the text and
+	// the optional second argument is the title.
+	CommandNotify = "notify"
+
+	// CommandSetTip sets the tooltip to the first argument.
+	CommandSetTip = "set-tip"
+
+	// CommandQuit closes the icon.
+	CommandQuit = "quit"
+)
+
+// Types of the messages sent in reply to a request.
+const (
+	replyOK    = "ok"
+	replyError = "error"
+)
+
+var (
+	// ErrUnknownCommand indicates that the server has no handler for the
+	// command.
+	ErrUnknownCommand = errors.New("ipc: unknown command")
+
+	// ErrMissingArgument indicates that a command was sent without a
+	// required argument.
+	ErrMissingArgument = errors.New("ipc: missing argument")
+)
+
+// Handler processes a command and returns the output that is sent back to
+// the client.
+type Handler func(args []string) (string, error)
@@ -0,0 +1,31 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"github.com/nathan-osman/go-wintray"
+)
+
+// These stubs allow applications that use this package to compile on
+// platforms other than Windows; every function fails with
+// wintray.ErrUnsupported.
+
+type Server struct{}
+
+func Listen(w *wintray.WinTray, name string) (*Server, error) {
+	return nil, wintray.ErrUnsupported
+}
+
+func (s *Server) Handle(command string, fn Handler) {}
+
+func (s *Server) Serve() error {
+	return wintray.ErrUnsupported
+}
+
+func (s *Server) Close() error {
+	return wintray.ErrUnsupported
+}
+
+func Send(name, command string, args ...string) (string, error) {
+	return "", wintray.ErrUnsupported
+}
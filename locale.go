@@ -0,0 +1,123 @@
+package wintray
+
+import (
+	"strings"
+)
+
+// Translator provides the text displayed by the icon in the user's language.
+// When an icon is created with WithTranslator, the text passed to AddMenuItem,
+// AddQuitItem, SetTip, SetStatus, and ShowNotification is treated as a key
+// and translated before it is displayed.
+type Translator interface {
+
+	// Translate returns the text for key in the locale identified by tag, a
+	// BCP 47 language tag such as "fr-CA" (or "" if SetLocale has not been
+	// called). Keys without a translation should be returned unchanged.
+	Translate(tag, key string) string
+}
+
+// TranslatorFunc adapts an ordinary function to the Translator interface.
+type TranslatorFunc func(tag, key string) string
+
+// Translate calls f(tag, key).
+func (f TranslatorFunc) Translate(tag, key string) string {
+	return f(tag, key)
+}
+
+// Keys for text supplied by the package itself.
+const (
+
+	// KeyAboutTitle is the title of the dialog shown by ShowAbout; "%s" is
+	// replaced with the name of the application.
+	KeyAboutTitle = "About %s"
+)
+
+// pRTLScripts are the ISO 15924 codes of scripts written from right to left.
+var pRTLScripts = []string{"arab", "hebr", "syrc", "thaa", "nkoo", "adlm", "rohg"}
+
+// pRTLLanguages are languages written from right to left by default.
+var pRTLLanguages = []string{"ar", "dv", "fa", "he", "iw", "ps", "sd", "ug", "ur", "yi"}
+
+// isRTL determines whether the language identified by tag is written from
+// right to left. A script subtag (as in "pa-Arab") takes precedence over the
+// language.
+func isRTL(tag string) bool {
+	subtags := strings.FieldsFunc(strings.ToLower(tag), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	if len(subtags) == 0 {
+		return false
+	}
+	for _, s := range subtags[1:] {
+		if len(s) == 4 {
+			for _, script := range pRTLScripts {
+				if s == script {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	for _, lang := range pRTLLanguages {
+		if subtags[0] == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// translate returns the text for key in the current locale.
+func (w *WinTray) translate(key string) string {
+	if w.translator == nil {
+		return key
+	}
+	w.localeMutex.Lock()
+	tag := w.locale
+	w.localeMutex.Unlock()
+	return w.translator.Translate(tag, key)
+}
+
+// rtlLocale reports whether the current locale is written from right to
+// left.
+func (w *WinTray) rtlLocale() bool {
+	w.localeMutex.Lock()
+	defer w.localeMutex.Unlock()
+	return isRTL(w.locale)
+}
+
+// SetLocale changes the locale passed to the Translator and relabels the
+// existing menu items and tooltip. Menus are laid out from right to left for
+// languages such as Arabic and Hebrew.
+func (w *WinTray) SetLocale(tag string) error {
+	return w.invoke(func() error {
+		w.localeMutex.Lock()
+		w.locale = tag
+		w.localeMutex.Unlock()
+		if w.translator == nil {
+			return nil
+		}
+		var errs []error
+		for id, key := range w.menuKeys {
+			text, err := normalizeText("SetLocale", w.translate(key))
+			if err == nil {
+				err = w.backend.setMenuItemText(id, text)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if w.tipIsKey {
+			text, err := normalizeText("SetLocale", w.translate(w.tipKey))
+			if err == nil {
+				err = w.backend.setTip(text)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) != 0 {
+			return &BatchError{Errors: errs}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package wintray
+
+// Logger receives diagnostic events from an icon. The arguments following
+// the message are alternating keys and values. *slog.Logger satisfies this
+// interface.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// WithLogger sends diagnostic events to l, including window creation, calls
+// to Shell_NotifyIcon, messages processed by the UI thread, menu
+// activations, and errors.
+func WithLogger(l Logger) Option {
+	return func(w *WinTray) {
+		w.logger = l
+	}
+}
+
+func (w *WinTray) debug(msg string, args ...any) {
+	if w.logger != nil {
+		w.logger.Debug(msg, args...)
+	}
+}
+
+func (w *WinTray) logError(msg string, args ...any) {
+	if w.logger != nil {
+		w.logger.Error(msg, args...)
+	}
+}
+
+// messageName returns the name of a message type for logging.
+func messageName(t int) string {
+	switch t {
+	case pMESSAGE_SET_ICON_FROM_BYTES:
+		return "SetIconFromBytes"
+	case pMESSAGE_SET_TIP:
+		return "SetTip"
+	case pMESSAGE_ADD_MENU_ITEM:
+		return "AddMenuItem"
+	case pMESSAGE_ADD_MENU_SEPARATOR:
+		return "AddMenuSeparator"
+	case pMESSAGE_SHOW_NOTIFICATION:
+		return "ShowNotification"
+	case pMESSAGE_BATCH:
+		return "Batch"
+	case pMESSAGE_INVOKE:
+		return "Invoke"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// AddMenuColumnBreak starts a new column in the menu, so that the next item
+// added appears at the top of it rather than below the last item. This suits
+// grid-like choices such as colors or profiles, which would otherwise need
+// several submenus. When divider is true, a vertical line separates the
+// columns.
+func (w *WinTray) AddMenuColumnBreak(divider bool) error {
+	return w.invoke(func() error {
+		if divider {
+			w.menuBreak = win.MF_MENUBARBREAK
+		} else {
+			w.menuBreak = win.MF_MENUBREAK
+		}
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// menuSelect shows the help text for the menu item that was highlighted, or
+// hides it if the item has none or the menu was closed, in response to
+// WM_MENUSELECT.
+func (w *WinTray) menuSelect(wparam, lparam uintptr) {
+	var (
+		item  = uint32(win.LOWORD(uint32(wparam)))
+		flags = uint32(win.HIWORD(uint32(wparam)))
+		hmenu = win.HMENU(lparam)
+	)
+	text, ok := w.menuHelp[item]
+	if hmenu == 0 || flags&win.MF_POPUP != 0 || !ok {
+		w.hideMenuHelp()
+		return
+	}
+
+	// Find the position of the item, which is needed for its bounds
+	pos := int32(-1)
+	for i := int32(0); i < win.GetMenuItemCount(hmenu); i++ {
+		if win.GetMenuItemID(hmenu, i) == item {
+			pos = i
+			break
+		}
+	}
+	var rc win.RECT
+	if pos < 0 || !win.GetMenuItemRect(0, hmenu, uint32(pos), &rc) {
+		w.hideMenuHelp()
+		return
+	}
+
+	// Create the popup the first time it is needed
+	if w.menuHelpTip == nil {
+		r, err := w.newRichTip()
+		if err != nil {
+			w.reportError(err, nil)
+			return
+		}
+		w.menuHelpTip = r
+	}
+	w.menuHelpTip.text = w.translate(text)
+
+	// Show the popup beside the item, on the left if there is no room on the
+	// right, and keep it within the work area
+	var (
+		width, height = w.menuHelpTip.measure()
+		work          = workArea(&rc)
+		pt            = win.POINT{X: rc.Right, Y: rc.Top}
+	)
+	if pt.X+width > work.Right {
+		pt.X = rc.Left - width
+	}
+	if pt.X < work.Left {
+		pt.X = work.Left
+	}
+	if pt.Y+height > work.Bottom {
+		pt.Y = work.Bottom - height
+	}
+	if pt.Y < work.Top {
+		pt.Y = work.Top
+	}
+	w.menuHelpTip.showAt(pt, width, height)
+}
+
+// hideMenuHelp hides the help text for menu items.
+func (w *WinTray) hideMenuHelp() {
+	if w.menuHelpTip != nil {
+		win.ShowWindow(w.menuHelpTip.hwnd, win.SW_HIDE)
+	}
+}
@@ -0,0 +1,73 @@
+package wintray
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// pActionQuit is the action that behaves like AddQuitItem unless the
+// application binds the name to a function of its own.
+const pActionQuit = "quit"
+
+// pMenuDefinitionItem is an item in a menu definition read by
+// LoadMenuFromJSON.
+type pMenuDefinitionItem struct {
+	Label     string `json:"label"`
+	Action    string `json:"action"`
+	Separator bool   `json:"separator"`
+}
+
+// LoadMenuFromJSON adds the items described by a JSON array to the menu,
+// binding the name of each item's action to a function in actions:
+//
+//	[
+//	    {"label": "&Open", "action": "open"},
+//	    {"separator": true},
+//	    {"label": "E&xit", "action": "quit"}
+//	]
+//
+// The "quit" action behaves like AddQuitItem unless it is present in actions.
+// The whole definition is checked before any items are added, so an
+// ErrInvalidMenu error leaves the menu unchanged.
+func (w *WinTray) LoadMenuFromJSON(r io.Reader, actions map[string]func()) error {
+	var items []pMenuDefinitionItem
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&items); err != nil {
+		return newErrorFrom("LoadMenuFromJSON", "unable to parse menu: "+err.Error(), ErrInvalidMenu, nil)
+	}
+	fns := make([]func(), len(items))
+	for i, item := range items {
+		if item.Separator {
+			if item.Label != "" || item.Action != "" {
+				return invalidMenuItem(i, "separator cannot have a label or action")
+			}
+			continue
+		}
+		if item.Label == "" {
+			return invalidMenuItem(i, "label is missing")
+		}
+		fn, ok := actions[item.Action]
+		if !ok {
+			if item.Action != pActionQuit {
+				return invalidMenuItem(i, fmt.Sprintf("unknown action %q", item.Action))
+			}
+			fn = w.quit
+		}
+		fns[i] = fn
+	}
+	return w.Batch(func(b *Batch) {
+		for i, item := range items {
+			if item.Separator {
+				b.AddMenuSeparator()
+			} else {
+				b.AddMenuItem(item.Label, fns[i])
+			}
+		}
+	})
+}
+
+func invalidMenuItem(i int, msg string) error {
+	return newErrorFrom("LoadMenuFromJSON", fmt.Sprintf("item %d: %s", i, msg), ErrInvalidMenu, nil)
+}
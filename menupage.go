@@ -0,0 +1,80 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+const (
+	// pMoreText is the text of the submenu that holds the items that do not
+	// fit on a page
+	pMoreText = "More…"
+
+	// A page must hold at least one item besides the submenu for the next
+	// page, otherwise the submenus would nest forever
+	pMinMenuPageSize = 2
+)
+
+// menuPageLimit returns the number of entries on each page of the menu,
+// including the submenu for the next page.
+func (w *WinTray) menuPageLimit() int {
+	if w.menuPageSize > 0 {
+		if w.menuPageSize < pMinMenuPageSize {
+			return pMinMenuPageSize
+		}
+		return w.menuPageSize
+	}
+
+	// Fit as many items as the work area of the monitor with the icon can
+	// hold, leaving room for the status line and its separator; this is
+	// determined once since existing pages are not rearranged if it changes
+	if w.menuPageItems == 0 {
+		var (
+			rc     = w.anchorRect()
+			work   = workArea(&rc)
+			height = w.systemMetric(win.SM_CYMENU)
+		)
+		w.menuPageItems = pMinMenuPageSize
+		if height > 0 {
+			if n := int((work.Bottom-work.Top)/height) - 2; n > pMinMenuPageSize {
+				w.menuPageItems = n
+			}
+		}
+	}
+	return w.menuPageItems
+}
+
+// menuTail returns the menu that new items are appended to. Once its last
+// column is full, a "More…" submenu is appended to it and becomes the new
+// tail, so that long menus never need to scroll.
+func (w *WinTray) menuTail(op string) (win.HMENU, error) {
+	if w.hmenuTail == 0 {
+		w.hmenuTail = w.hmenu
+	}
+	count := int(win.GetMenuItemCount(w.hmenuTail))
+	if w.menuBreak != 0 {
+		w.menuColumnStart = count
+	}
+	if count-w.menuColumnStart < w.menuPageLimit()-1 {
+		return w.hmenuTail, nil
+	}
+	hmenu := win.CreatePopupMenu()
+	if hmenu == 0 {
+		return 0, newError(op, "unable to create submenu", nil)
+	}
+	if ret, _, err := pAppendMenuW.Call(
+		uintptr(w.hmenuTail),
+		uintptr(win.MF_POPUP),
+		uintptr(hmenu),
+		uintptr(unsafe.Pointer(utf16PtrFromString(pMoreText))),
+	); ret == 0 {
+		win.DestroyMenu(hmenu)
+		return 0, newErrorFrom(op, "unable to add submenu", nil, err)
+	}
+	w.hmenuTail = hmenu
+	w.menuColumnStart = 0
+	return hmenu, nil
+}
@@ -0,0 +1,191 @@
+//go:build windows
+
+package wintray
+
+import (
+	"image/color"
+	"strings"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+const (
+	// Padding around the label of a styled menu item, in pixels at 96 DPI
+	pMenuItemPadding = 4
+
+	// Colors of menus in dark mode, which are not available from
+	// GetSysColor
+	pDarkMenuBackground = 0x2b2b2b
+	pDarkMenuHighlight  = 0x414141
+	pDarkMenuText       = 0xffffff
+	pDarkMenuGrayText   = 0x6d6d6d
+)
+
+// SetMenuItemStyle changes the appearance of the label of the item with the
+// specified ID (as reported by MenuItems), such as to show a "Disconnect"
+// item in red. Styled items are drawn by the library rather than by Windows,
+// so they do not show the UAC shield added by AddShieldMenuItem. Passing the
+// zero ItemStyle restores the usual appearance.
+func (w *WinTray) SetMenuItemStyle(id uint32, style ItemStyle) error {
+	return w.invoke(func() error {
+		if err := w.checkMenuId("SetMenuItemStyle", id); err != nil {
+			return err
+		}
+		mii := &win.MENUITEMINFO{
+			FMask: win.MIIM_FTYPE,
+		}
+		mii.CbSize = uint32(unsafe.Sizeof(*mii))
+		if !win.GetMenuItemInfo(w.hmenu, id, win.FALSE, mii) {
+			return newError("SetMenuItemStyle", "unable to read menu item", nil)
+		}
+		if style == (ItemStyle{}) {
+			mii.FType &^= win.MFT_OWNERDRAW
+		} else {
+			mii.FType |= win.MFT_OWNERDRAW
+		}
+		if !win.SetMenuItemInfo(w.hmenu, id, false, mii) {
+			return newError("SetMenuItemStyle", "unable to change menu item", nil)
+		}
+		if style == (ItemStyle{}) {
+			delete(w.menuStyles, id)
+		} else {
+			w.menuStyles[id] = style
+		}
+		return nil
+	})
+}
+
+// colorRef converts c to a COLORREF.
+func colorRef(c color.Color) win.COLORREF {
+	r, g, b, _ := c.RGBA()
+	return win.COLORREF(r>>8 | g>>8<<8 | b>>8<<16)
+}
+
+// darkMenus reports whether menus are currently drawn in dark mode.
+func (w *WinTray) darkMenus() bool {
+	if !w.darkModeMenus || w.highContrast || !loadDarkMode() {
+		return false
+	}
+	t, err := CurrentTheme()
+	return err == nil && t.AppsDark
+}
+
+// menuItemFont creates the font for the label of a styled menu item.
+func (w *WinTray) menuItemFont(style ItemStyle) win.HFONT {
+	return systemFont(w.dpi(), func(ncm *win.NONCLIENTMETRICS) win.LOGFONT {
+		lf := ncm.LfMenuFont
+		if style.Bold {
+			lf.LfWeight = win.FW_BOLD
+		}
+		return lf
+	})
+}
+
+// menuItemLabel returns the text of a menu item split into the label and the
+// shortcut that follows a tab, if any.
+func (w *WinTray) menuItemLabel(id uint32) (label, shortcut string) {
+	label, shortcut, _ = strings.Cut(menuItemText(w.hmenu, id), "\t")
+	return
+}
+
+// measureMenuItem reports the size of a styled menu item in response to
+// WM_MEASUREITEM.
+func (w *WinTray) measureMenuItem(mis *win.MEASUREITEMSTRUCT) bool {
+	style, ok := w.menuStyles[mis.ItemID]
+	if mis.CtlType != win.ODT_MENU || !ok {
+		return false
+	}
+	var (
+		label, shortcut = w.menuItemLabel(mis.ItemID)
+		hdc             = win.GetDC(w.hwnd)
+		hfont           = w.menuItemFont(style)
+		old             = win.SelectObject(hdc, win.HGDIOBJ(hfont))
+		pad             = win.MulDiv(pMenuItemPadding, int32(w.dpi()), pUSER_DEFAULT_SCREEN_DPI)
+		check           = w.systemMetric(win.SM_CXMENUCHECK) + 2*pad
+		rc              win.RECT
+	)
+	text := utf16FromString(label + "\t" + shortcut)
+	win.DrawTextEx(hdc, &text[0], int32(len(text)-1), &rc, win.DT_SINGLELINE|win.DT_EXPANDTABS|win.DT_CALCRECT, nil)
+	win.SelectObject(hdc, old)
+	win.DeleteObject(win.HGDIOBJ(hfont))
+	win.ReleaseDC(w.hwnd, hdc)
+	mis.ItemWidth = uint32(rc.Right - rc.Left + 2*check)
+	mis.ItemHeight = uint32(rc.Bottom - rc.Top + 2*pad)
+	return true
+}
+
+// drawMenuItem draws a styled menu item in response to WM_DRAWITEM.
+func (w *WinTray) drawMenuItem(dis *win.DRAWITEMSTRUCT) bool {
+	style, ok := w.menuStyles[dis.ItemID]
+	if dis.CtlType != win.ODT_MENU || !ok {
+		return false
+	}
+	var (
+		selected = dis.ItemState&win.ODS_SELECTED != 0
+		disabled = dis.ItemState&(win.ODS_GRAYED|win.ODS_DISABLED) != 0
+		dark     = w.darkMenus()
+		bg, fg   win.COLORREF
+	)
+	switch {
+	case dark && selected:
+		bg, fg = pDarkMenuHighlight, pDarkMenuText
+	case dark:
+		bg, fg = pDarkMenuBackground, pDarkMenuText
+	case selected:
+		bg = win.COLORREF(win.GetSysColor(win.COLOR_HIGHLIGHT))
+		fg = win.COLORREF(win.GetSysColor(win.COLOR_HIGHLIGHTTEXT))
+	default:
+		bg = win.COLORREF(win.GetSysColor(win.COLOR_MENU))
+		fg = win.COLORREF(win.GetSysColor(win.COLOR_MENUTEXT))
+	}
+
+	// The custom color is not used on the highlight (which may not contrast
+	// with it) or in high contrast mode
+	switch {
+	case disabled && dark:
+		fg = pDarkMenuGrayText
+	case disabled:
+		fg = win.COLORREF(win.GetSysColor(win.COLOR_GRAYTEXT))
+	case style.Color != nil && !selected && !w.highContrast:
+		fg = colorRef(style.Color)
+	}
+
+	hbr := win.CreateSolidBrush(bg)
+	win.FillRect(dis.HDC, &dis.RcItem, hbr)
+	win.DeleteObject(win.HGDIOBJ(hbr))
+
+	var (
+		label, shortcut = w.menuItemLabel(dis.ItemID)
+		hfont           = w.menuItemFont(style)
+		old             = win.SelectObject(dis.HDC, win.HGDIOBJ(hfont))
+		pad             = win.MulDiv(pMenuItemPadding, int32(w.dpi()), pUSER_DEFAULT_SCREEN_DPI)
+		check           = w.systemMetric(win.SM_CXMENUCHECK) + 2*pad
+		format          = uint32(win.DT_SINGLELINE | win.DT_VCENTER)
+	)
+	if dis.ItemState&win.ODS_NOACCEL != 0 {
+		format |= win.DT_HIDEPREFIX
+	}
+	win.SetBkMode(dis.HDC, win.TRANSPARENT)
+	win.SetTextColor(dis.HDC, fg)
+	if dis.ItemState&win.ODS_CHECKED != 0 {
+		rc := dis.RcItem
+		rc.Right = rc.Left + check
+		w.drawMenuText(dis.HDC, "✓", &rc, format|win.DT_CENTER|win.DT_NOPREFIX)
+	}
+	rc := dis.RcItem
+	rc.Left += check
+	rc.Right -= check
+	w.drawMenuText(dis.HDC, label, &rc, format)
+	if shortcut != "" {
+		w.drawMenuText(dis.HDC, shortcut, &rc, format|win.DT_RIGHT)
+	}
+	win.SelectObject(dis.HDC, old)
+	win.DeleteObject(win.HGDIOBJ(hfont))
+	return true
+}
+
+func (w *WinTray) drawMenuText(hdc win.HDC, s string, rc *win.RECT, format uint32) {
+	text := utf16FromString(s)
+	win.DrawTextEx(hdc, &text[0], int32(len(text)-1), rc, format, nil)
+}
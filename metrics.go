@@ -0,0 +1,72 @@
+package wintray
+
+import (
+	"errors"
+	"time"
+)
+
+// Metrics receives counters from an icon, such as for reporting the health of
+// applications deployed to many machines. Each call adds delta to the named
+// counter. *expvar.Map satisfies this interface, and collectors for other
+// systems (such as Prometheus) need only map the names to their own
+// counters.
+type Metrics interface {
+	Add(name string, delta int64)
+	AddFloat(name string, delta float64)
+}
+
+// The names of the counters passed to Metrics.
+const (
+
+	// MetricMessages counts the requests processed by the UI thread.
+	MetricMessages = "messages"
+
+	// MetricCalls counts the calls that waited for the UI thread, and
+	// MetricCallSeconds is the total time they spent waiting (including
+	// processing), so that dividing one by the other gives the average
+	// latency of the API.
+	MetricCalls       = "calls"
+	MetricCallSeconds = "call_seconds"
+
+	// MetricMenuShows counts the times the context menu was shown.
+	MetricMenuShows = "menu_shows"
+
+	// MetricNotificationsShown, MetricNotificationsSuppressed, and
+	// MetricNotificationsFailed count the outcomes of ShowNotification.
+	MetricNotificationsShown      = "notifications_shown"
+	MetricNotificationsSuppressed = "notifications_suppressed"
+	MetricNotificationsFailed     = "notifications_failed"
+)
+
+// WithMetrics sends counters for the icon's activity to m.
+func WithMetrics(m Metrics) Option {
+	return func(w *WinTray) {
+		w.metrics = m
+	}
+}
+
+func (w *WinTray) count(name string) {
+	if w.metrics != nil {
+		w.metrics.Add(name, 1)
+	}
+}
+
+// observeCall records the latency of a call that started at t.
+func (w *WinTray) observeCall(t time.Time) {
+	if w.metrics != nil {
+		w.metrics.Add(MetricCalls, 1)
+		w.metrics.AddFloat(MetricCallSeconds, time.Since(t).Seconds())
+	}
+}
+
+// countNotification records the outcome of showing a notification.
+func (w *WinTray) countNotification(err error) {
+	switch {
+	case err == nil:
+		w.count(MetricNotificationsShown)
+	case errors.Is(err, ErrSuppressed):
+		w.count(MetricNotificationsSuppressed)
+	default:
+		w.count(MetricNotificationsFailed)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package wintray
+
+// HWND returns the handle of the hidden window that receives the messages
+// for the icon. It can be passed to other APIs (such as to register for
+// broadcasts) but must not be destroyed.
+func (w *WinTray) HWND() uintptr {
+	return uintptr(w.hwnd)
+}
+
+// AddMessageFilter registers a function that is offered every message sent
+// to the hidden window before the icon processes it, which allows custom
+// messages to be handled without modifying the package. Filters are invoked
+// on the UI thread in the order they were added, so they must return
+// quickly; blocking API functions called from a filter run immediately.
+func (w *WinTray) AddMessageFilter(fn MessageFilter) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.messageFilters = append(w.messageFilters, fn)
+}
+
+// filterMessage offers a message to the filters registered with
+// AddMessageFilter.
+func (w *WinTray) filterMessage(hwnd, msg, wparam, lparam uintptr) (bool, uintptr) {
+	w.hooksMutex.Lock()
+	filters := w.messageFilters
+	w.hooksMutex.Unlock()
+	for _, fn := range filters {
+		if handled, result := fn(hwnd, msg, wparam, lparam); handled {
+			return true, result
+		}
+	}
+	return false, 0
+}
@@ -0,0 +1,213 @@
+//go:build windows
+
+package wintray
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	iphlpapi              = windows.NewLazySystemDLL("iphlpapi.dll")
+	pNotifyAddrChange     = iphlpapi.NewProc("NotifyAddrChange")
+	pCancelIPChangeNotify = iphlpapi.NewProc("CancelIPChangeNotify")
+
+	pCLSID_NetworkListManager = mustGUID("{DCB00C01-570F-4A9B-8D69-199FDBA5723B}")
+	pIID_INetworkListManager  = mustGUID("{DCB00000-570F-4A9B-8D69-199FDBA5723B}")
+)
+
+const (
+	// Vtable index for INetworkListManager (which derives from IDispatch)
+	pINetworkListManager_GetConnectivity = 13
+
+	pNLM_CONNECTIVITY_IPV4_INTERNET = 0x0040
+	pNLM_CONNECTIVITY_IPV6_INTERNET = 0x0400
+
+	pGAA_FLAG_INCLUDE_GATEWAYS = 0x0080
+
+	pIF_TYPE_WWANPP  = 243
+	pIF_TYPE_WWANPP2 = 244
+
+	// Addresses tend to change several times in quick succession and Windows
+	// takes a moment to determine whether the Internet can be reached
+	pConnectivitySettleDelay = 2 * time.Second
+)
+
+// connectionKind returns the kind of the connection that carries the default
+// route, preferring the one with the lowest metric if there are several.
+func connectionKind() (ConnKind, error) {
+	var (
+		size = uint32(15 * 1024)
+		buf  []byte
+	)
+	for {
+		buf = make([]byte, size)
+		err := windows.GetAdaptersAddresses(
+			windows.AF_UNSPEC,
+			pGAA_FLAG_INCLUDE_GATEWAYS,
+			0,
+			(*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])),
+			&size,
+		)
+		if err == nil {
+			break
+		}
+		if err != windows.ERROR_BUFFER_OVERFLOW {
+			return ConnNone, newErrorFrom("IsOnline", "unable to enumerate network adapters", nil, err)
+		}
+	}
+	var (
+		kind   = ConnNone
+		metric = ^uint32(0)
+	)
+	for a := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])); a != nil; a = a.Next {
+		if a.OperStatus != windows.IfOperStatusUp ||
+			a.IfType == windows.IF_TYPE_SOFTWARE_LOOPBACK ||
+			a.FirstGatewayAddress == nil ||
+			a.Ipv4Metric >= metric {
+			continue
+		}
+		metric = a.Ipv4Metric
+		switch a.IfType {
+		case windows.IF_TYPE_ETHERNET_CSMACD:
+			kind = ConnEthernet
+		case windows.IF_TYPE_IEEE80211:
+			kind = ConnWiFi
+		case pIF_TYPE_WWANPP, pIF_TYPE_WWANPP2:
+			kind = ConnCellular
+		default:
+			kind = ConnOther
+		}
+	}
+	return kind, nil
+}
+
+// internetConnectivity asks the Network List Manager whether the Internet can
+// be reached. COM is initialized for the calling thread, which is locked for
+// the duration of the call.
+func internetConnectivity() (bool, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := windows.CoInitializeEx(
+		0,
+		windows.COINIT_MULTITHREADED,
+	); err != nil && err != syscall.Errno(1) {
+		return false, newErrorFrom("IsOnline", "unable to initialize COM", nil, err)
+	}
+	defer windows.CoUninitialize()
+	nlm, err := createInstance("IsOnline", pCLSID_NetworkListManager, pIID_INetworkListManager)
+	if err != nil {
+		return false, err
+	}
+	defer nlm.release()
+	var connectivity uint32
+	if hr := nlm.call(
+		pINetworkListManager_GetConnectivity,
+		uintptr(unsafe.Pointer(&connectivity)),
+	); hr != 0 {
+		return false, newHRESULTError("IsOnline", "unable to determine connectivity", nil, hr)
+	}
+	return connectivity&(pNLM_CONNECTIVITY_IPV4_INTERNET|pNLM_CONNECTIVITY_IPV6_INTERNET) != 0, nil
+}
+
+// connectivity returns whether the Internet can be reached and the kind of
+// connection used to reach it. If the Network List Manager is unavailable,
+// any connection with a default gateway is assumed to be online.
+func connectivity() (bool, ConnKind, error) {
+	kind, err := connectionKind()
+	if err != nil {
+		return false, ConnNone, err
+	}
+	online, err := internetConnectivity()
+	if err != nil {
+		online = kind != ConnNone
+	}
+	return online, kind, nil
+}
+
+// IsOnline reports whether the Internet can be reached, as determined by
+// Windows (the same status shown by the network icon in the taskbar).
+func IsOnline() (bool, error) {
+	online, _, err := connectivity()
+	return online, err
+}
+
+// OnConnectivityChange registers a function to be invoked when the computer
+// goes online or offline or the kind of connection changes (such as from
+// Ethernet to Wi-Fi). Passing nil stops monitoring the network.
+func (w *WinTray) OnConnectivityChange(fn func(online bool, kind ConnKind)) error {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onConnectivityChange = fn
+	switch {
+	case fn != nil && w.connectivityStop == 0:
+		stop, err := windows.CreateEvent(nil, 1, 0, nil)
+		if err != nil {
+			return newErrorFrom("OnConnectivityChange", "unable to create event", nil, err)
+		}
+		w.connectivityStop = stop
+		go w.watchConnectivity(stop)
+	case fn == nil && w.connectivityStop != 0:
+		windows.SetEvent(w.connectivityStop)
+		w.connectivityStop = 0
+	}
+	return nil
+}
+
+// watchConnectivity waits for the addresses of the network adapters to change
+// until the icon is closed or stop is signaled, invoking the function
+// registered with OnConnectivityChange if the connectivity changed.
+func (w *WinTray) watchConnectivity(stop windows.Handle) {
+	defer windows.CloseHandle(stop)
+	changed, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		w.reportError(newErrorFrom("OnConnectivityChange", "unable to create event", nil, err), nil)
+		return
+	}
+	defer windows.CloseHandle(changed)
+	defer w.setEventOnClose(stop)()
+	online, kind, _ := connectivity()
+	for {
+		var (
+			h  windows.Handle
+			ov = &windows.Overlapped{HEvent: changed}
+		)
+		if r, _, _ := pNotifyAddrChange.Call(
+			uintptr(unsafe.Pointer(&h)),
+			uintptr(unsafe.Pointer(ov)),
+		); r != uintptr(windows.ERROR_IO_PENDING) {
+			w.reportError(newErrorFrom("OnConnectivityChange", "unable to monitor network", nil, syscall.Errno(r)), nil)
+			return
+		}
+		event, _ := windows.WaitForMultipleObjects(
+			[]windows.Handle{changed, stop},
+			false,
+			windows.INFINITE,
+		)
+		if event != windows.WAIT_OBJECT_0 {
+
+			// The cancelled request completes asynchronously, signalling the
+			// event, and must not outlive ov or the event
+			pCancelIPChangeNotify.Call(uintptr(unsafe.Pointer(ov)))
+			windows.WaitForSingleObject(changed, windows.INFINITE)
+			runtime.KeepAlive(ov)
+			return
+		}
+		time.Sleep(pConnectivitySettleDelay)
+		o, k, err := connectivity()
+		if err != nil || (o == online && k == kind) {
+			continue
+		}
+		online, kind = o, k
+		w.hooksMutex.Lock()
+		fn := w.onConnectivityChange
+		w.hooksMutex.Unlock()
+		if fn != nil {
+			w.invokeHandler(func() { fn(o, k) })
+		}
+	}
+}
@@ -0,0 +1,173 @@
+//go:build windows
+
+package wintray
+
+import (
+	"image"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// Notification codes sent to the callback message (shellapi.h)
+const (
+	pNIN_SELECT           = win.WM_USER + 0
+	pNINF_KEY             = 0x1
+	pNIN_KEYSELECT        = pNIN_SELECT | pNINF_KEY
+	pNIN_BALLOONSHOW      = win.WM_USER + 2
+	pNIN_BALLOONHIDE      = win.WM_USER + 3
+	pNIN_BALLOONTIMEOUT   = win.WM_USER + 4
+	pNIN_BALLOONUSERCLICK = win.WM_USER + 5
+	pNIN_POPUPOPEN        = win.WM_USER + 6
+	pNIN_POPUPCLOSE       = win.WM_USER + 7
+)
+
+// pNotifyEvent is a decoded callback message from the notification area.
+type pNotifyEvent struct {
+
+	// Code is the event, such as WM_CONTEXTMENU or NIN_SELECT
+	Code uint32
+
+	// IconId identifies the icon that generated the event
+	IconId uint32
+
+	// Anchor is the position (in screen coordinates) that should be used for
+	// displaying UI in response to the event; for keyboard activation, this
+	// is the location of the icon rather than the cursor
+	Anchor win.POINT
+}
+
+// decodeNotifyEvent decodes a callback message according to the version
+// granted by the shell. With NOTIFYICON_VERSION_4, the event is in
+// LOWORD(lParam), the icon ID in HIWORD(lParam), and the anchor coordinates
+// in wParam. Older versions pass the icon ID in wParam and the event in
+// lParam, with the cursor position used as the anchor.
+func (w *WinTray) decodeNotifyEvent(wparam, lparam uintptr) *pNotifyEvent {
+	if w.notifyVersion == win.NOTIFYICON_VERSION_4 {
+		return &pNotifyEvent{
+			Code:   uint32(win.LOWORD(uint32(lparam))),
+			IconId: uint32(win.HIWORD(uint32(lparam))),
+			Anchor: win.POINT{
+				X: win.GET_X_LPARAM(wparam),
+				Y: win.GET_Y_LPARAM(wparam),
+			},
+		}
+	}
+	e := &pNotifyEvent{
+		Code:   uint32(lparam),
+		IconId: uint32(wparam),
+	}
+	win.GetCursorPos(&e.Anchor)
+	e.Anchor = w.iconAnchor(e.Anchor)
+
+	// Before version 3, the shell only sends the raw mouse messages, so
+	// they are translated into the events sent by later versions
+	if w.notifyVersion == 0 {
+		switch e.Code {
+		case win.WM_RBUTTONUP:
+			e.Code = win.WM_CONTEXTMENU
+		case win.WM_LBUTTONUP:
+			e.Code = pNIN_SELECT
+		}
+	}
+	return e
+}
+
+// setAnchor records the anchor point of the most recent interaction.
+func (w *WinTray) setAnchor(pt win.POINT) {
+	w.anchorMutex.Lock()
+	defer w.anchorMutex.Unlock()
+	w.anchor = image.Pt(int(pt.X), int(pt.Y))
+}
+
+// AnchorPoint returns the position (in screen coordinates) of the most recent
+// interaction with the icon, as reported by the notification area. When the
+// icon is activated with the keyboard, this is the location of the icon
+// rather than the cursor. It is intended for positioning menus and other
+// popups; the zero value is returned if there has been no interaction yet.
+func (w *WinTray) AnchorPoint() image.Point {
+	w.anchorMutex.Lock()
+	defer w.anchorMutex.Unlock()
+	return w.anchor
+}
+
+// showContextMenu shows the menu at the anchor point. The selected item (if
+// any) is delivered to the window as WM_COMMAND once the menu closes.
+func (w *WinTray) showContextMenu(pt *win.POINT) {
+	w.hooksMutex.Lock()
+	onMenuOpen, onMenuClose := w.onMenuOpen, w.onMenuClose
+	w.hooksMutex.Unlock()
+
+	w.emit(Event{Type: EventMenuOpened})
+	if onMenuOpen != nil {
+		go w.invokeHandler(onMenuOpen)
+	}
+	w.showMenu(w.hwnd, w.hmenu, pt)
+	w.debug("menu closed")
+	if onMenuClose != nil {
+		go w.invokeHandler(onMenuClose)
+	}
+}
+
+// handleNotifyEvent processes a callback message from the notification area.
+// It returns true if the event was handled.
+func (w *WinTray) handleNotifyEvent(e *pNotifyEvent) bool {
+	switch e.Code {
+
+	// The context menu was requested with the mouse or keyboard
+	case win.WM_CONTEXTMENU:
+		w.setAnchor(e.Anchor)
+		w.showContextMenu(&e.Anchor)
+		return true
+
+	// The icon was selected with the mouse or keyboard
+	case pNIN_SELECT, pNIN_KEYSELECT:
+		w.setAnchor(e.Anchor)
+		w.emit(Event{Type: EventIconClicked})
+		if w.leftClickMenu {
+			w.showContextMenu(&e.Anchor)
+		}
+		for f := range w.flyouts {
+			if f.opts.ShowOnClick {
+				f.toggle()
+			}
+		}
+		return true
+
+	// The user is hovering over the icon (these are only sent when the
+	// standard tooltip is suppressed)
+	case pNIN_POPUPOPEN, pNIN_POPUPCLOSE:
+		return w.richTipPopup(e.Code == pNIN_POPUPOPEN)
+
+	// A notification was clicked
+	case pNIN_BALLOONUSERCLICK:
+		go w.notificationClicked()
+		return true
+	}
+	return false
+}
+
+// ShowMenu opens the context menu next to the icon, as if it had been
+// right-clicked. This can be used to open the menu in response to a global
+// hotkey. ShowMenu returns once the menu is closed.
+func (w *WinTray) ShowMenu() error {
+	return w.invoke(func() error {
+		var (
+			rc = w.anchorRect()
+			pt = win.POINT{
+				X: (rc.Left + rc.Right) / 2,
+				Y: (rc.Top + rc.Bottom) / 2,
+			}
+		)
+		w.showContextMenu(&pt)
+		return nil
+	})
+}
+
+// ShowMenuAt opens the context menu at the specified position in screen
+// coordinates. ShowMenuAt returns once the menu is closed.
+func (w *WinTray) ShowMenuAt(x, y int) error {
+	return w.invoke(func() error {
+		w.showContextMenu(&win.POINT{X: int32(x), Y: int32(y)})
+		return nil
+	})
+}
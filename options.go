@@ -0,0 +1,241 @@
+package wintray
+
+import (
+	"time"
+)
+
+const (
+	pDefaultRetries    = 4
+	pDefaultRetryDelay = 250 * time.Millisecond
+)
+
+// Option configures a WinTray icon when it is created.
+type Option func(*WinTray)
+
+// WithDarkModeMenus renders the context menu in dark mode when applications
+// are set to use the dark theme. This relies on undocumented functions in
+// uxtheme.dll and has no effect on versions of Windows before 1809.
+func WithDarkModeMenus() Option {
+	return func(w *WinTray) {
+		w.darkModeMenus = true
+	}
+}
+
+// WithIcon sets the icon from the contents of an ICO file. The icon is shown
+// as soon as it is added to the notification area, rather than appearing
+// blank until SetIconFromBytes is called.
+func WithIcon(b []byte) Option {
+	return func(w *WinTray) {
+		w.initIcon = b
+	}
+}
+
+// WithTip sets the tooltip shown when the icon is first added.
+func WithTip(text string) Option {
+	return func(w *WinTray) {
+		w.initTip = text
+	}
+}
+
+// WithGUID identifies the icon by a GUID in the form
+// "{XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX}" rather than by the window and an
+// ID. Windows uses the GUID to remember the user's preferences for the icon
+// (such as whether it is shown in the overflow area) across launches; it must
+// not be shared with other applications. New fails with the parse error if
+// the GUID is invalid.
+func WithGUID(guid string) Option {
+	return func(w *WinTray) {
+		w.guid = guid
+	}
+}
+
+// WithClassName sets the name of the window class registered for the hidden
+// window. The name must be unique within the process; by default, one is
+// generated for each icon.
+func WithClassName(name string) Option {
+	return func(w *WinTray) {
+		w.className = name
+	}
+}
+
+// WithWindowTitle sets the title of the hidden window, which can be used to
+// find it with FindWindow.
+func WithWindowTitle(title string) Option {
+	return func(w *WinTray) {
+		w.windowTitle = title
+	}
+}
+
+// WithLeftClickMenu shows the context menu when the icon is clicked with the
+// left mouse button (or selected with the keyboard) as well as the right.
+func WithLeftClickMenu() Option {
+	return func(w *WinTray) {
+		w.leftClickMenu = true
+	}
+}
+
+// WithMenuPageSize sets how many items are shown in the menu before the rest
+// are moved to a "More…" submenu (and so on for that submenu). By default,
+// the size is chosen so that the menu fits on the screen without scrolling.
+func WithMenuPageSize(n int) Option {
+	return func(w *WinTray) {
+		w.menuPageSize = n
+	}
+}
+
+// WithRetry sets how many times a request to the notification area is retried
+// when it fails transiently, such as during the flurry of activity after
+// login. The first retry happens after delay, which doubles for each
+// subsequent retry; the UI thread is blocked in the meantime. Adding the icon
+// is retried after any failure while other requests are only retried if they
+// time out. The default is 4 retries starting at 250ms; WithRetry(0, 0)
+// disables retrying. The final failure is logged and returned.
+func WithRetry(n int, delay time.Duration) Option {
+	return func(w *WinTray) {
+		w.retries = n
+		w.retryDelay = delay
+	}
+}
+
+// WithStatusTimestamp appends the time at which SetStatus was called to the
+// status text, formatted with the specified layout (such as time.Kitchen).
+func WithStatusTimestamp(layout string) Option {
+	return func(w *WinTray) {
+		w.statusLayout = layout
+	}
+}
+
+// WithCallTimeout limits how long the API functions wait for the UI thread to
+// begin processing a request. If the UI thread is blocked for longer (such as
+// by a misbehaving shell extension), the request is abandoned and
+// ErrTrayUnresponsive is returned so that the application can carry on. The
+// time taken to complete the request is not limited, since some functions
+// wait for the user. By default, there is no timeout.
+func WithCallTimeout(d time.Duration) Option {
+	return func(w *WinTray) {
+		w.callTimeout = d
+	}
+}
+
+// WithTempDir sets the directory in which icons are temporarily written while
+// they are loaded, for environments where the default temporary directory is
+// not writable. A subdirectory that only the current user can access is
+// created within it; loading an icon fails with ErrTempDirNotPrivate if that
+// subdirectory already exists but is owned by another user or accessible to
+// others.
+func WithTempDir(dir string) Option {
+	return func(w *WinTray) {
+		w.tempDir = dir
+	}
+}
+
+// WithHighContrastIcon sets an icon (the contents of an ICO file) that is
+// displayed in place of the usual one while a high contrast theme is active.
+// The icons are swapped automatically when high contrast is turned on or off.
+func WithHighContrastIcon(b []byte) Option {
+	return func(w *WinTray) {
+		w.highContrastIcon = b
+	}
+}
+
+// WithTranslator translates the text displayed by the icon; see Translator.
+func WithTranslator(t Translator) Option {
+	return func(w *WinTray) {
+		w.translator = t
+	}
+}
+
+// WithLocale sets the locale passed to the Translator until SetLocale is
+// called.
+func WithLocale(tag string) Option {
+	return func(w *WinTray) {
+		w.locale = tag
+	}
+}
+
+// WithQueueWhileSilenced holds back notifications shown while notifications
+// are silenced with SetSilenced and shows them once they are no longer
+// silenced, instead of discarding them. They are shown a few seconds apart so
+// that each one can be read before the next replaces it.
+func WithQueueWhileSilenced() Option {
+	return func(w *WinTray) {
+		w.queueSilenced = true
+	}
+}
+
+// WithDefaultSound plays s when a notification is shown, unless another
+// sound is chosen with WithSound.
+func WithDefaultSound(s Sound) Option {
+	return func(w *WinTray) {
+		w.defaultSound = s
+	}
+}
+
+// WithHealthCheck checks that the icon is still in the notification area at
+// the specified interval, adding it again if it is missing, as Verify and
+// Repair would. This suits long-running applications where losing the icon
+// without notice is unacceptable.
+func WithHealthCheck(interval time.Duration) Option {
+	return func(w *WinTray) {
+		w.healthInterval = interval
+	}
+}
+
+// NotificationOption configures a notification shown with ShowNotification.
+type NotificationOption func(*pDataShowNotification)
+
+// WithDuration requests that the notification is displayed for approximately
+// the specified duration. Windows shows notifications for as long as the user
+// has chosen in the accessibility settings (five seconds by default), so a
+// shorter duration hides the notification early, while a longer one has no
+// effect except on Windows XP and older, which accept between 10 and 30
+// seconds.
+func WithDuration(d time.Duration) NotificationOption {
+	return func(n *pDataShowNotification) {
+		n.Duration = d
+	}
+}
+
+// WithSuppressedFallback runs fn if the notification will not be displayed,
+// in which case ShowNotification returns ErrSuppressed. This can be used to
+// draw attention to the icon in some other way, such as by changing it.
+func WithSuppressedFallback(fn func()) NotificationOption {
+	return func(n *pDataShowNotification) {
+		n.OnSuppressed = fn
+	}
+}
+
+// WithClickHandler runs fn if the notification is clicked, instead of the
+// function registered with OnNotificationClick. Only the most recent
+// notification can be clicked, so fn is discarded once another is shown.
+func WithClickHandler(fn func()) NotificationOption {
+	return func(n *pDataShowNotification) {
+		n.OnClick = fn
+	}
+}
+
+// WithTag replaces the previous notification if it was shown with the same
+// tag, rather than adding another, so that frequent updates such as progress
+// reports do not pile up.
+func WithTag(tag string) NotificationOption {
+	return func(n *pDataShowNotification) {
+		n.Tag = tag
+	}
+}
+
+// WithTrayIcon shows the icon in the notification, using the size from
+// SetIcons that best fits it.
+func WithTrayIcon() NotificationOption {
+	return func(n *pDataShowNotification) {
+		n.TrayIcon = true
+	}
+}
+
+// WithSound plays s when the notification is shown instead of the sound
+// chosen with WithDefaultSound or the usual notification sound. Use NoSound
+// to show the notification silently.
+func WithSound(s Sound) NotificationOption {
+	return func(n *pDataShowNotification) {
+		n.Sound = s
+	}
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package wintray
+
+import (
+	"errors"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// notificationAreaRect returns the bounds of the notification area on the
+// primary taskbar in screen coordinates. If the notification area cannot be
+// found, the bounds of the taskbar itself are returned instead.
+func notificationAreaRect() (win.RECT, bool) {
+	var rc win.RECT
+	tray := win.FindWindow(utf16PtrFromString("Shell_TrayWnd"), nil)
+	if tray == 0 {
+		return rc, false
+	}
+	hwnd := win.FindWindowEx(tray, 0, utf16PtrFromString("TrayNotifyWnd"), nil)
+	if hwnd == 0 {
+		hwnd = tray
+	}
+	return rc, win.GetWindowRect(hwnd, &rc)
+}
+
+// IsVisibleInTray reports whether the icon is shown in the notification area
+// of the taskbar, as opposed to being hidden in the overflow area. Windows
+// provides no way to query this directly, so it is determined by comparing
+// the position of the icon with that of the notification area.
+func (w *WinTray) IsVisibleInTray() (bool, error) {
+	area, ok := notificationAreaRect()
+	if !ok {
+		return false, newErrorFrom("IsVisibleInTray", "unable to find the taskbar", ErrShellNotRunning, nil)
+	}
+	rc, err := w.iconRect()
+	if err != nil {
+		// The shell does not report a position for icons in the overflow
+		// area while it is closed
+		if errors.Is(err, ErrShellRejected) {
+			return false, nil
+		}
+		return false, err
+	}
+	var (
+		x = (rc.Left + rc.Right) / 2
+		y = (rc.Top + rc.Bottom) / 2
+	)
+	return x >= area.Left && x < area.Right && y >= area.Top && y < area.Bottom, nil
+}
+
+// RequestPromotion asks the user to move the icon out of the overflow area.
+// Applications cannot do this themselves, so if the icon is hidden, the
+// taskbar settings are opened where the user can choose to show it. Nothing
+// happens if the icon is already visible.
+func (w *WinTray) RequestPromotion() error {
+	visible, err := w.IsVisibleInTray()
+	if err != nil {
+		return err
+	}
+	if visible {
+		return nil
+	}
+	return openTaskbarSettings("RequestPromotion")
+}
+
+// PromptPinIcon opens the settings where the user can choose which icons are
+// shown on the taskbar, regardless of whether the icon is currently hidden.
+// This is useful for a "Show this icon on the taskbar" menu item or help link.
+func (w *WinTray) PromptPinIcon() error {
+	return openTaskbarSettings("PromptPinIcon")
+}
+
+// openTaskbarSettings opens the taskbar settings page, or the Notification
+// Area Icons control panel on versions of Windows that predate it.
+func openTaskbarSettings(op string) error {
+	if err := shellExecute(op, "open", "ms-settings:taskbar", "", ""); err == nil {
+		return nil
+	}
+
+	// The control panel was replaced by the settings page in Windows 10
+	return shellExecute(
+		op,
+		"open",
+		"explorer.exe",
+		"shell:::{05d7b0f4-2121-4eff-bf6b-ed3f69b894d9}",
+		"",
+	)
+}
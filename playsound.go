@@ -0,0 +1,34 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var pPlaySound = windows.NewLazySystemDLL("winmm.dll").NewProc("PlaySoundW")
+
+const (
+	pSND_ASYNC     = 0x00000001
+	pSND_NODEFAULT = 0x00000002
+	pSND_ALIAS     = 0x00010000
+	pSND_FILENAME  = 0x00020000
+)
+
+// playSound starts playing s without waiting for it to finish, replacing any
+// sound that is already playing.
+func playSound(op string, s Sound) error {
+	flags := uintptr(pSND_ASYNC | pSND_NODEFAULT)
+	if s.file {
+		flags |= pSND_FILENAME
+	} else {
+		flags |= pSND_ALIAS
+	}
+	r, _, _ := pPlaySound.Call(uintptr(unsafe.Pointer(utf16PtrFromString(s.name))), 0, flags)
+	if r == 0 {
+		return newErrorFrom(op, "unable to play sound "+s.name, nil, nil)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+var (
+	pMonitorFromRect = user32.MustFindProc("MonitorFromRect")
+)
+
+// monitorInfo returns the bounds and work area of the monitor nearest to rc.
+func monitorInfo(rc *win.RECT) *win.MONITORINFO {
+	hmonitor, _, _ := pMonitorFromRect.Call(
+		uintptr(unsafe.Pointer(rc)),
+		win.MONITOR_DEFAULTTONEAREST,
+	)
+	mi := &win.MONITORINFO{
+		CbSize: uint32(unsafe.Sizeof(win.MONITORINFO{})),
+	}
+	win.GetMonitorInfo(win.HMONITOR(hmonitor), mi)
+	return mi
+}
+
+// workArea returns the work area of the monitor nearest to rc.
+func workArea(rc *win.RECT) win.RECT {
+	return monitorInfo(rc).RcWork
+}
+
+// popupPosition returns the position of a popup of the specified size so that
+// it is adjacent to anchor (typically the icon) on the side facing away from
+// the taskbar and lies entirely within the work area of the monitor.
+func popupPosition(anchor *win.RECT, width, height int32) win.POINT {
+	var (
+		work    = workArea(anchor)
+		centerX = (anchor.Left + anchor.Right) / 2
+		centerY = (anchor.Top + anchor.Bottom) / 2
+		pt      = win.POINT{
+			X: centerX - width/2,
+			Y: anchor.Top - height,
+		}
+	)
+
+	// The taskbar occupies the space outside of the work area, so the edge
+	// it is docked to can be determined from the position of the anchor
+	switch {
+	case anchor.Top >= work.Bottom:
+		pt.Y = work.Bottom - height
+	case anchor.Bottom <= work.Top:
+		pt.Y = work.Top
+	case anchor.Left >= work.Right:
+		pt.X = work.Right - width
+		pt.Y = centerY - height/2
+	case anchor.Right <= work.Left:
+		pt.X = work.Left
+		pt.Y = centerY - height/2
+	}
+
+	// Ensure the popup remains entirely within the work area
+	if pt.X+width > work.Right {
+		pt.X = work.Right - width
+	}
+	if pt.X < work.Left {
+		pt.X = work.Left
+	}
+	if pt.Y+height > work.Bottom {
+		pt.Y = work.Bottom - height
+	}
+	if pt.Y < work.Top {
+		pt.Y = work.Top
+	}
+
+	return pt
+}
+
+// anchorRect returns the rectangle that popups should be positioned against:
+// the icon itself if its position is known, otherwise the anchor point of the
+// most recent interaction, otherwise the cursor.
+func (w *WinTray) anchorRect() win.RECT {
+	if rc, err := w.iconRect(); err == nil {
+		return rc
+	}
+	pt := w.AnchorPoint()
+	if pt.X == 0 && pt.Y == 0 {
+		p := win.POINT{}
+		win.GetCursorPos(&p)
+		pt.X, pt.Y = int(p.X), int(p.Y)
+	}
+	return win.RECT{
+		Left:   int32(pt.X),
+		Top:    int32(pt.Y),
+		Right:  int32(pt.X) + 1,
+		Bottom: int32(pt.Y) + 1,
+	}
+}
+
+// menuPlacement adjusts the point and flags passed to TrackPopupMenuEx so
+// that a menu opened from a taskbar lies entirely within the work area of the
+// taskbar's monitor, opening away from whichever edge the taskbar is docked
+// to. The taskbar is excluded so that the menu is never drawn over it. The
+// parameters are nil when pt is not on a taskbar (such as when it is hidden
+// automatically or the icon is in the overflow area).
+func menuPlacement(pt win.POINT, flags uint32) (win.POINT, uint32, *win.TPMPARAMS) {
+	var (
+		anchor = win.RECT{Left: pt.X, Top: pt.Y, Right: pt.X + 1, Bottom: pt.Y + 1}
+		mi     = monitorInfo(&anchor)
+		mon    = mi.RcMonitor
+		work   = mi.RcWork
+		params = &win.TPMPARAMS{
+			CbSize: uint32(unsafe.Sizeof(win.TPMPARAMS{})),
+		}
+	)
+	switch {
+	case pt.Y >= work.Bottom:
+		pt.Y = work.Bottom
+		flags |= win.TPM_BOTTOMALIGN | win.TPM_VERTICAL
+		params.RcExclude = win.RECT{Left: mon.Left, Top: work.Bottom, Right: mon.Right, Bottom: mon.Bottom}
+	case pt.Y < work.Top:
+		pt.Y = work.Top
+		flags |= win.TPM_TOPALIGN | win.TPM_VERTICAL
+		params.RcExclude = win.RECT{Left: mon.Left, Top: mon.Top, Right: mon.Right, Bottom: work.Top}
+	case pt.X >= work.Right:
+		pt.X = work.Right
+		flags = flags&^win.TPM_CENTERALIGN | win.TPM_RIGHTALIGN | win.TPM_HORIZONTAL
+		params.RcExclude = win.RECT{Left: work.Right, Top: mon.Top, Right: mon.Right, Bottom: mon.Bottom}
+	case pt.X < work.Left:
+		pt.X = work.Left
+		flags = flags&^(win.TPM_CENTERALIGN|win.TPM_RIGHTALIGN) | win.TPM_LEFTALIGN | win.TPM_HORIZONTAL
+		params.RcExclude = win.RECT{Left: mon.Left, Top: mon.Top, Right: work.Left, Bottom: mon.Bottom}
+	default:
+		return pt, flags, nil
+	}
+
+	// Keep the point within the work area along the edge of the taskbar
+	if pt.X < work.Left {
+		pt.X = work.Left
+	} else if pt.X > work.Right {
+		pt.X = work.Right
+	}
+	if pt.Y < work.Top {
+		pt.Y = work.Top
+	} else if pt.Y > work.Bottom {
+		pt.Y = work.Bottom
+	}
+
+	return pt, flags, params
+}
+
+// iconAnchor corrects the anchor of an interaction reported by a version of
+// the shell that does not include the position of the icon. The cursor is used
+// instead, which may be anywhere if the icon was activated with the keyboard,
+// so the center of the icon is used whenever pt lies outside of it.
+func (w *WinTray) iconAnchor(pt win.POINT) win.POINT {
+	rc, err := w.iconRect()
+	if err != nil {
+		return pt
+	}
+	if pt.X >= rc.Left && pt.X < rc.Right && pt.Y >= rc.Top && pt.Y < rc.Bottom {
+		return pt
+	}
+	return win.POINT{
+		X: (rc.Left + rc.Right) / 2,
+		Y: (rc.Top + rc.Bottom) / 2,
+	}
+}
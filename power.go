@@ -0,0 +1,130 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	pRegisterPowerSettingNotification   = user32.MustFindProc("RegisterPowerSettingNotification")
+	pUnregisterPowerSettingNotification = user32.MustFindProc("UnregisterPowerSettingNotification")
+)
+
+// Power management events (WM_POWERBROADCAST)
+const (
+	pPBT_APMSUSPEND         = 0x4
+	pPBT_APMRESUMEAUTOMATIC = 0x12
+	pPBT_POWERSETTINGCHANGE = 0x8013
+
+	pDEVICE_NOTIFY_WINDOW_HANDLE = 0x0
+)
+
+// pPOWERBROADCAST_SETTING is followed by DataLength bytes of data.
+type pPOWERBROADCAST_SETTING struct {
+	PowerSetting windows.GUID
+	DataLength   uint32
+}
+
+// OnSuspend registers a function to be invoked when the system is about to
+// suspend. The function runs on a separate goroutine, so the system may
+// suspend before it completes.
+func (w *WinTray) OnSuspend(fn func()) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onSuspend = fn
+}
+
+// OnResume registers a function to be invoked when the system resumes from
+// suspension. This is a good time to re-establish network connections.
+func (w *WinTray) OnResume(fn func()) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onResume = fn
+}
+
+// OnPowerSettingChange registers a function to be invoked when the specified
+// power setting changes; it is also invoked once with the current value
+// shortly after registration. Passing nil stops monitoring the setting.
+func (w *WinTray) OnPowerSettingChange(setting PowerSetting, fn func(data []byte)) error {
+	guid, err := windows.GUIDFromString(string(setting))
+	if err != nil {
+		return err
+	}
+	setting = PowerSetting(guid.String())
+	w.hooksMutex.Lock()
+	if fn != nil {
+		if w.onPowerSetting == nil {
+			w.onPowerSetting = make(map[PowerSetting]func(data []byte))
+		}
+		w.onPowerSetting[setting] = fn
+	} else {
+		delete(w.onPowerSetting, setting)
+	}
+	w.hooksMutex.Unlock()
+	return w.invoke(func() error {
+		h, registered := w.powerNotifications[setting]
+		switch {
+		case fn != nil && !registered:
+			h, _, err := pRegisterPowerSettingNotification.Call(
+				uintptr(w.hwnd),
+				uintptr(unsafe.Pointer(&guid)),
+				pDEVICE_NOTIFY_WINDOW_HANDLE,
+			)
+			if h == 0 {
+				return newErrorFrom("OnPowerSettingChange", "unable to register for notifications", nil, err)
+			}
+			w.powerNotifications[setting] = h
+		case fn == nil && registered:
+			pUnregisterPowerSettingNotification.Call(h)
+			delete(w.powerNotifications, setting)
+		}
+		return nil
+	})
+}
+
+func (w *WinTray) unregisterPowerNotifications() {
+	for setting, h := range w.powerNotifications {
+		pUnregisterPowerSettingNotification.Call(h)
+		delete(w.powerNotifications, setting)
+	}
+}
+
+// powerBroadcast is invoked on the UI thread when WM_POWERBROADCAST is
+// received.
+func (w *WinTray) powerBroadcast(wparam, lparam uintptr) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	switch wparam {
+	case pPBT_APMSUSPEND:
+		w.setSuspended(true)
+		if w.onSuspend != nil {
+			go w.invokeHandler(w.onSuspend)
+		}
+	case pPBT_APMRESUMEAUTOMATIC:
+		w.setSuspended(false)
+		w.rearmSchedules()
+		if w.onResume != nil {
+			go w.invokeHandler(w.onResume)
+		}
+	case pPBT_POWERSETTINGCHANGE:
+		var (
+			pbs     = (*pPOWERBROADCAST_SETTING)(paramPointer(lparam))
+			setting = PowerSetting(pbs.PowerSetting.String())
+		)
+		fn, ok := w.onPowerSetting[setting]
+		if !ok {
+			return
+		}
+
+		// Copy the data, since it is only valid during the message
+		data := make([]byte, pbs.DataLength)
+		copy(data, unsafe.Slice(
+			(*byte)(unsafe.Add(unsafe.Pointer(pbs), unsafe.Sizeof(*pbs))),
+			pbs.DataLength,
+		))
+		go w.invokeHandler(func() { fn(data) })
+	}
+}
@@ -0,0 +1,246 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+var pAdjustWindowRectEx = user32.MustFindProc("AdjustWindowRectEx")
+
+// Layout of the prompt at 96 DPI
+const (
+	promptWidth        = 320
+	promptHeight       = 112
+	promptMargin       = 12
+	promptLabelHeight  = 20
+	promptEditHeight   = 24
+	promptButtonWidth  = 75
+	promptButtonHeight = 26
+)
+
+// pPrompt is the window created by PromptText.
+type pPrompt struct {
+	w      *WinTray
+	hwnd   win.HWND
+	hedit  win.HWND
+	hfont  win.HFONT
+	text   string
+	ok     bool
+	closed bool
+}
+
+func (p *pPrompt) tray() *WinTray {
+	return p.w
+}
+
+func (p *pPrompt) wndProc(hwnd win.HWND, msg uint32, wparam, lparam uintptr) uintptr {
+	switch msg {
+	case win.WM_COMMAND:
+		switch win.LOWORD(uint32(wparam)) {
+		case win.IDOK:
+			n := win.SendMessage(p.hedit, win.WM_GETTEXTLENGTH, 0, 0)
+			buf := make([]uint16, n+1)
+			win.SendMessage(
+				p.hedit,
+				win.WM_GETTEXT,
+				uintptr(len(buf)),
+				uintptr(unsafe.Pointer(&buf[0])),
+			)
+			p.text = win.UTF16PtrToString(&buf[0])
+			p.ok = true
+			win.DestroyWindow(hwnd)
+		case win.IDCANCEL:
+			win.DestroyWindow(hwnd)
+		}
+		return 0
+
+	case win.WM_CLOSE:
+		win.DestroyWindow(hwnd)
+		return 0
+
+	case win.WM_DESTROY:
+		p.closed = true
+	}
+	return win.DefWindowProc(hwnd, msg, wparam, lparam)
+}
+
+// createControl creates a child control of the prompt.
+func (p *pPrompt) createControl(className, text string, style uint32, id, x, y, width, height int32) win.HWND {
+	hwnd := win.CreateWindowEx(
+		0,
+		utf16PtrFromString(className),
+		utf16PtrFromString(text),
+		win.WS_CHILD|win.WS_VISIBLE|style,
+		x,
+		y,
+		width,
+		height,
+		p.hwnd,
+		win.HMENU(id),
+		hinstance,
+		nil,
+	)
+	win.SendMessage(hwnd, win.WM_SETFONT, uintptr(p.hfont), 0)
+	return hwnd
+}
+
+// messageFont creates the font used for dialogs at the specified DPI.
+func messageFont(dpi uint32) win.HFONT {
+	return systemFont(dpi, func(ncm *win.NONCLIENTMETRICS) win.LOGFONT {
+		return ncm.LfMessageFont
+	})
+}
+
+// systemFont creates the font chosen by fn from the non-client metrics at the
+// specified DPI.
+func systemFont(dpi uint32, fn func(*win.NONCLIENTMETRICS) win.LOGFONT) win.HFONT {
+	ncm := &win.NONCLIENTMETRICS{}
+	ncm.CbSize = uint32(unsafe.Sizeof(*ncm))
+	if !win.SystemParametersInfo(win.SPI_GETNONCLIENTMETRICS, ncm.CbSize, unsafe.Pointer(ncm), 0) {
+		return 0
+	}
+
+	// The metrics are reported for the system DPI
+	hdc := win.GetDC(0)
+	defer win.ReleaseDC(0, hdc)
+	lf := fn(ncm)
+	lf.LfHeight = win.MulDiv(lf.LfHeight, int32(dpi), win.GetDeviceCaps(hdc, win.LOGPIXELSY))
+	return win.CreateFontIndirect(&lf)
+}
+
+// PromptText displays a modal dialog asking the user to enter a line of text
+// and waits for it to be closed. ok is false if the user cancelled the
+// dialog.
+func (w *WinTray) PromptText(title, label, defaultValue string) (text string, ok bool, err error) {
+	if err := checkText("PromptText", title, label, defaultValue); err != nil {
+		return "", false, err
+	}
+	err = w.invoke(func() error {
+
+		// All prompts for an icon share a single class
+		className := w.className + "_Prompt"
+		if !w.promptClassRegistered {
+			if err := registerClass(
+				className,
+				0,
+				win.GetSysColorBrush(win.COLOR_BTNFACE),
+			); err != nil {
+				return err
+			}
+			w.promptClassRegistered = true
+		}
+
+		p := &pPrompt{w: w}
+		var (
+			style   uint32 = win.WS_POPUP | win.WS_CAPTION | win.WS_SYSMENU
+			exStyle uint32 = win.WS_EX_DLGMODALFRAME | win.WS_EX_TOPMOST
+		)
+		hwnd, err := createWindow(p, className, title, exStyle, style, w.hwnd)
+		if err != nil {
+			return err
+		}
+		p.hwnd = hwnd
+
+		// Scale the layout for the DPI of the window
+		var (
+			dpi   = int32(w.dpi())
+			scale = func(v int32) int32 { return win.MulDiv(v, dpi, pUSER_DEFAULT_SCREEN_DPI) }
+			m     = scale(promptMargin)
+			cw    = scale(promptWidth)
+			ch    = scale(promptHeight)
+			bw    = scale(promptButtonWidth)
+			bh    = scale(promptButtonHeight)
+			by    = ch - m - bh
+		)
+		p.hfont = messageFont(uint32(dpi))
+		defer func() {
+			if p.hfont != 0 {
+				win.DeleteObject(win.HGDIOBJ(p.hfont))
+			}
+		}()
+		p.createControl("STATIC", label, 0, 0, m, m, cw-2*m, scale(promptLabelHeight))
+		p.hedit = p.createControl(
+			"EDIT",
+			defaultValue,
+			win.WS_TABSTOP|win.WS_BORDER|win.ES_AUTOHSCROLL,
+			0,
+			m,
+			m+scale(promptLabelHeight),
+			cw-2*m,
+			scale(promptEditHeight),
+		)
+		p.createControl(
+			"BUTTON",
+			"OK",
+			win.WS_TABSTOP|win.BS_DEFPUSHBUTTON,
+			win.IDOK,
+			cw-2*bw-m-m/2,
+			by,
+			bw,
+			bh,
+		)
+		p.createControl(
+			"BUTTON",
+			"Cancel",
+			win.WS_TABSTOP|win.BS_PUSHBUTTON,
+			win.IDCANCEL,
+			cw-bw-m,
+			by,
+			bw,
+			bh,
+		)
+
+		// Size the window to fit the client area and center it in the work
+		// area of the monitor containing the icon
+		var (
+			rc     = win.RECT{Right: cw, Bottom: ch}
+			anchor = w.anchorRect()
+			wa     = workArea(&anchor)
+		)
+		pAdjustWindowRectEx.Call(
+			uintptr(unsafe.Pointer(&rc)),
+			uintptr(style),
+			0,
+			uintptr(exStyle),
+		)
+		var (
+			width  = rc.Right - rc.Left
+			height = rc.Bottom - rc.Top
+		)
+		win.SetWindowPos(
+			hwnd,
+			win.HWND_TOPMOST,
+			wa.Left+(wa.Right-wa.Left-width)/2,
+			wa.Top+(wa.Bottom-wa.Top-height)/2,
+			width,
+			height,
+			win.SWP_SHOWWINDOW,
+		)
+		win.SetForegroundWindow(hwnd)
+		win.SetFocus(p.hedit)
+		win.SendMessage(p.hedit, win.EM_SETSEL, 0, ^uintptr(0))
+
+		// Run a nested loop until the prompt is closed; IsDialogMessage
+		// provides keyboard navigation and the Enter and Escape keys
+		msg := win.MSG{}
+		for !p.closed {
+			if win.GetMessage(&msg, 0, 0, 0) != win.TRUE {
+
+				// Leave WM_QUIT for the main loop
+				win.PostQuitMessage(int32(msg.WParam))
+				break
+			}
+			if !win.IsDialogMessage(hwnd, &msg) {
+				win.TranslateMessage(&msg)
+				win.DispatchMessage(&msg)
+			}
+		}
+
+		text, ok = p.text, p.ok
+		return nil
+	})
+	return
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+// ModifyRaw calls Shell_NotifyIcon with NIM_MODIFY for the icon, after fn
+// fills in the fields to change. This allows flags and fields that are not
+// otherwise supported to be used without modifying the package. fn is run on
+// the UI thread and receives a zeroed structure; the size, window, and ID
+// that identify the icon are filled in afterwards. Changes made this way may
+// be undone by later calls to the other functions, and changing the callback
+// message stops the icon from responding to the mouse.
+func (w *WinTray) ModifyRaw(fn func(nid *NOTIFYICONDATA)) error {
+	return w.invoke(func() error {
+		var raw NOTIFYICONDATA
+		fn(&raw)
+		nid := &win.NOTIFYICONDATA{
+			HWnd:              w.hwnd,
+			UID:               w.iconId,
+			UFlags:            raw.UFlags,
+			UCallbackMessage:  raw.UCallbackMessage,
+			HIcon:             win.HICON(raw.HIcon),
+			SzTip:             raw.SzTip,
+			DwState:           raw.DwState,
+			DwStateMask:       raw.DwStateMask,
+			SzInfo:            raw.SzInfo,
+			UTimeoutOrVersion: raw.UTimeoutOrVersion,
+			SzInfoTitle:       raw.SzInfoTitle,
+			DwInfoFlags:       raw.DwInfoFlags,
+			HBalloonIcon:      win.HICON(raw.HBalloonIcon),
+		}
+		return w.notifyIcon("ModifyRaw", "unable to modify icon", win.NIM_MODIFY, nid)
+	})
+}
@@ -0,0 +1,62 @@
+//go:build windows
+
+package wintray
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	shell32                  = windows.MustLoadDLL("Shell32.dll")
+	pShell_NotifyIconGetRect = shell32.MustFindProc("Shell_NotifyIconGetRect")
+)
+
+type pNOTIFYICONIDENTIFIER struct {
+	CbSize   uint32
+	HWnd     win.HWND
+	UID      uint32
+	GuidItem windows.GUID
+}
+
+// iconRect returns the bounding rectangle of the icon in screen coordinates.
+func (w *WinTray) iconRect() (win.RECT, error) {
+	var (
+		nii = &pNOTIFYICONIDENTIFIER{
+			CbSize: uint32(unsafe.Sizeof(pNOTIFYICONIDENTIFIER{})),
+			HWnd:   w.hwnd,
+			UID:    w.iconId,
+		}
+		rc = win.RECT{}
+	)
+	if w.guidItem != nil {
+		nii.GuidItem = *w.guidItem
+	}
+	if hr, _, _ := pShell_NotifyIconGetRect.Call(
+		uintptr(unsafe.Pointer(nii)),
+		uintptr(unsafe.Pointer(&rc)),
+	); hr != 0 {
+		return rc, newHRESULTError("IconRect", "unable to get icon rectangle", ErrShellRejected, hr)
+	}
+	return rc, nil
+}
+
+// IconRect returns the bounding rectangle of the icon in screen coordinates.
+// This can be used to position popup windows next to the icon. An error is
+// returned if the icon is not currently visible (for example, if it is in the
+// overflow area and the overflow window is closed).
+func (w *WinTray) IconRect() (image.Rectangle, error) {
+	rc, err := w.iconRect()
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return image.Rect(
+		int(rc.Left),
+		int(rc.Top),
+		int(rc.Right),
+		int(rc.Bottom),
+	), nil
+}
@@ -0,0 +1,233 @@
+//go:build windows
+
+package wintray
+
+import (
+	"strings"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+const (
+	// Padding around the text of a rich tooltip, in pixels at 96 DPI
+	pRichTipPadding = 8
+
+	// Format used for measuring and drawing the text of a rich tooltip
+	pRichTipFormat = win.DT_LEFT | win.DT_NOPREFIX | win.DT_EXPANDTABS
+)
+
+// pRichTip is the popup shown in place of the standard tooltip when the icon
+// is hovered, allowing text longer than the 127 characters supported by the
+// notification area.
+type pRichTip struct {
+	w     *WinTray
+	hwnd  win.HWND
+	text  string
+	hfont win.HFONT
+}
+
+func (r *pRichTip) tray() *WinTray {
+	return r.w
+}
+
+func (r *pRichTip) wndProc(hwnd win.HWND, msg uint32, wparam, lparam uintptr) uintptr {
+	switch msg {
+	case win.WM_PAINT:
+		var (
+			ps  = &win.PAINTSTRUCT{}
+			hdc = win.BeginPaint(hwnd, ps)
+			rc  = win.RECT{}
+			pad = win.MulDiv(pRichTipPadding, int32(r.w.dpi()), pUSER_DEFAULT_SCREEN_DPI)
+		)
+		win.GetClientRect(hwnd, &rc)
+		rc.Left += pad
+		rc.Top += pad
+		win.SelectObject(hdc, win.HGDIOBJ(r.hfont))
+		win.SetBkMode(hdc, win.TRANSPARENT)
+		win.SetTextColor(hdc, win.COLORREF(win.GetSysColor(win.COLOR_INFOTEXT)))
+		r.drawText(hdc, &rc, pRichTipFormat)
+		win.EndPaint(hwnd, ps)
+		return 0
+
+	case win.WM_DESTROY:
+		if r.hfont != 0 {
+			win.DeleteObject(win.HGDIOBJ(r.hfont))
+		}
+	}
+	return win.DefWindowProc(hwnd, msg, wparam, lparam)
+}
+
+func (r *pRichTip) drawText(hdc win.HDC, rc *win.RECT, format uint32) {
+	text := utf16FromString(r.text)
+	win.DrawTextEx(hdc, &text[0], int32(len(text)-1), rc, format, nil)
+}
+
+// show sizes the popup to fit the text and displays it next to the icon
+// without taking focus.
+func (r *pRichTip) show() {
+	var (
+		width, height = r.measure()
+		anchor        = r.w.anchorRect()
+	)
+	r.showAt(popupPosition(&anchor, width, height), width, height)
+}
+
+// measure returns the size of the popup needed to fit the text.
+func (r *pRichTip) measure() (width, height int32) {
+
+	// The font is recreated each time in case the DPI has changed
+	if r.hfont != 0 {
+		win.DeleteObject(win.HGDIOBJ(r.hfont))
+	}
+	dpi := r.w.dpi()
+	r.hfont = messageFont(dpi)
+
+	// Measure the text
+	var (
+		hdc = win.GetDC(r.hwnd)
+		rc  = win.RECT{}
+		pad = win.MulDiv(pRichTipPadding, int32(dpi), pUSER_DEFAULT_SCREEN_DPI)
+	)
+	old := win.SelectObject(hdc, win.HGDIOBJ(r.hfont))
+	r.drawText(hdc, &rc, pRichTipFormat|win.DT_CALCRECT)
+	win.SelectObject(hdc, old)
+	win.ReleaseDC(r.hwnd, hdc)
+
+	return rc.Right - rc.Left + 2*pad, rc.Bottom - rc.Top + 2*pad
+}
+
+// showAt displays the popup at pt without taking focus.
+func (r *pRichTip) showAt(pt win.POINT, width, height int32) {
+	win.SetWindowPos(
+		r.hwnd,
+		win.HWND_TOPMOST,
+		pt.X,
+		pt.Y,
+		width,
+		height,
+		win.SWP_NOACTIVATE|win.SWP_SHOWWINDOW,
+	)
+	win.InvalidateRect(r.hwnd, nil, true)
+}
+
+// setTipText changes the tooltip. When rich is true, the standard tooltip is
+// suppressed (so that the shell sends NIN_POPUPOPEN instead) and the text is
+// only used by accessibility tools. It returns ErrTruncated if the text did
+// not fit.
+func (w *WinTray) setTipText(op, text string, rich bool) error {
+	nid := &win.NOTIFYICONDATA{
+		HWnd:   w.hwnd,
+		UID:    w.iconId,
+		UFlags: win.NIF_TIP,
+	}
+	if !rich {
+		nid.UFlags |= win.NIF_SHOWTIP
+	}
+	truncated := copyToUint16Buffer(nid.SzTip[:], w.shellTip(text))
+	if err := w.notifyIcon(op, "unable to change tooltip", win.NIM_MODIFY, nid); err != nil {
+		return err
+	}
+	w.tip = text
+	if truncated && !rich && w.accessibleName == "" {
+		return newErrorFrom(op, "tooltip was truncated", ErrTruncated, nil)
+	}
+	return nil
+}
+
+// SetRichTip replaces the tooltip with a popup that can show text of any
+// length. Lines are separated with "\n" and columns can be aligned with
+// tabs. The popup is shown when the user hovers over the icon and requires
+// Windows 7 or newer (NOTIFYICON_VERSION_4). Calling SetTip switches back to
+// the standard tooltip.
+func (w *WinTray) SetRichTip(text string) error {
+	text, err := normalizeText("SetRichTip", text)
+	if err != nil {
+		return err
+	}
+	return w.invoke(func() error {
+		w.tipIsKey = false
+		w.richTipExplicit = true
+		return w.enableRichTip("SetRichTip", text)
+	})
+}
+
+// newRichTip creates a hidden popup for showing text, registering the window
+// class the first time.
+func (w *WinTray) newRichTip() (*pRichTip, error) {
+	className := w.className + "_RichTip"
+	if !w.richTipClassRegistered {
+		if err := registerClass(
+			className,
+			win.CS_DROPSHADOW,
+			win.GetSysColorBrush(win.COLOR_INFOBK),
+		); err != nil {
+			return nil, err
+		}
+		w.richTipClassRegistered = true
+	}
+	r := &pRichTip{w: w}
+	hwnd, err := createWindow(
+		r,
+		className,
+		"",
+		win.WS_EX_TOPMOST|win.WS_EX_TOOLWINDOW|win.WS_EX_NOACTIVATE,
+		win.WS_POPUP,
+		0,
+	)
+	if err != nil {
+		return nil, err
+	}
+	r.hwnd = hwnd
+	return r, nil
+}
+
+// enableRichTip shows text in the rich tooltip popup instead of the standard
+// tooltip.
+func (w *WinTray) enableRichTip(op, text string) error {
+
+	// The first line is used as the standard tooltip for accessibility
+	first, _, _ := strings.Cut(text, "\n")
+	if err := w.setTipText(op, first, true); err != nil {
+		return err
+	}
+
+	// Create the popup the first time it is needed
+	if w.richTip == nil {
+		r, err := w.newRichTip()
+		if err != nil {
+			return err
+		}
+		w.richTip = r
+	}
+	w.richTip.text = text
+	w.richTipEnabled = true
+
+	// Update the popup if it is already visible
+	if win.IsWindowVisible(w.richTip.hwnd) {
+		w.richTip.show()
+	}
+	return nil
+}
+
+// disableRichTip hides the rich tooltip when switching back to the standard
+// tooltip.
+func (w *WinTray) disableRichTip() {
+	w.richTipEnabled = false
+	if w.richTip != nil {
+		win.ShowWindow(w.richTip.hwnd, win.SW_HIDE)
+	}
+}
+
+// richTipPopup shows or hides the rich tooltip in response to NIN_POPUPOPEN
+// and NIN_POPUPCLOSE.
+func (w *WinTray) richTipPopup(open bool) bool {
+	if !w.richTipEnabled {
+		return false
+	}
+	if open {
+		w.richTip.show()
+	} else {
+		win.ShowWindow(w.richTip.hwnd, win.SW_HIDE)
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package wintray
+
+import (
+	"sync"
+	"time"
+)
+
+// The longest a schedule sleeps before checking the time again, which bounds
+// how late it runs if the system clock is changed or the computer resumes
+// without the icon being told
+const pScheduleMaxSleep = time.Minute
+
+// Schedule is a function that runs at a specific time or at regular
+// intervals, created with At or Every.
+type Schedule struct {
+	w        *WinTray
+	fn       func()
+	interval time.Duration
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// Only accessed by the goroutine running the schedule
+	next time.Time
+}
+
+// At runs fn once at the specified time, or immediately if it has already
+// passed. The time is compared with the system clock rather than measured as
+// a duration, so the function runs at the right time even if the computer is
+// suspended or the clock is changed in the meantime. If the time passes while
+// the computer is suspended or the session is locked, fn runs once the user
+// returns.
+func (w *WinTray) At(t time.Time, fn func()) *Schedule {
+	return w.schedule(t, 0, fn)
+}
+
+// Every runs fn at intervals of d, starting d from now, until the schedule is
+// stopped or the icon is closed. Like At, it follows the system clock; if the
+// computer was suspended through one or more runs, fn runs once when it
+// resumes (or, if the session was locked, once it is unlocked) and the
+// schedule then continues from the original starting time.
+func (w *WinTray) Every(d time.Duration, fn func()) *Schedule {
+	if d <= 0 {
+		panic("wintray: non-positive interval for Every")
+	}
+	return w.schedule(time.Now().Add(d), d, fn)
+}
+
+func (w *WinTray) schedule(next time.Time, interval time.Duration, fn func()) *Schedule {
+	s := &Schedule{
+		w:        w,
+		fn:       fn,
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+
+		// Removing the monotonic reading makes comparisons use the system
+		// clock, which keeps advancing while the computer is suspended
+		next: next.Round(0),
+	}
+	w.schedulesMutex.Lock()
+	if w.schedules == nil {
+		w.schedules = make(map[*Schedule]struct{})
+	}
+	w.schedules[s] = struct{}{}
+	w.schedulesMutex.Unlock()
+	go s.run()
+	return s
+}
+
+// Stop prevents the function from running again. It does not wait for a run
+// that is already in progress.
+func (s *Schedule) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *Schedule) run() {
+	defer func() {
+		s.w.schedulesMutex.Lock()
+		delete(s.w.schedules, s)
+		s.w.schedulesMutex.Unlock()
+	}()
+	for {
+		if d := time.Until(s.next); d > 0 {
+			if d > pScheduleMaxSleep {
+				d = pScheduleMaxSleep
+			}
+			t := time.NewTimer(d)
+			select {
+			case <-t.C:
+			case <-s.wake:
+			case <-s.stop:
+			case <-s.w.Done():
+			}
+			t.Stop()
+			select {
+			case <-s.stop:
+				return
+			case <-s.w.Done():
+				return
+			default:
+			}
+			continue
+		}
+
+		// Runs that come due while the user is away wait for them to return
+		if s.w.schedulesHeld() {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.stop:
+			case <-s.w.Done():
+			}
+			return
+		}
+		s.w.invokeHandler(s.fn)
+		if s.interval == 0 {
+			return
+		}
+
+		// Skip the runs that were missed while the computer was suspended
+		now := time.Now().Round(0)
+		s.next = s.next.Add(s.interval)
+		if !s.next.After(now) {
+			s.next = s.next.Add((now.Sub(s.next)/s.interval + 1) * s.interval)
+		}
+	}
+}
+
+// rearmSchedules makes every schedule check the time again, such as after the
+// computer resumes or the clock is changed.
+func (w *WinTray) rearmSchedules() {
+	w.schedulesMutex.Lock()
+	defer w.schedulesMutex.Unlock()
+	for s := range w.schedules {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// holdSchedules stops schedules from running while the user is away, or
+// releases them (running any that came due in the meantime) once they return.
+func (w *WinTray) holdSchedules(hold bool) {
+	w.schedulesMutex.Lock()
+	w.schedulesOnHold = hold
+	w.schedulesMutex.Unlock()
+	if !hold {
+		w.rearmSchedules()
+	}
+}
+
+// schedulesHeld reports whether schedules are being held by holdSchedules.
+func (w *WinTray) schedulesHeld() bool {
+	w.schedulesMutex.Lock()
+	defer w.schedulesMutex.Unlock()
+	return w.schedulesOnHold
+}
@@ -0,0 +1,108 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	wtsapi32                          = windows.NewLazySystemDLL("wtsapi32.dll")
+	pWTSRegisterSessionNotification   = wtsapi32.NewProc("WTSRegisterSessionNotification")
+	pWTSUnRegisterSessionNotification = wtsapi32.NewProc("WTSUnRegisterSessionNotification")
+)
+
+// Session changes (WM_WTSSESSION_CHANGE)
+const (
+	pWTS_CONSOLE_CONNECT    = 0x1
+	pWTS_CONSOLE_DISCONNECT = 0x2
+	pWTS_REMOTE_CONNECT     = 0x3
+	pWTS_REMOTE_DISCONNECT  = 0x4
+	pWTS_SESSION_LOCK       = 0x7
+	pWTS_SESSION_UNLOCK     = 0x8
+
+	pNOTIFY_FOR_THIS_SESSION = 0x0
+)
+
+// IsRemoteSession reports whether the application is running in a Remote
+// Desktop session.
+func IsRemoteSession() bool {
+	return win.GetSystemMetrics(win.SM_REMOTESESSION) != 0
+}
+
+// OnSessionChange registers a function to be invoked when the user connects
+// to or disconnects from the session, or locks or unlocks it. remote reports
+// whether the session is now a Remote Desktop session. The icon itself is
+// looked after automatically: while the session is disconnected,
+// notifications are not displayed and ShowNotification returns
+// ErrSuppressed, and on reconnection the icon is reloaded at the size for the
+// new display and added again if the shell lost it.
+func (w *WinTray) OnSessionChange(fn func(change SessionChange, remote bool)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onSessionChange = fn
+}
+
+// registerSessionNotifications requests WM_WTSSESSION_CHANGE. The icon works
+// without it, so failure is only logged.
+func (w *WinTray) registerSessionNotifications() {
+	if err := pWTSRegisterSessionNotification.Find(); err != nil {
+		return
+	}
+	if r, _, err := pWTSRegisterSessionNotification.Call(
+		uintptr(w.hwnd),
+		pNOTIFY_FOR_THIS_SESSION,
+	); r == 0 {
+		w.logError("unable to register for session notifications", "err", err)
+		return
+	}
+	w.sessionRegistered = true
+}
+
+func (w *WinTray) unregisterSessionNotifications() {
+	if w.sessionRegistered {
+		pWTSUnRegisterSessionNotification.Call(uintptr(w.hwnd))
+		w.sessionRegistered = false
+	}
+}
+
+// sessionChanged is invoked on the UI thread when WM_WTSSESSION_CHANGE is
+// received.
+func (w *WinTray) sessionChanged(wparam uintptr) {
+	var change SessionChange
+	switch wparam {
+	case pWTS_CONSOLE_CONNECT, pWTS_REMOTE_CONNECT:
+		change = SessionConnected
+		w.sessionDisconnected = false
+
+		// The client may have a different DPI from the previous one, and a
+		// Remote Desktop session does not always send WM_DPICHANGED
+		w.destroyLargeIcon()
+		w.reloadIcon()
+		if w.verify() != nil {
+			if err := w.repair(); err != nil {
+				w.reportError(err, nil)
+			}
+		}
+	case pWTS_CONSOLE_DISCONNECT, pWTS_REMOTE_DISCONNECT:
+		change = SessionDisconnected
+		w.sessionDisconnected = true
+	case pWTS_SESSION_LOCK:
+		change = SessionLocked
+		w.setLocked(true)
+	case pWTS_SESSION_UNLOCK:
+		change = SessionUnlocked
+		w.setLocked(false)
+	default:
+		return
+	}
+	w.debug("session changed", "change", change)
+	w.hooksMutex.Lock()
+	fn := w.onSessionChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		remote := IsRemoteSession()
+		go w.invokeHandler(func() { fn(change, remote) })
+	}
+}
@@ -0,0 +1,171 @@
+package wintray
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Settings stores application preferences as JSON in
+// %APPDATA%\<app>\settings.json. Values are written to disk as soon as they
+// are set. Settings may be used from any goroutine.
+type Settings struct {
+	path     string
+	mutex    sync.Mutex
+	values   map[string]json.RawMessage
+	onChange func(key string)
+	watchers []pWatcher
+	watchId  uint64
+}
+
+// pWatcher is an internal function registered with watch.
+type pWatcher struct {
+	id uint64
+	fn func(key string)
+}
+
+// OpenSettings loads the settings for the application with the specified
+// name. The file is created when a value is first set.
+func OpenSettings(appName string) (*Settings, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return OpenSettingsFile(filepath.Join(dir, appName, "settings.json"))
+}
+
+// OpenSettingsFile loads settings from the specified file.
+func OpenSettingsFile(path string) (*Settings, error) {
+	s := &Settings{
+		path:   path,
+		values: make(map[string]json.RawMessage),
+	}
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(b, &s.values); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// save writes the settings to disk; the mutex must be held. The file is
+// replaced atomically so that it is never left partially written.
+func (s *Settings) save() error {
+	b, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Get decodes the value for key into v, returning false if it is not set.
+func (s *Settings) Get(key string, v any) (bool, error) {
+	s.mutex.Lock()
+	raw, ok := s.values[key]
+	s.mutex.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Set stores v (which must be encodable as JSON) for key and saves the
+// settings. The function registered with OnChange is invoked if the value
+// changed.
+func (s *Settings) Set(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	if string(s.values[key]) == string(raw) {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.values[key] = raw
+	err = s.save()
+	var (
+		onChange = s.onChange
+		watchers = s.watchers
+	)
+	s.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, watcher := range watchers {
+		watcher.fn(key)
+	}
+	if onChange != nil {
+		onChange(key)
+	}
+	return nil
+}
+
+// Bool returns the boolean value for key or def if it is not set.
+func (s *Settings) Bool(key string, def bool) bool {
+	v := def
+	if ok, err := s.Get(key, &v); !ok || err != nil {
+		return def
+	}
+	return v
+}
+
+// String returns the string value for key or def if it is not set.
+func (s *Settings) String(key, def string) string {
+	v := def
+	if ok, err := s.Get(key, &v); !ok || err != nil {
+		return def
+	}
+	return v
+}
+
+// Int returns the integer value for key or def if it is not set.
+func (s *Settings) Int(key string, def int) int {
+	v := def
+	if ok, err := s.Get(key, &v); !ok || err != nil {
+		return def
+	}
+	return v
+}
+
+// OnChange registers a function to be invoked when a value is changed. It is
+// invoked on the goroutine that called Set.
+func (s *Settings) OnChange(fn func(key string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onChange = fn
+}
+
+// watch registers an internal function to be invoked when a value changes.
+// The returned function unregisters it.
+func (s *Settings) watch(fn func(key string)) func() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.watchId++
+	id := s.watchId
+	s.watchers = append(s.watchers, pWatcher{id: id, fn: fn})
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for i, v := range s.watchers {
+			if v.id == id {
+				s.watchers = append(s.watchers[:i:i], s.watchers[i+1:]...)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package wintray
+
+// pSettingItem binds a menu item added with AddSettingMenuItem to a boolean
+// setting.
+type pSettingItem struct {
+	s       *Settings
+	key     string
+	unwatch func()
+}
+
+// AddSilenceMenuItem adds a menu item with a check mark that turns
+// notifications on and off with SetSilenced. The choice is stored in s under
+// SilencedKey, so it is restored the next time the application starts, and
+// calls to SetSilenced are stored there too so that the check mark follows
+// them.
+func (w *WinTray) AddSilenceMenuItem(text string, s *Settings) error {
+	if err := w.SetSilenced(s.Bool(SilencedKey, false)); err != nil {
+		return err
+	}
+	return w.addSettingMenuItem("AddSilenceMenuItem", text, s, SilencedKey, w.setSilenced)
+}
+
+// AddSettingMenuItem adds a menu item with a check mark bound to the boolean
+// setting with the specified key. Selecting the item toggles the setting and
+// the check mark follows the setting when it is changed elsewhere.
+func (w *WinTray) AddSettingMenuItem(text string, s *Settings, key string) error {
+	return w.addSettingMenuItem("AddSettingMenuItem", text, s, key, nil)
+}
+
+// addSettingMenuItem adds a menu item bound to a setting. If onChange is not
+// nil, it is invoked on the UI thread with the new value whenever the
+// setting changes, for as long as the item remains in the menu.
+func (w *WinTray) addSettingMenuItem(op, text string, s *Settings, key string, onChange func(bool) error) error {
+	text, err := normalizeText(op, text)
+	if err != nil {
+		return err
+	}
+	return w.invoke(func() error {
+		id, err := w.newMenuId(op)
+		if err != nil {
+			return err
+		}
+		if err := w.backend.addMenuItem(id, text); err != nil {
+			return err
+		}
+		if err := w.backend.checkMenuItem(id, s.Bool(key, false)); err != nil {
+			return err
+		}
+		w.menuFns[id] = func() {
+			if err := s.Set(key, !s.Bool(key, false)); err != nil {
+				w.reportError(err, nil)
+			}
+		}
+		unwatch := s.watch(func(changed string) {
+			if changed != key {
+				return
+			}
+			checked := s.Bool(key, false)
+			w.post(&pMessage{
+				Type: pMESSAGE_INVOKE,
+				Data: func() error {
+					if _, ok := w.menuSetting[id]; !ok {
+						return nil
+					}
+					if err := w.backend.checkMenuItem(id, checked); err != nil {
+						return err
+					}
+					if onChange != nil {
+						return onChange(checked)
+					}
+					return nil
+				},
+			})
+		})
+		w.menuSetting[id] = &pSettingItem{
+			s:       s,
+			key:     key,
+			unwatch: unwatch,
+		}
+		return nil
+	})
+}
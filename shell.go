@@ -0,0 +1,69 @@
+//go:build windows
+
+package wintray
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// shellExecute invokes ShellExecute with the specified verb; an empty verb
+// performs the default action.
+func shellExecute(op, verb, file, params, dir string) error {
+	if err := checkText(op, verb, file, params, dir); err != nil {
+		return err
+	}
+	var verbPtr, paramsPtr, dirPtr *uint16
+	if verb != "" {
+		verbPtr = utf16PtrFromString(verb)
+	}
+	if params != "" {
+		paramsPtr = utf16PtrFromString(params)
+	}
+	if dir != "" {
+		dirPtr = utf16PtrFromString(dir)
+	}
+	if err := windows.ShellExecute(
+		0,
+		verbPtr,
+		utf16PtrFromString(file),
+		paramsPtr,
+		dirPtr,
+		windows.SW_SHOWNORMAL,
+	); err != nil {
+		return newErrorFrom(op, "unable to open "+file, nil, err)
+	}
+	return nil
+}
+
+// OpenURL opens the URL in the default browser (or the application
+// registered for its scheme).
+func OpenURL(url string) error {
+	return shellExecute("OpenURL", "open", url, "", "")
+}
+
+// OpenFolder opens the folder in Explorer.
+func OpenFolder(path string) error {
+	return shellExecute("OpenFolder", "explore", path, "", "")
+}
+
+// RevealInExplorer opens the folder containing the file or folder in
+// Explorer and selects it.
+func RevealInExplorer(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return newErrorFrom("RevealInExplorer", "unable to resolve path", nil, err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return newErrorFrom("RevealInExplorer", "unable to find "+abs, nil, err)
+	}
+	return shellExecute(
+		"RevealInExplorer",
+		"open",
+		"explorer.exe",
+		"/select,"+windows.EscapeArg(abs),
+		"",
+	)
+}
@@ -0,0 +1,105 @@
+package wintray
+
+import (
+	"time"
+)
+
+// SilencedKey is the setting that stores whether notifications are silenced
+// when using AddSilenceMenuItem.
+const SilencedKey = "notifications.silenced"
+
+// The most notifications held back by WithQueueWhileSilenced; older ones are
+// discarded once it is reached
+const pMaxSilencedQueue = 20
+
+// The delay between notifications held back by WithQueueWhileSilenced once
+// they are shown, since each notification replaces the previous one
+const pSilencedQueueInterval = 5 * time.Second
+
+// SetSilenced turns the application's own do-not-disturb mode on or off.
+// While it is on, ShowNotification does not display anything and returns
+// ErrSuppressed (running the function passed to WithSuppressedFallback, if
+// any), unless the icon was created with WithQueueWhileSilenced, in which
+// case the notifications are shown one at a time once it is turned off. The
+// choice is stored in the Settings passed to AddSilenceMenuItem, if any.
+func (w *WinTray) SetSilenced(silenced bool) error {
+	return w.invoke(func() error {
+		if err := w.setSilenced(silenced); err != nil {
+			return err
+		}
+
+		// Store the choice in the settings bound to any menu item for it,
+		// whose watcher then updates the check mark
+		for _, item := range w.menuSetting {
+			if item.key != SilencedKey {
+				continue
+			}
+			if err := item.s.Set(SilencedKey, silenced); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// IsSilenced reports whether notifications are silenced with SetSilenced.
+func (w *WinTray) IsSilenced() bool {
+	var silenced bool
+	w.invoke(func() error {
+		silenced = w.silenced
+		return nil
+	})
+	return silenced
+}
+
+// setSilenced changes the mode on the UI thread, showing any notifications
+// that were held back when it is turned off.
+func (w *WinTray) setSilenced(silenced bool) error {
+	w.silenced = silenced
+	w.showSilencedQueue()
+	return nil
+}
+
+// showSilencedQueue shows the oldest notification that was held back and
+// schedules the next one, unless notifications are silenced again or the
+// next one is already scheduled.
+func (w *WinTray) showSilencedQueue() {
+	if w.silenced || w.silencedTimer || len(w.silencedQueue) == 0 {
+		return
+	}
+	d := w.silencedQueue[0]
+	w.silencedQueue = w.silencedQueue[1:]
+	w.deliverNotification(d)
+	if len(w.silencedQueue) == 0 {
+		return
+	}
+	w.silencedTimer = true
+	time.AfterFunc(pSilencedQueueInterval, func() {
+		w.post(&pMessage{
+			Type: pMESSAGE_INVOKE,
+			Data: func() error {
+				w.silencedTimer = false
+				w.showSilencedQueue()
+				return nil
+			},
+		})
+	})
+}
+
+// silenceNotification holds back or discards a notification shown while
+// notifications are silenced. Notifications shown while earlier ones are
+// still waiting to be shown are also held back so that they stay in order.
+func (w *WinTray) silenceNotification(d *pDataShowNotification) error {
+	if w.queueSilenced {
+		if len(w.silencedQueue) == pMaxSilencedQueue {
+			w.silencedQueue = w.silencedQueue[1:]
+		}
+		w.silencedQueue = append(w.silencedQueue, d)
+		return nil
+	}
+	w.count(MetricNotificationsSuppressed)
+	if d.OnSuppressed != nil {
+		go w.invokeHandler(d.OnSuppressed)
+	}
+	return newErrorFrom("ShowNotification", "notifications are silenced", ErrSuppressed, nil)
+}
@@ -0,0 +1,25 @@
+package wintray
+
+// Sound is played when a notification is shown. The zero value plays the
+// sound that Windows uses for notifications, which the user can change in the
+// Sound control panel.
+type Sound struct {
+	name   string
+	file   bool
+	silent bool
+}
+
+// NoSound shows notifications without playing a sound.
+var NoSound = Sound{silent: true}
+
+// SoundFile plays the WAV file at path.
+func SoundFile(path string) Sound {
+	return Sound{name: path, file: true}
+}
+
+// SystemSound plays one of the sounds from the Sound control panel, using
+// its alias, such as "SystemAsterisk", "SystemExclamation", or
+// "SystemNotification". Nothing is played if the alias is not recognized.
+func SystemSound(alias string) Sound {
+	return Sound{name: alias}
+}
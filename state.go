@@ -0,0 +1,63 @@
+package wintray
+
+// State is a stage in the life of an icon.
+type State int
+
+const (
+
+	// StateStarting indicates that the icon is being created.
+	StateStarting State = iota
+
+	// StateReady indicates that the icon is in the notification area.
+	StateReady
+
+	// StateDegraded indicates that the event loop is running but the icon is
+	// missing from the notification area, such as because Explorer crashed
+	// or the shell rejected it. The icon is added again automatically when
+	// the taskbar is recreated.
+	StateDegraded
+
+	// StateClosing indicates that Close was called (or the icon is shutting
+	// down for another reason) and the event loop has not yet ended.
+	StateClosing
+
+	// StateClosed indicates that the event loop has ended; Err reports why.
+	StateClosed
+)
+
+// State returns the current state of the icon.
+func (w *WinTray) State() State {
+	w.stateMutex.Lock()
+	defer w.stateMutex.Unlock()
+	return w.state
+}
+
+// OnStateChange registers a function to be invoked when the state of the
+// icon changes, such as to alert the user or restart the application when
+// the icon goes missing. The function runs on its own goroutine, so calls
+// for changes in quick succession may overlap; State reports the latest
+// state.
+func (w *WinTray) OnStateChange(fn func(state State)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onStateChange = fn
+}
+
+// setState moves the icon to state s. Once the icon is closing, it can only
+// move on to StateClosed.
+func (w *WinTray) setState(s State) {
+	w.stateMutex.Lock()
+	if w.state == s || (w.state >= StateClosing && s < w.state) {
+		w.stateMutex.Unlock()
+		return
+	}
+	w.state = s
+	w.stateMutex.Unlock()
+	w.debug("state changed", "state", s)
+	w.hooksMutex.Lock()
+	fn := w.onStateChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		go w.invokeHandler(func() { fn(s) })
+	}
+}
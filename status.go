@@ -0,0 +1,117 @@
+package wintray
+
+import (
+	"time"
+)
+
+// pStatus is a status defined with DefineStatus.
+type pStatus struct {
+	icon       []byte
+	tip        string
+	menuHeader string
+}
+
+// DefineStatus registers a named status, such as "idle", "busy", or "error",
+// that ApplyStatus can switch to in a single call. Each of icon, tip, and
+// menuHeader is left unchanged when switching if it is empty. Defining a
+// status again replaces it. ErrInvalidImage is returned if icon is not an ICO
+// file or PNG image.
+func (w *WinTray) DefineStatus(name string, icon []byte, tip, menuHeader string) error {
+	if name == "" {
+		return newErrorFrom("DefineStatus", "status name is empty", ErrInvalidText, nil)
+	}
+	if len(icon) > 0 {
+		if _, err := iconImages(icon); err != nil {
+			return newErrorFrom("DefineStatus", "invalid icon", ErrInvalidImage, err)
+		}
+	}
+	return w.invoke(func() error {
+		if w.statuses == nil {
+			w.statuses = make(map[string]*pStatus)
+		}
+		w.statuses[name] = &pStatus{
+			icon:       icon,
+			tip:        tip,
+			menuHeader: menuHeader,
+		}
+		return nil
+	})
+}
+
+// ApplyStatus switches to a status defined with DefineStatus, changing the
+// icon, tooltip, and status line at the top of the menu (see SetStatus)
+// together. ErrNoStatus is returned if no status has the specified name. The
+// text is checked before anything is changed, so invalid text leaves the
+// icon as it was.
+func (w *WinTray) ApplyStatus(name string) error {
+	now := time.Now()
+	return w.invoke(func() error {
+		s, ok := w.statuses[name]
+		if !ok {
+			return newErrorFrom("ApplyStatus", "no status named "+name, ErrNoStatus, nil)
+		}
+		return w.applyStatus(s, now)
+	})
+}
+
+// SetStatus displays a status line in both the tooltip and a disabled item at
+// the top of the menu, which is added (along with a separator) the first time
+// SetStatus is called. If WithStatusTimestamp was used, the current time is
+// appended to the text. As with SetTip, ErrTruncated is returned if the text
+// is too long for the tooltip, though the menu item shows it in full.
+func (w *WinTray) SetStatus(text string) error {
+	now := time.Now()
+	return w.invoke(func() error {
+		text, err := normalizeText("SetStatus", w.translate(text))
+		if err != nil {
+			return err
+		}
+		if w.statusLayout != "" {
+			text += " (" + now.Format(w.statusLayout) + ")"
+		}
+		if err := w.backend.setMenuHeader(text); err != nil {
+			return err
+		}
+		w.tipIsKey = false
+		return w.backend.setTip(text)
+	})
+}
+
+// applyStatus changes the icon, tooltip, and menu item to those of s on the
+// UI thread. The text is translated and checked first so that the only
+// failures part way through are those of the shell itself.
+func (w *WinTray) applyStatus(s *pStatus, now time.Time) error {
+	var header, tip string
+	if s.menuHeader != "" {
+		text, err := normalizeText("ApplyStatus", w.translate(s.menuHeader))
+		if err != nil {
+			return err
+		}
+		if w.statusLayout != "" {
+			text += " (" + now.Format(w.statusLayout) + ")"
+		}
+		header = text
+	}
+	if s.tip != "" {
+		text, err := normalizeText("ApplyStatus", w.translate(s.tip))
+		if err != nil {
+			return err
+		}
+		tip = text
+	}
+	if len(s.icon) > 0 {
+		if err := w.backend.setIcon(s.icon); err != nil {
+			return err
+		}
+	}
+	if header != "" {
+		if err := w.backend.setMenuHeader(header); err != nil {
+			return err
+		}
+	}
+	if tip != "" {
+		w.tipKey, w.tipIsKey = s.tip, w.translator != nil
+		return w.backend.setTip(tip)
+	}
+	return nil
+}
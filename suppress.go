@@ -0,0 +1,74 @@
+//go:build windows
+
+package wintray
+
+import (
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	pPushNotificationsKey    = `Software\Microsoft\Windows\CurrentVersion\PushNotifications`
+	pNotificationSettingsKey = `Software\Microsoft\Windows\CurrentVersion\Notifications\Settings\`
+)
+
+var pGetCurrentProcessExplicitAppUserModelID = shell32.MustFindProc("GetCurrentProcessExplicitAppUserModelID")
+
+// registryDisabled reports whether the DWORD value in the key under
+// HKEY_CURRENT_USER exists and is zero.
+func registryDisabled(path, name string) bool {
+	k, err := registry.OpenKey(registry.CURRENT_USER, path, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer k.Close()
+	v, _, err := k.GetIntegerValue(name)
+	return err == nil && v == 0
+}
+
+// currentAppID returns the AppUserModelID set with SetAppID, or "" if none
+// was set.
+func currentAppID() string {
+	var p *uint16
+	if hr, _, _ := pGetCurrentProcessExplicitAppUserModelID.Call(
+		uintptr(unsafe.Pointer(&p)),
+	); hr != 0 || p == nil {
+		return ""
+	}
+	defer windows.CoTaskMemFree(unsafe.Pointer(p))
+	return windows.UTF16PtrToString(p)
+}
+
+// notificationsSuppressed returns the reason that notifications will not be
+// displayed, or "" if they will be.
+func notificationsSuppressed() string {
+	var state int32
+	if win.SHQueryUserNotificationState(&state) == 0 {
+		switch state {
+		case win.QUNS_NOT_PRESENT:
+			return "user is not present"
+		case win.QUNS_BUSY, win.QUNS_RUNNING_D3D_FULL_SCREEN:
+			return "a full-screen application is running"
+		case win.QUNS_PRESENTATION_MODE:
+			return "presentation mode is on"
+		case win.QUNS_QUIET_TIME:
+			return "quiet hours are on"
+		case win.QUNS_APP:
+			return "a Windows Store app is running"
+		}
+	}
+	if state, err := FocusAssistState(); err == nil && state != FocusAssistOff {
+		return "Focus Assist is on"
+	}
+	if registryDisabled(pPushNotificationsKey, "ToastEnabled") {
+		return "notifications are turned off"
+	}
+	if id := currentAppID(); id != "" &&
+		registryDisabled(pNotificationSettingsKey+id, "Enabled") {
+		return "notifications are turned off for " + id
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+//go:build windows
+
+package wintray
+
+import (
+	"github.com/nathan-osman/go-wintray/internal/win"
+)
+
+var (
+	pCLSID_TaskbarList = mustGUID("{56FDF344-FD6D-11D0-958A-006097C9A090}")
+	pIID_ITaskbarList3 = mustGUID("{EA1AFB91-9E28-4B86-90E9-9E9F8A5EEFAF}")
+)
+
+// Vtable indices for ITaskbarList3
+const (
+	pITaskbarList3_HrInit           = 3
+	pITaskbarList3_SetProgressValue = 9
+	pITaskbarList3_SetProgressState = 10
+)
+
+// TaskbarProgress displays progress on the taskbar button of a window
+// belonging to the application (such as an optional main window). The tray
+// icon itself has no taskbar button, so this is only useful alongside another
+// window.
+type TaskbarProgress struct {
+	w    *WinTray
+	hwnd win.HWND
+
+	// Only accessed from the UI thread
+	list *pComObject
+}
+
+// NewTaskbarProgress creates a TaskbarProgress for the window with the
+// specified handle, which must have a taskbar button.
+func (w *WinTray) NewTaskbarProgress(hwnd uintptr) (*TaskbarProgress, error) {
+	p := &TaskbarProgress{
+		w:    w,
+		hwnd: win.HWND(hwnd),
+	}
+	if err := w.invoke(func() error {
+		if err := w.initCOM(); err != nil {
+			return err
+		}
+		list, err := createInstance(
+			"NewTaskbarProgress",
+			pCLSID_TaskbarList,
+			pIID_ITaskbarList3,
+		)
+		if err != nil {
+			return err
+		}
+		if hr := list.call(pITaskbarList3_HrInit); hr != 0 {
+			list.release()
+			return newHRESULTError("NewTaskbarProgress", "unable to initialize taskbar list", nil, hr)
+		}
+		p.list = list
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetState changes the appearance of the progress indicator.
+func (p *TaskbarProgress) SetState(state ProgressState) error {
+	return p.w.invoke(func() error {
+		if p.list == nil {
+			return ErrClosed
+		}
+		if hr := p.list.call(
+			pITaskbarList3_SetProgressState,
+			uintptr(p.hwnd),
+			uintptr(state),
+		); hr != 0 {
+			return newHRESULTError("SetState", "unable to set progress state", nil, hr)
+		}
+		return nil
+	})
+}
+
+// SetValue sets the amount of progress that has been made. If the state is
+// ProgressNone or ProgressIndeterminate, it changes to ProgressNormal.
+func (p *TaskbarProgress) SetValue(completed, total uint64) error {
+	return p.w.invoke(func() error {
+		if p.list == nil {
+			return ErrClosed
+		}
+		args := []uintptr{uintptr(p.hwnd)}
+		args = append(args, uint64Args(completed)...)
+		args = append(args, uint64Args(total)...)
+		if hr := p.list.call(pITaskbarList3_SetProgressValue, args...); hr != 0 {
+			return newHRESULTError("SetValue", "unable to set progress value", nil, hr)
+		}
+		return nil
+	})
+}
+
+// Close removes the progress indicator and releases its resources.
+func (p *TaskbarProgress) Close() error {
+	return p.w.invoke(func() error {
+		if p.list == nil {
+			return nil
+		}
+		p.list.call(
+			pITaskbarList3_SetProgressState,
+			uintptr(p.hwnd),
+			uintptr(ProgressNone),
+		)
+		p.list.release()
+		p.list = nil
+		return nil
+	})
+}
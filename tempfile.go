@@ -0,0 +1,234 @@
+//go:build windows
+
+package wintray
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// Icons are written to files in this subdirectory of the temporary
+	// directory so that they can be loaded with LoadImage; the name is
+	// suffixed with the SID of the user so that each user has their own
+	pTempDirName = "go-wintray"
+	pTempPattern = "icon-*.ico"
+
+	// Files older than this are assumed to have been left behind by a
+	// process that crashed while loading an icon
+	pTempOrphanAge = time.Minute
+)
+
+// ACCESS_ALLOWED_ACE_TYPE is the only type of ACE expected in the DACL of the
+// temporary directory
+const pACCESS_ALLOWED_ACE_TYPE = 0
+
+var (
+	// The private subdirectory of each temporary directory, which is only
+	// recorded once it has been created or verified and cleaned up
+	tempDirMutex sync.Mutex
+	tempDirs     = make(map[string]string)
+
+	// x/sys/windows does not provide a way to read the entries in an ACL
+	pGetAce = windows.NewLazySystemDLL("advapi32.dll").NewProc("GetAce")
+)
+
+// pACL mirrors the header of an ACL, whose fields are not exported by
+// x/sys/windows.
+type pACL struct {
+	AclRevision byte
+	Sbz1        byte
+	AclSize     uint16
+	AceCount    uint16
+	Sbz2        uint16
+}
+
+// pACCESS_ALLOWED_ACE is the fixed part of an ACCESS_ALLOWED_ACE, which is
+// followed by the rest of the SID starting at SidStart.
+type pACCESS_ALLOWED_ACE struct {
+	AceType  byte
+	AceFlags byte
+	AceSize  uint16
+	Mask     uint32
+	SidStart uint32
+}
+
+// iconTempBase returns the directory in which the private subdirectory for
+// icons is created.
+func (w *WinTray) iconTempBase() string {
+	if w.tempDir != "" {
+		return w.tempDir
+	}
+	return os.TempDir()
+}
+
+// iconTempDir returns the directory used for temporary icon files, creating
+// it if necessary. The directory is created with access restricted to the
+// current user, and orphaned files in it are removed, the first time it is
+// used by the process; later calls return the same directory.
+func (w *WinTray) iconTempDir() (string, error) {
+	base := w.iconTempBase()
+	tempDirMutex.Lock()
+	defer tempDirMutex.Unlock()
+	if dir, ok := tempDirs[base]; ok {
+		return dir, nil
+	}
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, pTempDirName+"-"+user.User.Sid.String())
+	if err := createPrivateDir(dir, user.User.Sid); err != nil {
+		return "", err
+	}
+	w.removeOrphanedIcons(dir)
+	tempDirs[base] = dir
+	return dir, nil
+}
+
+// forgetIconTempDir discards the recorded directory for temporary icon files
+// so that the next call to iconTempDir creates and verifies it again, such
+// as after it has been removed by a disk cleanup tool.
+func (w *WinTray) forgetIconTempDir() {
+	tempDirMutex.Lock()
+	defer tempDirMutex.Unlock()
+	delete(tempDirs, w.iconTempBase())
+}
+
+// createPrivateDir creates a directory owned by sid that only sid and SYSTEM
+// can access. If the directory already exists, it is checked with
+// checkPrivateDir instead, since another user may have created it first.
+func createPrivateDir(dir string, sid *windows.SID) error {
+	sd, err := windows.SecurityDescriptorFromString(
+		"O:" + sid.String() + "D:P(A;OICI;FA;;;" + sid.String() + ")(A;OICI;FA;;;SY)",
+	)
+	if err != nil {
+		return err
+	}
+	sa := &windows.SecurityAttributes{
+		SecurityDescriptor: sd,
+	}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return err
+	}
+	if err := windows.CreateDirectory(p, sa); err != nil {
+		if err != windows.ERROR_ALREADY_EXISTS {
+			return err
+		}
+		return checkPrivateDir(dir, sid)
+	}
+	return nil
+}
+
+// checkPrivateDir returns an error of kind ErrTempDirNotPrivate unless dir is
+// a directory (and not a link to one) owned by sid whose DACL only grants
+// access to sid and SYSTEM.
+func checkPrivateDir(dir string, sid *windows.SID) error {
+	errNotPrivate := newErrorFrom("LoadIcon", "temporary directory "+dir+" is not private", ErrTempDirNotPrivate, nil)
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeType != os.ModeDir {
+		return errNotPrivate
+	}
+	sd, err := windows.GetNamedSecurityInfo(
+		dir,
+		windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION,
+	)
+	if err != nil {
+		return err
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return err
+	}
+	if !owner.Equals(sid) {
+		return errNotPrivate
+	}
+
+	// A missing DACL grants access to everyone
+	dacl, _, err := sd.DACL()
+	if err != nil || dacl == nil {
+		return errNotPrivate
+	}
+	system, err := windows.CreateWellKnownSid(windows.WinLocalSystemSid)
+	if err != nil {
+		return err
+	}
+	n := (*pACL)(unsafe.Pointer(dacl)).AceCount
+	for i := uint16(0); i < n; i++ {
+		var ace *pACCESS_ALLOWED_ACE
+		if r, _, _ := pGetAce.Call(
+			uintptr(unsafe.Pointer(dacl)),
+			uintptr(i),
+			uintptr(unsafe.Pointer(&ace)),
+		); r == 0 {
+			return errNotPrivate
+		}
+		if ace.AceType != pACCESS_ALLOWED_ACE_TYPE {
+			return errNotPrivate
+		}
+		aceSid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+		if !aceSid.Equals(sid) && !aceSid.Equals(system) {
+			return errNotPrivate
+		}
+	}
+	return nil
+}
+
+// removeOrphanedIcons removes icon files left behind by earlier runs that
+// crashed before they could clean up.
+func (w *WinTray) removeOrphanedIcons(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	prefix, suffix, _ := strings.Cut(pTempPattern, "*")
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < pTempOrphanAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err == nil {
+			w.debug("removed orphaned icon file", "name", name)
+		}
+	}
+}
+
+// writeTempIcon writes the contents of an ICO file to a temporary file and
+// returns its name. The caller must remove the file.
+func (w *WinTray) writeTempIcon(b []byte) (string, error) {
+	dir, err := w.iconTempDir()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, pTempPattern)
+	if err != nil {
+		w.forgetIconTempDir()
+		return "", err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,60 @@
+package wintray
+
+import (
+	"fmt"
+)
+
+// Events that can be simulated with Tester
+const (
+	pSIMULATE_MENU_ITEM = iota
+	pSIMULATE_ICON_CLICK
+	pSIMULATE_NOTIFICATION_CLICK
+)
+
+// Tester simulates user interaction with an icon for integration tests. The
+// events are posted to the event loop and processed exactly as if the user
+// had performed them, so functions registered with the icon run
+// asynchronously after the methods return.
+type Tester struct {
+	w *WinTray
+}
+
+// Test returns a Tester for the icon.
+func (w *WinTray) Test() *Tester {
+	return &Tester{w: w}
+}
+
+// ClickMenuItem simulates selecting the first menu item with the specified
+// label.
+func (t *Tester) ClickMenuItem(label string) error {
+	var (
+		id uint32
+		ok bool
+	)
+	if err := t.w.invoke(func() error {
+		id, ok = t.w.backend.findMenuItem(label)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("wintray: no menu item %q", label)
+	}
+	return t.simulate(pSIMULATE_MENU_ITEM, id)
+}
+
+// ClickIcon simulates clicking the icon with the left mouse button.
+func (t *Tester) ClickIcon() error {
+	return t.simulate(pSIMULATE_ICON_CLICK, 0)
+}
+
+// ClickNotification simulates clicking a notification shown by the icon.
+func (t *Tester) ClickNotification() error {
+	return t.simulate(pSIMULATE_NOTIFICATION_CLICK, 0)
+}
+
+func (t *Tester) simulate(event int, id uint32) error {
+	return t.w.invoke(func() error {
+		return t.w.backend.simulate(event, id)
+	})
+}
@@ -0,0 +1,122 @@
+package wintray
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	pPDF = '\u202c' // POP DIRECTIONAL FORMATTING
+	pPDI = '\u2069' // POP DIRECTIONAL ISOLATE
+)
+
+// normalizeText prepares text supplied by the application for display in
+// the tooltip, menu, or a notification. Text containing a null character or
+// invalid UTF-8 is rejected, since Windows would silently cut it short or
+// show replacement characters. Otherwise, "\r\n" is replaced with "\n",
+// control characters other than "\n" and "\t" are removed, and unterminated
+// bidirectional embeddings, overrides, and isolates are closed so that they
+// cannot affect text displayed after them (such as the timestamp appended by
+// SetStatus).
+func normalizeText(op, text string) (string, error) {
+	if err := checkText(op, text); err != nil {
+		return "", err
+	}
+	if !utf8.ValidString(text) {
+		return "", newErrorFrom(op, "text is not valid UTF-8", ErrInvalidText, nil)
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var (
+		b       strings.Builder
+		pending []rune
+	)
+	b.Grow(len(text))
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\t':
+		case unicode.IsControl(r):
+			continue
+
+		// LRE, RLE, LRO, and RLO are closed by PDF
+		case r >= '\u202a' && r <= '\u202e' && r != pPDF:
+			pending = append(pending, pPDF)
+
+		// LRI, RLI, and FSI are closed by PDI
+		case r >= '\u2066' && r <= '\u2068':
+			pending = append(pending, pPDI)
+
+		// PDF only closes an embedding or override within the current
+		// isolate, and is ignored otherwise
+		case r == pPDF:
+			if len(pending) == 0 || pending[len(pending)-1] != pPDF {
+				continue
+			}
+			pending = pending[:len(pending)-1]
+
+		// PDI closes the innermost isolate along with any embeddings and
+		// overrides within it, and is ignored if there is no isolate open
+		case r == pPDI:
+			i := len(pending) - 1
+			for i >= 0 && pending[i] != pPDI {
+				i--
+			}
+			if i < 0 {
+				continue
+			}
+			pending = pending[:i]
+		}
+		b.WriteRune(r)
+	}
+	for i := len(pending) - 1; i >= 0; i-- {
+		b.WriteRune(pending[i])
+	}
+	return b.String(), nil
+}
+
+// checkText returns ErrInvalidText if any of the values contains a null
+// character, which Windows would otherwise treat as the end of the string.
+func checkText(op string, values ...string) error {
+	for _, v := range values {
+		if strings.IndexByte(v, 0) != -1 {
+			return newErrorFrom(op, "text contains a null character", ErrInvalidText, nil)
+		}
+	}
+	return nil
+}
+
+// copyToUint16Buffer copies text into a fixed-size buffer (such as
+// nid.SzTip[:]), truncating it if necessary so that it remains
+// null-terminated. Text after an embedded null character is dropped and a
+// surrogate pair is never split. It returns true if the text was truncated.
+func copyToUint16Buffer(buf []uint16, text string) bool {
+	var (
+		n         = 0
+		truncated = false
+	)
+	for _, r := range text {
+		if r == 0 {
+			truncated = true
+			break
+		}
+		r1, r2 := utf16.EncodeRune(r)
+		if r1 == unicode.ReplacementChar {
+			if n+1 >= len(buf) {
+				truncated = true
+				break
+			}
+			buf[n] = uint16(r)
+			n++
+			continue
+		}
+		if n+2 >= len(buf) {
+			truncated = true
+			break
+		}
+		buf[n], buf[n+1] = uint16(r1), uint16(r2)
+		n += 2
+	}
+	buf[n] = 0
+	return truncated
+}
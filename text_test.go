@@ -0,0 +1,205 @@
+package wintray
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// uint16BufferText returns the text in buf up to the null terminator,
+// failing the test if there is none.
+func uint16BufferText(t *testing.T, buf []uint16) string {
+	t.Helper()
+	for i, v := range buf {
+		if v == 0 {
+			return string(utf16.Decode(buf[:i]))
+		}
+	}
+	t.Fatal("buffer is not null-terminated")
+	return ""
+}
+
+// checkUTF16 fails the test if buf contains an unpaired surrogate.
+func checkUTF16(t *testing.T, buf []uint16) {
+	t.Helper()
+	for i := 0; i < len(buf); i++ {
+		switch {
+		case buf[i] >= 0xd800 && buf[i] < 0xdc00:
+			if i+1 == len(buf) || buf[i+1] < 0xdc00 || buf[i+1] >= 0xe000 {
+				t.Fatalf("unpaired high surrogate at %d", i)
+			}
+			i++
+		case buf[i] >= 0xdc00 && buf[i] < 0xe000:
+			t.Fatalf("unpaired low surrogate at %d", i)
+		}
+	}
+}
+
+func TestCopyToUint16Buffer(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		size      int
+		text      string
+		want      string
+		truncated bool
+	}{
+		{"empty", 4, "", "", false},
+		{"exact fit", 4, "abc", "abc", false},
+		{"truncated", 4, "abcd", "abc", true},
+		{"surrogate pair fits", 4, "a\U0001f600", "a\U0001f600", false},
+		{"surrogate pair at last slot", 4, "ab\U0001f600", "ab", true},
+		{"embedded null", 10, "ab\x00cd", "ab", true},
+		{"title exact fit", 64, strings.Repeat("x", 63), strings.Repeat("x", 63), false},
+		{"title truncated", 64, strings.Repeat("x", 64), strings.Repeat("x", 63), true},
+		{"title surrogate pair at last slot", 64, strings.Repeat("x", 62) + "\U0001f600", strings.Repeat("x", 62), true},
+		{"tip exact fit", 128, strings.Repeat("x", 127), strings.Repeat("x", 127), false},
+		{"info truncated", 256, strings.Repeat("x", 300), strings.Repeat("x", 255), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+
+			// Fill the buffer so that a missing terminator is detected
+			buf := make([]uint16, tc.size)
+			for i := range buf {
+				buf[i] = 'z'
+			}
+			truncated := copyToUint16Buffer(buf, tc.text)
+			if got := uint16BufferText(t, buf); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if truncated != tc.truncated {
+				t.Errorf("got truncated = %v, want %v", truncated, tc.truncated)
+			}
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+		want string
+		err  error
+	}{
+		{"plain", "hello", "hello", nil},
+		{"newline and tab", "a\nb\tc", "a\nb\tc", nil},
+		{"control characters", "a\x07b\x1bc\x7fd\u0085e", "abcde", nil},
+		{"CRLF", "a\r\nb\r\n", "a\nb\n", nil},
+		{"lone CR", "a\rb", "ab", nil},
+		{"unbalanced override", "\u202eabc", "\u202eabc\u202c", nil},
+		{"unbalanced isolate", "\u2067abc", "\u2067abc\u2069", nil},
+		{"nested", "\u2066\u202aabc", "\u2066\u202aabc\u202c\u2069", nil},
+		{"balanced", "\u202babc\u202c", "\u202babc\u202c", nil},
+		{"PDI closes embedding", "\u2068\u202dab\u2069c", "\u2068\u202dab\u2069c", nil},
+		{"stray PDF", "ab\u202cc", "abc", nil},
+		{"stray PDI", "ab\u2069c", "abc", nil},
+		{"PDF outside isolate", "\u202a\u2066a\u202cb", "\u202a\u2066ab\u2069\u202c", nil},
+		{"null character", "a\x00b", "", ErrInvalidText},
+		{"invalid UTF-8", "a\xffb", "", ErrInvalidText},
+		{"unpaired surrogate", "a\xed\xa0\x80b", "", ErrInvalidText},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := normalizeText("Test", tc.text)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v, want %v", err, tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestNormalizeTextLimits checks that normalized text is truncated to the
+// size of each NOTIFYICONDATA field without losing the closing bidi marks
+// when the text fits.
+func TestNormalizeTextLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		size      int
+		text      string
+		want      string
+		truncated bool
+	}{
+		{"tip fits", 128, strings.Repeat("x", 125) + "\u202e", strings.Repeat("x", 125) + "\u202e\u202c", false},
+		{"tip truncated", 128, strings.Repeat("x", 127) + "\u202e", strings.Repeat("x", 127), true},
+		{"tip CRLF", 128, strings.Repeat("x\r\n", 64), strings.Repeat("x\n", 63) + "x", true},
+		{"info fits", 256, strings.Repeat("x", 255), strings.Repeat("x", 255), false},
+		{"info truncated", 256, strings.Repeat("\U0001f600", 128), strings.Repeat("\U0001f600", 127), true},
+		{"title fits", 64, strings.Repeat("x\x07", 63), strings.Repeat("x", 63), false},
+		{"title truncated", 64, strings.Repeat("x", 64), strings.Repeat("x", 63), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := normalizeText("Test", tc.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := make([]uint16, tc.size)
+			truncated := copyToUint16Buffer(buf, text)
+			if got := uint16BufferText(t, buf); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if truncated != tc.truncated {
+				t.Errorf("got truncated = %v, want %v", truncated, tc.truncated)
+			}
+		})
+	}
+}
+
+func FuzzNormalizeText(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"hello",
+		"a\r\nb\tc",
+		"\x07\x1b\u0085",
+		"\u202e\u2067abc\u202c",
+		"\u2066\u202a\u2069\u202c",
+		"\U0001f600\U0001f600",
+		"a\x00b",
+		"a\xffb",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		out, err := normalizeText("Fuzz", text)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidText) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			return
+		}
+		if !utf8.ValidString(out) {
+			t.Fatalf("%q is not valid UTF-8", out)
+		}
+
+		// Only a closing mark for each opening mark may be added
+		var (
+			inLen  = len(utf16.Encode([]rune(text)))
+			outLen = len(utf16.Encode([]rune(out)))
+			marks  = 0
+		)
+		for _, r := range text {
+			if (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2068') {
+				marks++
+			}
+		}
+		if outLen > inLen+marks {
+			t.Fatalf("output has %d units, input has %d units and %d marks", outLen, inLen, marks)
+		}
+
+		// Each field must be null-terminated valid UTF-16 of at most its size
+		for _, size := range []int{64, 128, 256} {
+			buf := make([]uint16, size)
+			copyToUint16Buffer(buf, out)
+			n := 0
+			for n < size && buf[n] != 0 {
+				n++
+			}
+			if n == size {
+				t.Fatalf("%d-unit buffer is not null-terminated", size)
+			}
+			checkUTF16(t, buf[:n])
+		}
+	})
+}
@@ -0,0 +1,120 @@
+//go:build windows
+
+package wintray
+
+import (
+	"errors"
+	"image/color"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	pPersonalizeKey = `Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`
+	pDWMKey         = `Software\Microsoft\Windows\DWM`
+)
+
+// readLightTheme reads one of the *UsesLightTheme values. Versions of Windows
+// that predate dark mode do not have these values, so light is assumed.
+func readLightTheme(k registry.Key, name string) (bool, error) {
+	v, _, err := k.GetIntegerValue(name)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	return v != 0, nil
+}
+
+// CurrentTheme reads the current theme settings.
+func CurrentTheme() (ThemeInfo, error) {
+	var info ThemeInfo
+	k, err := registry.OpenKey(registry.CURRENT_USER, pPersonalizeKey, registry.QUERY_VALUE)
+	switch {
+	case err == nil:
+		defer k.Close()
+		appsLight, err := readLightTheme(k, "AppsUseLightTheme")
+		if err != nil {
+			return ThemeInfo{}, newErrorFrom("CurrentTheme", "unable to read theme", nil, err)
+		}
+		systemLight, err := readLightTheme(k, "SystemUsesLightTheme")
+		if err != nil {
+			return ThemeInfo{}, newErrorFrom("CurrentTheme", "unable to read theme", nil, err)
+		}
+		info.AppsDark = !appsLight
+		info.SystemDark = !systemLight
+	case !errors.Is(err, registry.ErrNotExist):
+		return ThemeInfo{}, newErrorFrom("CurrentTheme", "unable to read theme", nil, err)
+	}
+
+	// The accent color is stored as 0xAABBGGRR
+	if k, err := registry.OpenKey(registry.CURRENT_USER, pDWMKey, registry.QUERY_VALUE); err == nil {
+		defer k.Close()
+		if v, _, err := k.GetIntegerValue("AccentColor"); err == nil {
+			info.Accent = color.RGBA{
+				R: uint8(v),
+				G: uint8(v >> 8),
+				B: uint8(v >> 16),
+				A: uint8(v >> 24),
+			}
+		}
+	}
+
+	info.HighContrast = highContrastEnabled()
+
+	return info, nil
+}
+
+// OnThemeChange registers a function to be invoked when the user switches
+// between light and dark mode, turns high contrast on or off, or changes the
+// accent color. Errors reading the
+// new theme are passed to the function registered with OnHandlerError.
+func (w *WinTray) OnThemeChange(fn func(ThemeInfo)) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onThemeChange = fn
+}
+
+// settingChanged is invoked on the UI thread when WM_SETTINGCHANGE is
+// received.
+func (w *WinTray) settingChanged(wparam, lparam uintptr) {
+	if wparam == win.SPI_SETHIGHCONTRAST {
+		w.highContrastChanged()
+		return
+	}
+	if lparam == 0 {
+		return
+	}
+	if windows.UTF16PtrToString((*uint16)(paramPointer(lparam))) != "ImmersiveColorSet" {
+		return
+	}
+	if w.darkModeMenus && loadDarkMode() {
+		refreshDarkModeMenus()
+	}
+	w.themeChanged()
+}
+
+// themeChanged reads the new theme and passes it to the function registered
+// with OnThemeChange and any subscribers to Events.
+func (w *WinTray) themeChanged() {
+	w.hooksMutex.Lock()
+	fn := w.onThemeChange
+	subscribed := w.events != nil
+	w.hooksMutex.Unlock()
+	if fn != nil || subscribed {
+		go func() {
+			info, err := CurrentTheme()
+			if err != nil {
+				w.reportError(err, nil)
+				return
+			}
+			w.emit(Event{Type: EventThemeChanged, Theme: info})
+			if fn != nil {
+				w.invokeHandler(func() { fn(info) })
+			}
+		}()
+	}
+}
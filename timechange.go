@@ -0,0 +1,24 @@
+//go:build windows
+
+package wintray
+
+// OnTimeChange registers a function to be invoked when the system time is
+// changed, whether by the user or by time synchronization, or the time zone
+// is changed. Note that Go reads the time zone once at startup, so time.Local
+// does not reflect a new time zone until the application is restarted.
+func (w *WinTray) OnTimeChange(fn func()) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onTimeChange = fn
+}
+
+// timeChanged is invoked on the UI thread when WM_TIMECHANGE is received.
+func (w *WinTray) timeChanged() {
+	w.rearmSchedules()
+	w.hooksMutex.Lock()
+	fn := w.onTimeChange
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		go w.invokeHandler(fn)
+	}
+}
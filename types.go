@@ -0,0 +1,352 @@
+package wintray
+
+import (
+	"image"
+	"image/color"
+)
+
+// The types in this file are shared by all platforms so that code using them
+// compiles everywhere; the functions that use them are only implemented on
+// Windows.
+
+// DeviceInterfaceClass identifies a device interface class by its GUID, in
+// the form "{xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}".
+type DeviceInterfaceClass string
+
+// Commonly used device interface classes.
+const (
+
+	// DeviceInterfaceUSB matches USB devices.
+	DeviceInterfaceUSB DeviceInterfaceClass = "{A5DCBF10-6530-11D2-901F-00C04FB951ED}"
+
+	// DeviceInterfaceHID matches human interface devices (such as keyboards,
+	// mice, and many hardware dongles).
+	DeviceInterfaceHID DeviceInterfaceClass = "{4D1E55B2-F16F-11CF-88CB-001111000030}"
+
+	// DeviceInterfaceVolume matches storage volumes.
+	DeviceInterfaceVolume DeviceInterfaceClass = "{53F5630D-B6BF-11D0-94F2-00A0C91EFB8B}"
+)
+
+// DeviceEvent describes a device that was added or removed.
+type DeviceEvent struct {
+
+	// Arrival is true if the device was added and false if it was removed.
+	Arrival bool
+
+	// Class is the interface class of the device. It is empty for volume
+	// events, which are delivered without calling WatchDeviceInterface.
+	Class DeviceInterfaceClass
+
+	// Path is the device interface path (for interface events).
+	Path string
+
+	// Drives contains the affected drive letters, such as "E:" (for volume
+	// events).
+	Drives []string
+}
+
+// AudioRole is the role for which an audio device is the default.
+type AudioRole int
+
+const (
+	// AudioRoleConsole is used for system sounds and games.
+	AudioRoleConsole AudioRole = 0
+
+	// AudioRoleMultimedia is used for music and video.
+	AudioRoleMultimedia AudioRole = 1
+
+	// AudioRoleCommunications is used for voice calls.
+	AudioRoleCommunications AudioRole = 2
+)
+
+// AudioDeviceEvent describes a change to the default audio device.
+type AudioDeviceEvent struct {
+
+	// Capture is true for recording devices (such as microphones) and false
+	// for playback devices.
+	Capture bool
+
+	// Role is the role for which the device became the default.
+	Role AudioRole
+
+	// DeviceID identifies the new default device; it is empty if there is
+	// no longer a device for the role.
+	DeviceID string
+}
+
+// MessageBoxStyle determines the buttons and icon of a message box. Combine
+// one of the button styles with one of the icon styles.
+type MessageBoxStyle uint32
+
+const (
+	MessageBoxOK          MessageBoxStyle = 0x0
+	MessageBoxOKCancel    MessageBoxStyle = 0x1
+	MessageBoxYesNo       MessageBoxStyle = 0x4
+	MessageBoxYesNoCancel MessageBoxStyle = 0x3
+
+	MessageBoxIconInfo     MessageBoxStyle = 0x40
+	MessageBoxIconWarning  MessageBoxStyle = 0x30
+	MessageBoxIconError    MessageBoxStyle = 0x10
+	MessageBoxIconQuestion MessageBoxStyle = 0x20
+)
+
+// MessageBoxResult indicates which button closed a message box.
+type MessageBoxResult int
+
+const (
+	MessageBoxResultOK     MessageBoxResult = 1
+	MessageBoxResultCancel MessageBoxResult = 2
+	MessageBoxResultYes    MessageBoxResult = 6
+	MessageBoxResultNo     MessageBoxResult = 7
+)
+
+// AppInfo describes the application in the About dialog. Only Name is
+// required.
+type AppInfo struct {
+	Name        string
+	Version     string
+	Description string
+	Copyright   string
+	URL         string
+}
+
+// FileFilter restricts the files shown in a file dialog.
+type FileFilter struct {
+
+	// Name is displayed in the dialog, such as "Text files".
+	Name string
+
+	// Pattern is a semicolon-separated list of patterns, such as
+	// "*.txt;*.md".
+	Pattern string
+}
+
+// FileDialogOptions configures a file or folder dialog. All fields are
+// optional.
+type FileDialogOptions struct {
+
+	// Title replaces the default title of the dialog.
+	Title string
+
+	// Folder is the folder displayed when the dialog opens.
+	Folder string
+
+	// FileName is the initial file name (SaveFileAs only).
+	FileName string
+
+	// Filters restricts the files that are displayed. The first filter is
+	// selected initially.
+	Filters []FileFilter
+
+	// DefaultExtension is appended to file names entered without one
+	// (SaveFileAs only), such as "txt".
+	DefaultExtension string
+
+	// Multiple allows more than one file to be selected (PickFile only).
+	Multiple bool
+}
+
+// FlyoutOptions configures a flyout created with NewFlyout.
+type FlyoutOptions struct {
+
+	// Width and Height specify the size of the flyout in pixels.
+	Width  int
+	Height int
+
+	// Draw renders the contents of the flyout. It is invoked on the UI thread
+	// with the device context (HDC) and the bounds of the client area; API
+	// functions called from Draw run immediately rather than being queued.
+	Draw func(hdc uintptr, bounds image.Rectangle)
+
+	// ShowOnClick causes the flyout to be shown (or hidden, if it is already
+	// visible) when the icon is clicked.
+	ShowOnClick bool
+
+	// OnDismiss is invoked when the flyout is hidden because it lost focus.
+	OnDismiss func()
+}
+
+// MessageFilter is invoked with each message received by the hidden window.
+// If it returns true for handled, the message is not processed further and
+// result is returned from the window procedure.
+type MessageFilter func(hwnd, msg, wparam, lparam uintptr) (handled bool, result uintptr)
+
+// PowerSetting identifies a power setting by its GUID, in the form
+// "{xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}".
+type PowerSetting string
+
+// Commonly used power settings. For each of these, the data passed to the
+// function registered with OnPowerSettingChange is a little-endian DWORD.
+const (
+
+	// PowerSettingBatteryPercentage reports the remaining battery capacity
+	// as a percentage.
+	PowerSettingBatteryPercentage PowerSetting = "{A7AD8041-B45A-4CAE-87A3-EECBB468A9E1}"
+
+	// PowerSettingPowerSource reports the power source: 0 for AC, 1 for
+	// battery, and 2 for short-term sources such as a UPS.
+	PowerSettingPowerSource PowerSetting = "{5D3E9A59-E9D5-4B00-A6BD-FF34FF516548}"
+
+	// PowerSettingLidSwitch reports the state of the lid: 0 when closed and 1
+	// when open.
+	PowerSettingLidSwitch PowerSetting = "{BA3E0F4D-B817-4094-A2D1-D56379E6A0F3}"
+
+	// PowerSettingDisplayState reports the state of the display: 0 when off,
+	// 1 when on, and 2 when dimmed.
+	PowerSettingDisplayState PowerSetting = "{6FE69556-704A-47A0-8F24-C28D936FDA47}"
+)
+
+// ProgressState describes the appearance of the progress indicator on a
+// taskbar button.
+type ProgressState int
+
+const (
+	// ProgressNone hides the progress indicator.
+	ProgressNone ProgressState = 0
+
+	// ProgressIndeterminate displays a pulsing indicator.
+	ProgressIndeterminate ProgressState = 1
+
+	// ProgressNormal displays a green indicator.
+	ProgressNormal ProgressState = 2
+
+	// ProgressError displays a red indicator.
+	ProgressError ProgressState = 4
+
+	// ProgressPaused displays a yellow indicator.
+	ProgressPaused ProgressState = 8
+)
+
+// FocusAssist describes which notifications are allowed through by Focus
+// Assist.
+type FocusAssist int
+
+const (
+	// FocusAssistOff allows all notifications.
+	FocusAssistOff FocusAssist = 0
+
+	// FocusAssistPriorityOnly only allows notifications from the apps on the
+	// user's priority list.
+	FocusAssistPriorityOnly FocusAssist = 1
+
+	// FocusAssistAlarmsOnly hides all notifications except alarms.
+	FocusAssistAlarmsOnly FocusAssist = 2
+)
+
+// ConnKind describes the kind of network connection used to reach the
+// Internet.
+type ConnKind int
+
+const (
+	// ConnNone indicates that there is no connection.
+	ConnNone ConnKind = iota
+
+	// ConnEthernet is a wired connection.
+	ConnEthernet
+
+	// ConnWiFi is a wireless connection.
+	ConnWiFi
+
+	// ConnCellular is a mobile broadband connection.
+	ConnCellular
+
+	// ConnOther is any other kind of connection, such as a VPN.
+	ConnOther
+)
+
+// ThemeInfo describes the current theme.
+type ThemeInfo struct {
+
+	// AppsDark is true when applications should use a dark theme.
+	AppsDark bool
+
+	// SystemDark is true when the taskbar and other shell surfaces use a dark
+	// theme; tray icons should generally follow this setting.
+	SystemDark bool
+
+	// Accent is the accent color chosen by the user. It is fully transparent
+	// if no accent color is available.
+	Accent color.RGBA
+
+	// HighContrast is true when a high contrast theme is active, in which case
+	// the colors of the theme should be used instead of those above.
+	HighContrast bool
+}
+
+// MenuItemInfo describes an item in the context menu, as returned by
+// MenuItems.
+type MenuItemInfo struct {
+
+	// ID identifies the item; it is zero for separators and submenus.
+	ID uint32
+
+	// Label is the text of the item, including any "&" prefixes.
+	Label string
+
+	// Separator is true if the item is a separator.
+	Separator bool
+
+	// Enabled is false if the item cannot be selected (such as the status
+	// line added by SetStatus).
+	Enabled bool
+
+	// Checked is true if the item has a check mark.
+	Checked bool
+
+	// Position is the zero-based index of the item within its menu.
+	Position int
+
+	// Depth is zero for items in the context menu itself and increases by
+	// one for each level of submenu.
+	Depth int
+}
+
+// ItemStyle changes the appearance of the label of a menu item. The zero
+// value draws the item like any other.
+type ItemStyle struct {
+
+	// Bold draws the label in bold.
+	Bold bool
+
+	// Color is the color of the label; if nil, the usual color is used.
+	Color color.Color
+}
+
+// NOTIFYICONDATA holds the fields of the Win32 structure of the same name
+// that can be changed with ModifyRaw. Handles are passed as uintptr values.
+// The fields are only applied if they are selected by UFlags (or, for
+// DwState, by DwStateMask); see the Shell_NotifyIcon documentation.
+type NOTIFYICONDATA struct {
+	UFlags            uint32
+	UCallbackMessage  uint32
+	HIcon             uintptr
+	SzTip             [128]uint16
+	DwState           uint32
+	DwStateMask       uint32
+	SzInfo            [256]uint16
+	UTimeoutOrVersion uint32
+	SzInfoTitle       [64]uint16
+	DwInfoFlags       uint32
+	HBalloonIcon      uintptr
+}
+
+// SessionChange is a change to the session that the application is running
+// in.
+type SessionChange int
+
+const (
+
+	// SessionConnected indicates that the user connected to the session,
+	// either at the console or over Remote Desktop.
+	SessionConnected SessionChange = iota
+
+	// SessionDisconnected indicates that the user disconnected from the
+	// session, which keeps running in the background.
+	SessionDisconnected
+
+	// SessionLocked indicates that the session was locked.
+	SessionLocked
+
+	// SessionUnlocked indicates that the session was unlocked.
+	SessionUnlocked
+)
@@ -0,0 +1,437 @@
+//go:build !windows
+
+package wintray
+
+import (
+	"image"
+	"time"
+)
+
+// pPlatform holds the members of WinTray that are specific to Windows.
+type pPlatform struct{}
+
+// unsupportedBackend is used by New on platforms other than Windows. The icon
+// is created and runs normally, but every operation fails with
+// ErrUnsupported.
+type unsupportedBackend struct {
+	w         *WinTray
+	wakeChan  chan struct{}
+	closeChan chan struct{}
+}
+
+func newPlatformBackend(w *WinTray) trayBackend {
+	return &unsupportedBackend{
+		w:         w,
+		wakeChan:  make(chan struct{}, 1),
+		closeChan: make(chan struct{}),
+	}
+}
+
+func (b *unsupportedBackend) create() error {
+	return nil
+}
+
+func (b *unsupportedBackend) loop() {
+	for {
+		select {
+		case <-b.wakeChan:
+			b.w.processMessages()
+		case <-b.closeChan:
+			return
+		}
+	}
+}
+
+func (b *unsupportedBackend) wake() {
+	select {
+	case b.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+func (b *unsupportedBackend) onUIThread() bool {
+	return false
+}
+
+func (b *unsupportedBackend) requestClose() {
+	close(b.closeChan)
+}
+
+func (b *unsupportedBackend) setIcon([]byte) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) setTip(string) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) addMenuItem(uint32, string) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) addMenuSeparator() error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) setMenuHeader(string) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) setMenuItemText(uint32, string) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) checkMenuItem(uint32, bool) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) removeMenuItem(uint32) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) listMenuItems() []MenuItemInfo {
+	return nil
+}
+
+func (b *unsupportedBackend) showNotification(*pDataShowNotification) error {
+	return ErrUnsupported
+}
+
+func (b *unsupportedBackend) findMenuItem(string) (uint32, bool) {
+	return 0, false
+}
+
+func (b *unsupportedBackend) simulate(int, uint32) error {
+	return ErrUnsupported
+}
+
+// The remainder of this file contains stubs for the functions that are only
+// implemented on Windows. Functions that register callbacks without
+// returning an error do nothing.
+
+func SetAppID(id string) error {
+	return ErrUnsupported
+}
+
+func EnableAutostart(appName string, args ...string) error {
+	return ErrUnsupported
+}
+
+func EnableAutostartElevated(appName string, args ...string) error {
+	return ErrUnsupported
+}
+
+func DisableAutostart(appName string) error {
+	return ErrUnsupported
+}
+
+func IsAutostartEnabled(appName string) (bool, error) {
+	return false, ErrUnsupported
+}
+
+func IsElevated() bool {
+	return false
+}
+
+func RelaunchElevated(args ...string) error {
+	return ErrUnsupported
+}
+
+func EnsureSingleInstance(id string) error {
+	return ErrUnsupported
+}
+
+func RegisterURLScheme(scheme, description string, args ...string) error {
+	return ErrUnsupported
+}
+
+func UnregisterURLScheme(scheme string) error {
+	return ErrUnsupported
+}
+
+func RegisterFileType(ext, progID, description string, args ...string) error {
+	return ErrUnsupported
+}
+
+func UnregisterFileType(ext, progID string) error {
+	return ErrUnsupported
+}
+
+func OpenURL(url string) error {
+	return ErrUnsupported
+}
+
+func OpenFolder(path string) error {
+	return ErrUnsupported
+}
+
+func RevealInExplorer(path string) error {
+	return ErrUnsupported
+}
+
+func CurrentTheme() (ThemeInfo, error) {
+	return ThemeInfo{}, ErrUnsupported
+}
+
+func (w *WinTray) EnsureStartMenuShortcut(name, appID string) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) ReadClipboardText() (string, error) {
+	return "", ErrUnsupported
+}
+
+func (w *WinTray) WriteClipboardText(text string) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) OnClipboardChange(fn func()) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) OnDeviceChange(fn func(e *DeviceEvent)) {}
+
+func (w *WinTray) WatchDeviceInterface(class DeviceInterfaceClass) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) ShowMessageBox(title, text string, style MessageBoxStyle) (MessageBoxResult, error) {
+	return 0, ErrUnsupported
+}
+
+func (w *WinTray) ShowAbout(info AppInfo) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) OnDisplayChange(fn func(width, height int)) {}
+
+func (w *WinTray) OnDPIChange(fn func(dpi int)) {}
+
+func (w *WinTray) AddShieldMenuItem(text string, fn func()) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) PickFile(opts *FileDialogOptions) ([]string, bool, error) {
+	return nil, false, ErrUnsupported
+}
+
+func (w *WinTray) PickFolder(opts *FileDialogOptions) (string, bool, error) {
+	return "", false, ErrUnsupported
+}
+
+func (w *WinTray) SaveFileAs(opts *FileDialogOptions) (string, bool, error) {
+	return "", false, ErrUnsupported
+}
+
+func (w *WinTray) OnActivate(fn func(args []string)) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) AnchorPoint() image.Point {
+	return image.Point{}
+}
+
+func (w *WinTray) OnSuspend(fn func()) {}
+
+func (w *WinTray) OnResume(fn func()) {}
+
+func (w *WinTray) OnPowerSettingChange(setting PowerSetting, fn func(data []byte)) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) SetRichTip(text string) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) PromptText(title, label, defaultValue string) (string, bool, error) {
+	return "", false, ErrUnsupported
+}
+
+func (w *WinTray) IconRect() (image.Rectangle, error) {
+	return image.Rectangle{}, ErrUnsupported
+}
+
+func (w *WinTray) IsVisibleInTray() (bool, error) {
+	return false, ErrUnsupported
+}
+
+func (w *WinTray) RequestPromotion() error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) PromptPinIcon() error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) AddMenuColumnBreak(divider bool) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) SetMenuItemStyle(id uint32, style ItemStyle) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) ShowMenu() error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) ShowMenuAt(x, y int) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) OnThemeChange(fn func(ThemeInfo)) {}
+
+func (w *WinTray) OnHighContrastChange(fn func(enabled bool)) {}
+
+func (w *WinTray) SetAccessibleName(name string) error {
+	return ErrUnsupported
+}
+
+func FocusAssistState() (FocusAssist, error) {
+	return FocusAssistOff, ErrUnsupported
+}
+
+func (w *WinTray) OnFocusAssistChange(fn func(state FocusAssist)) {}
+
+func (w *WinTray) OnTimeChange(fn func()) {}
+
+func (w *WinTray) OnDefaultAudioDeviceChange(fn func(e *AudioDeviceEvent)) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) OnMicInUse(fn func(inUse bool)) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) OnCameraInUse(fn func(inUse bool)) error {
+	return ErrUnsupported
+}
+
+func IsOnline() (bool, error) {
+	return false, ErrUnsupported
+}
+
+func (w *WinTray) OnConnectivityChange(fn func(online bool, kind ConnKind)) error {
+	return ErrUnsupported
+}
+
+func LastInputTime() (time.Time, error) {
+	return time.Time{}, ErrUnsupported
+}
+
+func (w *WinTray) OnIdle(threshold time.Duration, fn func(idle bool)) error {
+	return ErrUnsupported
+}
+
+// Flyout is a small window displayed next to the icon.
+type Flyout struct{}
+
+func (w *WinTray) NewFlyout(opts *FlyoutOptions) (*Flyout, error) {
+	return nil, ErrUnsupported
+}
+
+func (f *Flyout) Show() error {
+	return ErrUnsupported
+}
+
+func (f *Flyout) Hide() error {
+	return ErrUnsupported
+}
+
+func (f *Flyout) Invalidate() error {
+	return ErrUnsupported
+}
+
+func (f *Flyout) SetSize(width, height int) error {
+	return ErrUnsupported
+}
+
+func (f *Flyout) Destroy() error {
+	return ErrUnsupported
+}
+
+// TaskbarProgress displays progress on the taskbar button of a window.
+type TaskbarProgress struct{}
+
+func (w *WinTray) NewTaskbarProgress(hwnd uintptr) (*TaskbarProgress, error) {
+	return nil, ErrUnsupported
+}
+
+func (p *TaskbarProgress) SetState(state ProgressState) error {
+	return ErrUnsupported
+}
+
+func (p *TaskbarProgress) SetValue(completed, total uint64) error {
+	return ErrUnsupported
+}
+
+func (p *TaskbarProgress) Close() error {
+	return ErrUnsupported
+}
+
+// WebPopup is a flyout that hosts a web page.
+type WebPopup struct {
+	*Flyout
+}
+
+func (w *WinTray) ShowWebPopup(url string, width, height int) (*WebPopup, error) {
+	return nil, ErrUnsupported
+}
+
+func (p *WebPopup) OnMessage(fn func(msg string)) error {
+	return ErrUnsupported
+}
+
+func (p *WebPopup) PostMessage(msg string) error {
+	return ErrUnsupported
+}
+
+func (p *WebPopup) Navigate(url string) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) HWND() uintptr {
+	return 0
+}
+
+func (w *WinTray) AddMessageFilter(fn MessageFilter) {}
+
+func (w *WinTray) OnCopyData(fn func(id uint32, data []byte)) {}
+
+func SendCopyDataTo(windowTitleOrClass string, id uint32, data []byte) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) OnFilesDropped(fn func(paths []string)) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) ModifyRaw(fn func(nid *NOTIFYICONDATA)) error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) Verify() error {
+	return ErrUnsupported
+}
+
+func (w *WinTray) Repair() error {
+	return ErrUnsupported
+}
+
+func IsRemoteSession() bool {
+	return false
+}
+
+func (w *WinTray) OnSessionChange(fn func(change SessionChange, remote bool)) {}
+
+func CleanupGhostIcons() error {
+	return ErrUnsupported
+}
+
+func BringWindowToFront(hwnd uintptr) error {
+	return ErrUnsupported
+}
+
+func ToggleWindow(hwnd uintptr) (bool, error) {
+	return false, ErrUnsupported
+}
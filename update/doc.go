@@ -0,0 +1,40 @@
+// Package update keeps a tray application up to date by periodically checking
+// a manifest published alongside each release.
+//
+// The manifest is a JSON document describing the latest version:
+//
+//	{
+//		"version": "1.2.0",
+//		"url": "https://example.com/MyApp-1.2.0.exe",
+//		"sha256": "<hex-encoded SHA-256 hash of the file>",
+//		"signature": "<base64-encoded Ed25519 signature of the version and hash>",
+//		"notes": "Fixes a crash when the network is unavailable."
+//	}
+//
+// The signature covers the version as well as the hash, and is verified
+// before the version is compared, so an old release cannot be offered under a
+// newer version number. When a newer version is found, a notification is
+// shown; clicking it downloads the new executable, checks it against the
+// signed hash, replaces the running executable, and starts the new version. The executable must therefore be located somewhere the user can
+// write to, such as under %LOCALAPPDATA%.
+//
+// Releases are signed with Sign, using the private key corresponding to the
+// public key passed to EnableUpdates, for example:
+//
+//	r := update.Sign(privateKey, "1.2.0", executable)
+//	r.URL = "https://example.com/MyApp-1.2.0.exe"
+//	b, _ := json.MarshalIndent(r, "", "\t")
+//
+// In the application:
+//
+//	w := wintray.New()
+//	u := update.EnableUpdates(w, "https://example.com/MyApp.json", version, publicKey)
+//	w.AddMenuItem("Check for updates", func() {
+//		u.CheckNow()
+//	})
+//	<-w.Done()
+//
+// Once the new version has been started, the icon is closed so that the
+// application can exit. Applications that call wintray.EnsureSingleInstance
+// should exit promptly, since the new version starts before the old one exits.
+package update
@@ -0,0 +1,368 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nathan-osman/go-wintray"
+)
+
+const (
+	// DefaultInterval is how often the manifest is checked unless
+	// WithInterval is used.
+	DefaultInterval = 24 * time.Hour
+
+	// The first check is delayed so that it does not slow down startup
+	initialDelay = time.Minute
+
+	// Downloads larger than this are rejected
+	maxDownloadSize = 512 << 20
+
+	// The running executable is moved aside with this suffix while it is
+	// replaced, and removed the next time the application starts
+	oldSuffix = ".old"
+	newSuffix = ".new"
+
+	// Prefixed to the signed message so that signatures made for other
+	// purposes with the same key are never accepted
+	signaturePrefix = "go-wintray update\n"
+)
+
+var (
+	// ErrInvalidManifest indicates that the manifest could not be parsed or
+	// is missing a required field.
+	ErrInvalidManifest = errors.New("update: invalid manifest")
+
+	// ErrInvalidSignature indicates that a release was not signed with the
+	// private key corresponding to the public key passed to EnableUpdates,
+	// or that the downloaded executable does not match the signed hash.
+	ErrInvalidSignature = errors.New("update: invalid signature")
+
+	// ErrInstalling indicates that an update is already being installed.
+	ErrInstalling = errors.New("update: already installing")
+)
+
+// Release describes a version of the application, as read from the manifest.
+type Release struct {
+
+	// Version is the version number, such as "1.2.0".
+	Version string `json:"version"`
+
+	// URL is the location of the executable.
+	URL string `json:"url"`
+
+	// SHA256 is the hex-encoded SHA-256 hash of the executable.
+	SHA256 string `json:"sha256"`
+
+	// Signature is the base64-encoded Ed25519 signature of the version and
+	// hash; see Sign.
+	Signature string `json:"signature"`
+
+	// Notes optionally describes the changes in the release.
+	Notes string `json:"notes,omitempty"`
+}
+
+// signedMessage returns the message that is signed for a release. The version
+// is signed along with the hash so that an old release cannot be offered
+// under a newer version number.
+func signedMessage(version, hash string) []byte {
+	return []byte(signaturePrefix + version + "\n" + hash)
+}
+
+// Sign returns a release for the executable exe with the specified version,
+// its hash, and its signature made with privateKey. The URL and notes are
+// left for the caller to fill in before publishing the manifest.
+func Sign(privateKey ed25519.PrivateKey, version string, exe []byte) *Release {
+	sum := sha256.Sum256(exe)
+	hash := hex.EncodeToString(sum[:])
+	sig := ed25519.Sign(privateKey, signedMessage(version, hash))
+	return &Release{
+		Version:   version,
+		SHA256:    hash,
+		Signature: base64.StdEncoding.EncodeToString(sig),
+	}
+}
+
+// verify checks the signature of the release.
+func (u *Updater) verify(r *Release) error {
+	sig, err := base64.StdEncoding.DecodeString(r.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	if len(u.publicKey) != ed25519.PublicKeySize ||
+		!ed25519.Verify(u.publicKey, signedMessage(r.Version, r.SHA256), sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Option configures an Updater.
+type Option func(*Updater)
+
+// WithInterval sets how often the manifest is checked.
+func WithInterval(d time.Duration) Option {
+	return func(u *Updater) {
+		u.interval = d
+	}
+}
+
+// WithClient sets the HTTP client used to download the manifest and
+// executable.
+func WithClient(c *http.Client) Option {
+	return func(u *Updater) {
+		u.client = c
+	}
+}
+
+// WithErrorHandler registers a function to be invoked when a periodic check
+// or an installation started from a notification fails. By default, these
+// errors are ignored.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(u *Updater) {
+		u.onError = fn
+	}
+}
+
+// Updater periodically checks for and installs new versions of the
+// application.
+type Updater struct {
+	w              *wintray.WinTray
+	manifestURL    string
+	currentVersion string
+	publicKey      ed25519.PublicKey
+	interval       time.Duration
+	client         *http.Client
+	onError        func(err error)
+	stopChan       chan struct{}
+	stopOnce       sync.Once
+
+	mutex      sync.Mutex
+	announced  string
+	installing bool
+}
+
+// EnableUpdates begins checking the manifest at manifestURL for versions newer
+// than currentVersion, showing a notification on w when one is found. Checks
+// continue until Stop is called or the icon is closed.
+func EnableUpdates(w *wintray.WinTray, manifestURL, currentVersion string, publicKey ed25519.PublicKey, opts ...Option) *Updater {
+	u := &Updater{
+		w:              w,
+		manifestURL:    manifestURL,
+		currentVersion: currentVersion,
+		publicKey:      publicKey,
+		interval:       DefaultInterval,
+		client:         http.DefaultClient,
+		stopChan:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	if exe, err := executable(); err == nil {
+		os.Remove(exe + oldSuffix)
+	}
+	go u.run()
+	return u
+}
+
+// Stop ends the periodic checks.
+func (u *Updater) Stop() {
+	u.stopOnce.Do(func() {
+		close(u.stopChan)
+	})
+}
+
+func (u *Updater) run() {
+	t := time.NewTimer(initialDelay)
+	defer t.Stop()
+	for {
+		select {
+		case <-u.w.Done():
+			return
+		case <-u.stopChan:
+			return
+		case <-t.C:
+		}
+		r, err := u.Check()
+		if err == nil && r != nil {
+			err = u.announce(r, false)
+		}
+		if err != nil {
+			u.reportError(err)
+		}
+		t.Reset(u.interval)
+	}
+}
+
+func (u *Updater) reportError(err error) {
+	if u.onError != nil {
+		u.onError(err)
+	}
+}
+
+// get downloads the resource at url, up to a maximum of limit bytes.
+func (u *Updater) get(url string, limit int64) ([]byte, error) {
+	resp, err := u.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("update: unable to download %s: %s", url, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, fmt.Errorf("update: %s is too large", url)
+	}
+	return b, nil
+}
+
+// Check downloads the manifest and returns the release it describes if it is
+// newer than the current version, or nil if it is not. The signature is
+// verified before the version is compared.
+func (u *Updater) Check() (*Release, error) {
+	b, err := u.get(u.manifestURL, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	r := &Release{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	if r.Version == "" || r.URL == "" || r.SHA256 == "" || r.Signature == "" {
+		return nil, fmt.Errorf("%w: version, url, sha256, and signature are required", ErrInvalidManifest)
+	}
+	if err := u.verify(r); err != nil {
+		return nil, err
+	}
+	if compareVersions(r.Version, u.currentVersion) <= 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// CheckNow checks for a new version immediately, such as when the user
+// selects a "Check for updates" menu item. Unlike the periodic checks, a
+// notification is shown even if the application is up to date or the new
+// version has already been announced.
+func (u *Updater) CheckNow() error {
+	r, err := u.Check()
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return u.w.ShowNotification("You are running the latest version.", "No updates available")
+	}
+	return u.announce(r, true)
+}
+
+// announce shows a notification for the release, which installs it when
+// clicked. Each release is only announced once unless force is true.
+func (u *Updater) announce(r *Release, force bool) error {
+	u.mutex.Lock()
+	if !force && u.announced == r.Version {
+		u.mutex.Unlock()
+		return nil
+	}
+	u.announced = r.Version
+	u.mutex.Unlock()
+	return u.w.ShowNotification(
+		fmt.Sprintf("Version %s is available. Click to install.", r.Version),
+		"Update available",
+		wintray.WithClickHandler(func() {
+			if err := u.Install(r); err != nil {
+				u.reportError(err)
+			}
+		}),
+	)
+}
+
+// executable returns the path of the running executable with symbolic links
+// resolved.
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// Install downloads the release, verifies its signature, and replaces the
+// running executable with it. The new version is then started with the same
+// arguments and the icon is closed. If anything fails, the running executable
+// is left in place.
+func (u *Updater) Install(r *Release) error {
+	u.mutex.Lock()
+	if u.installing {
+		u.mutex.Unlock()
+		return ErrInstalling
+	}
+	u.installing = true
+	u.mutex.Unlock()
+	defer func() {
+		u.mutex.Lock()
+		u.installing = false
+		u.mutex.Unlock()
+	}()
+
+	if err := u.verify(r); err != nil {
+		return err
+	}
+	want, err := hex.DecodeString(r.SHA256)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	b, err := u.get(r.URL, maxDownloadSize)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b)
+	if subtle.ConstantTimeCompare(sum[:], want) != 1 {
+		return ErrInvalidSignature
+	}
+	exe, err := executable()
+	if err != nil {
+		return err
+	}
+
+	// Windows does not allow a running executable to be overwritten, but it
+	// can be renamed, which frees its name for the new version
+	newExe, oldExe := exe+newSuffix, exe+oldSuffix
+	if err := os.WriteFile(newExe, b, 0755); err != nil {
+		return err
+	}
+	defer os.Remove(newExe)
+	os.Remove(oldExe)
+	if err := os.Rename(exe, oldExe); err != nil {
+		return err
+	}
+	if err := os.Rename(newExe, exe); err != nil {
+		os.Rename(oldExe, exe)
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		os.Remove(exe)
+		os.Rename(oldExe, exe)
+		return err
+	}
+	cmd.Process.Release()
+	u.w.Close()
+	return nil
+}
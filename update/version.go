@@ -0,0 +1,60 @@
+package update
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two dotted version numbers, such as "1.2.0" and
+// "v1.10", returning -1, 0, or 1. Missing components are treated as zero and
+// anything following a "-" or "+" (such as "-beta") is ignored. Components
+// that are not numbers are compared as strings.
+func compareVersions(a, b string) int {
+	var (
+		as = versionComponents(a)
+		bs = versionComponents(b)
+	)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := compareComponents(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func versionComponents(v string) []string {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.Split(v, ".")
+}
+
+func compareComponents(x, y string) int {
+	if x == "" {
+		x = "0"
+	}
+	if y == "" {
+		y = "0"
+	}
+	xn, xerr := strconv.ParseUint(x, 10, 64)
+	yn, yerr := strconv.ParseUint(y, 10, 64)
+	switch {
+	case xerr == nil && yerr == nil:
+		if xn < yn {
+			return -1
+		} else if xn > yn {
+			return 1
+		}
+		return 0
+	default:
+		return strings.Compare(x, y)
+	}
+}
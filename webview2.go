@@ -0,0 +1,297 @@
+//go:build windows
+
+package wintray
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+// The WebView2 loader is not part of Windows and must be shipped with the
+// application (alongside the executable) for web popups to work
+var (
+	webView2Loader                            = windows.NewLazyDLL("WebView2Loader.dll")
+	pCreateCoreWebView2EnvironmentWithOptions = webView2Loader.NewProc("CreateCoreWebView2EnvironmentWithOptions")
+)
+
+// Vtable indices for the WebView2 interfaces
+const (
+	pICoreWebView2Environment_CreateCoreWebView2Controller = 3
+
+	pICoreWebView2Controller_put_Bounds       = 6
+	pICoreWebView2Controller_Close            = 24
+	pICoreWebView2Controller_get_CoreWebView2 = 25
+
+	pICoreWebView2_Navigate                  = 5
+	pICoreWebView2_PostWebMessageAsString    = 33
+	pICoreWebView2_add_WebMessageReceived    = 34
+	pICoreWebView2_remove_WebMessageReceived = 35
+
+	pICoreWebView2WebMessageReceivedEventArgs_TryGetWebMessageAsString = 5
+)
+
+// pComHandler implements the WebView2 completion and event handler
+// interfaces, all of which consist of a single Invoke method following those
+// of IUnknown. The object is owned by Go, so reference counting is a no-op;
+// it must be kept alive for as long as WebView2 may invoke it.
+type pComHandler struct {
+	vtbl   *[4]uintptr
+	invoke func(arg uintptr, obj *pComObject) uintptr
+}
+
+var pComHandlerVtbl = [4]uintptr{
+	syscall.NewCallback(func(this *pComHandler, riid uintptr, ppv *uintptr) uintptr {
+		*ppv = uintptr(unsafe.Pointer(this))
+		return 0
+	}),
+	syscall.NewCallback(func(this *pComHandler) uintptr {
+		return 1
+	}),
+	syscall.NewCallback(func(this *pComHandler) uintptr {
+		return 1
+	}),
+	syscall.NewCallback(func(this *pComHandler, arg uintptr, obj *pComObject) uintptr {
+		return this.invoke(arg, obj)
+	}),
+}
+
+func newComHandler(fn func(arg uintptr, obj *pComObject) uintptr) *pComHandler {
+	return &pComHandler{
+		vtbl:   &pComHandlerVtbl,
+		invoke: fn,
+	}
+}
+
+// WebPopup is a flyout that hosts a WebView2 control. Pages can send messages
+// to the application with window.chrome.webview.postMessage() and receive
+// messages with window.chrome.webview.addEventListener("message", ...).
+type WebPopup struct {
+	*Flyout
+
+	// These are only accessed from the UI thread
+	controller *pComObject
+	webview    *pComObject
+	token      int64
+	handlers   []*pComHandler
+	onMessage  func(msg string)
+}
+
+// userDataFolder returns the directory used by WebView2 for storing browser
+// data. The default (next to the executable) is often not writable.
+func userDataFolder() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	exe, _ := os.Executable()
+	name := strings.TrimSuffix(filepath.Base(exe), filepath.Ext(exe))
+	return filepath.Join(dir, name, "WebView2")
+}
+
+// resize makes the control fill the client area of the popup.
+func (p *WebPopup) resize() {
+	if p.controller == nil {
+		return
+	}
+	rc := win.RECT{}
+	win.GetClientRect(p.hwnd, &rc)
+	p.controller.call(pICoreWebView2Controller_put_Bounds, rectArgs(&rc)...)
+	runtime.KeepAlive(&rc)
+}
+
+// create begins the asynchronous creation of the WebView2 control; done is
+// invoked on the UI thread once it is ready to navigate or has failed.
+func (p *WebPopup) create(done func(error)) error {
+	if err := pCreateCoreWebView2EnvironmentWithOptions.Find(); err != nil {
+		return ErrWebView2Unavailable
+	}
+	if err := p.w.initCOM(); err != nil {
+		return err
+	}
+
+	// Invoked when the WebView2 control has been created
+	controllerCompleted := newComHandler(func(hr uintptr, controller *pComObject) uintptr {
+		if hr != 0 {
+			done(newHRESULTError("ShowWebPopup", "unable to create WebView2 control", nil, hr))
+			return 0
+		}
+		controller.call(1)
+		p.controller = controller
+		controller.call(
+			pICoreWebView2Controller_get_CoreWebView2,
+			uintptr(unsafe.Pointer(&p.webview)),
+		)
+		p.resize()
+
+		// Deliver messages from the page to the application
+		messageReceived := newComHandler(func(_ uintptr, args *pComObject) uintptr {
+			var s *uint16
+			if args.call(
+				pICoreWebView2WebMessageReceivedEventArgs_TryGetWebMessageAsString,
+				uintptr(unsafe.Pointer(&s)),
+			) != 0 {
+				return 0
+			}
+			msg := windows.UTF16PtrToString(s)
+			windows.CoTaskMemFree(unsafe.Pointer(s))
+			if p.onMessage != nil {
+				fn := p.onMessage
+				go p.w.invokeHandler(func() { fn(msg) })
+			}
+			return 0
+		})
+		p.handlers = append(p.handlers, messageReceived)
+		p.webview.call(
+			pICoreWebView2_add_WebMessageReceived,
+			uintptr(unsafe.Pointer(messageReceived)),
+			uintptr(unsafe.Pointer(&p.token)),
+		)
+
+		done(nil)
+		return 0
+	})
+
+	// Invoked when the WebView2 environment has been created
+	environmentCompleted := newComHandler(func(hr uintptr, env *pComObject) uintptr {
+		if hr != 0 {
+			done(newHRESULTError("ShowWebPopup", "unable to create WebView2 environment", ErrWebView2Unavailable, hr))
+			return 0
+		}
+		if hr := env.call(
+			pICoreWebView2Environment_CreateCoreWebView2Controller,
+			uintptr(p.hwnd),
+			uintptr(unsafe.Pointer(controllerCompleted)),
+		); hr != 0 {
+			done(newHRESULTError("ShowWebPopup", "unable to create WebView2 control", nil, hr))
+		}
+		return 0
+	})
+
+	p.handlers = append(p.handlers, environmentCompleted, controllerCompleted)
+
+	var dataDir *uint16
+	if d := userDataFolder(); d != "" {
+		dataDir = utf16PtrFromString(d)
+	}
+	if hr, _, _ := pCreateCoreWebView2EnvironmentWithOptions.Call(
+		0,
+		uintptr(unsafe.Pointer(dataDir)),
+		0,
+		uintptr(unsafe.Pointer(environmentCompleted)),
+	); hr != 0 {
+		return newHRESULTError("ShowWebPopup", "unable to create WebView2 environment", ErrWebView2Unavailable, hr)
+	}
+	return nil
+}
+
+// destroy releases the WebView2 control.
+func (p *WebPopup) destroy() {
+	if p.webview != nil {
+		p.webview.call(pICoreWebView2_remove_WebMessageReceived, uintptr(p.token))
+		p.webview.release()
+		p.webview = nil
+	}
+	if p.controller != nil {
+		p.controller.call(pICoreWebView2Controller_Close)
+		p.controller.release()
+		p.controller = nil
+	}
+}
+
+func (p *WebPopup) navigate(url string) error {
+	if hr := p.webview.call(
+		pICoreWebView2_Navigate,
+		uintptr(unsafe.Pointer(utf16PtrFromString(url))),
+	); hr != 0 {
+		return newHRESULTError("Navigate", "unable to navigate", nil, hr)
+	}
+	return nil
+}
+
+// ShowWebPopup creates a popup of the specified size next to the icon that
+// displays the page at url using WebView2, and shows it. Like other flyouts,
+// the popup is hidden when it loses focus; call Show to display it again.
+//
+// WebView2Loader.dll must be distributed with the application and the
+// WebView2 runtime must be installed; ErrWebView2Unavailable is returned
+// otherwise.
+func (w *WinTray) ShowWebPopup(url string, width, height int) (*WebPopup, error) {
+	if err := checkText("ShowWebPopup", url); err != nil {
+		return nil, err
+	}
+	f, err := w.NewFlyout(&FlyoutOptions{
+		Width:  width,
+		Height: height,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		p       = &WebPopup{Flyout: f}
+		errChan = make(chan error, 1)
+	)
+	if err := w.invoke(func() error {
+		f.resized = p.resize
+		f.destroyed = p.destroy
+		return p.create(func(err error) {
+			if err == nil {
+				err = p.navigate(url)
+			}
+			errChan <- err
+		})
+	}); err != nil {
+		f.Destroy()
+		return nil, err
+	}
+	if err := <-errChan; err != nil {
+		f.Destroy()
+		return nil, err
+	}
+	if err := f.Show(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// OnMessage registers a function to be invoked when the page posts a message
+// with window.chrome.webview.postMessage().
+func (p *WebPopup) OnMessage(fn func(msg string)) error {
+	return p.w.invoke(func() error {
+		p.onMessage = fn
+		return nil
+	})
+}
+
+// PostMessage sends a message to the page, which receives it as the data
+// property of a "message" event on window.chrome.webview.
+func (p *WebPopup) PostMessage(msg string) error {
+	if err := checkText("PostMessage", msg); err != nil {
+		return err
+	}
+	return p.w.invoke(func() error {
+		if hr := p.webview.call(
+			pICoreWebView2_PostWebMessageAsString,
+			uintptr(unsafe.Pointer(utf16PtrFromString(msg))),
+		); hr != 0 {
+			return newHRESULTError("PostMessage", "unable to post message", nil, hr)
+		}
+		return nil
+	})
+}
+
+// Navigate loads a different page in the popup.
+func (p *WebPopup) Navigate(url string) error {
+	if err := checkText("Navigate", url); err != nil {
+		return err
+	}
+	return p.w.invoke(func() error {
+		return p.navigate(url)
+	})
+}
@@ -0,0 +1,1086 @@
+//go:build windows
+
+package wintray
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	pWMAPP_NOTIFYCALLBACK = iota + win.WM_APP + 1
+	pWMAPP_MESSAGE
+)
+
+const (
+	// Limits applied to the balloon timeout by Windows XP and older
+	pMinBalloonTimeout = 10 * time.Second
+	pMaxBalloonTimeout = 30 * time.Second
+
+	// Used if the notification duration setting cannot be read
+	pDefaultMessageDuration = 5 * time.Second
+)
+
+var (
+	newIconId = atomic.Uint32{}
+
+	pWM_TASKBARCREATED = win.RegisterWindowMessage(utf16PtrFromString("TaskbarCreated"))
+
+	user32                        = windows.MustLoadDLL("User32.dll")
+	pAppendMenuW                  = user32.MustFindProc("AppendMenuW")
+	pUnregisterClassW             = user32.MustFindProc("UnregisterClassW")
+	pCheckMenuItem                = user32.MustFindProc("CheckMenuItem")
+	pSetThreadDpiAwarenessContext *windows.Proc
+)
+
+func init() {
+	p, _ := user32.FindProc("SetThreadDpiAwarenessContext")
+	if p != nil {
+		pSetThreadDpiAwarenessContext = p
+	}
+}
+
+// pPlatform holds the members of WinTray that are specific to Windows.
+type pPlatform struct {
+	hwnd     win.HWND
+	threadId uint32
+
+	// Functions registered by the application, guarded by hooksMutex
+	onClipboardChange    func()
+	onSuspend            func()
+	onResume             func()
+	onPowerSetting       map[PowerSetting]func(data []byte)
+	onDeviceChange       func(e *DeviceEvent)
+	onDisplayChange      func(width, height int)
+	onDPIChange          func(dpi int)
+	onThemeChange        func(ThemeInfo)
+	onActivate           func(args []string)
+	onCopyData           func(id uint32, data []byte)
+	onHighContrastChange func(enabled bool)
+	onFocusAssistChange  func(state FocusAssist)
+	watchingFocusAssist  bool
+	onIdle               func(idle bool)
+	onFilesDropped       func(paths []string)
+	onSessionChange      func(change SessionChange, remote bool)
+	onTimeChange         func()
+	onConnectivityChange func(online bool, kind ConnKind)
+	connectivityStop     windows.Handle
+	onAudioDeviceChange  func(e *AudioDeviceEvent)
+	onMicInUse           func(inUse bool)
+	micStop              windows.Handle
+	onCameraInUse        func(inUse bool)
+	cameraStop           windows.Handle
+	messageFilters       []MessageFilter
+
+	// These are only accessed from the UI thread
+	idleThreshold   time.Duration
+	idle            bool
+	iconId          uint32
+	notifyVersion   uint32
+	guidItem        *windows.GUID
+	hmenu           win.HMENU
+	hmenuTail       win.HMENU
+	menuPageItems   int
+	menuBreak       uint32
+	menuColumnStart int
+	hicon           win.HICON
+	hiconLarge      win.HICON
+	hbmShield       win.HBITMAP
+	headerId        uint32
+	tip             string
+	accessibleName  string
+	richTipExplicit bool
+	iconData        []byte
+	highContrast    bool
+
+	// Incremented for each notification so that a pending dismissal does not
+	// hide a newer one
+	notificationSeq uint32
+
+	// The tag of the most recent notification
+	notificationTag string
+
+	flyouts                map[*Flyout]struct{}
+	richTip                *pRichTip
+	menuHelpTip            *pRichTip
+	richTipEnabled         bool
+	flyoutClassRegistered  bool
+	promptClassRegistered  bool
+	richTipClassRegistered bool
+	comInitialized         bool
+	audioEnumerator        *pComObject
+	audioClient            *pMMNotificationClient
+	clipboardListening     bool
+	powerNotifications     map[PowerSetting]uintptr
+	deviceNotifications    map[DeviceInterfaceClass]uintptr
+
+	// Notifications are not displayed while the session is disconnected
+	sessionRegistered   bool
+	sessionDisconnected bool
+
+	// Timers and schedules are paused while the computer is suspended or the
+	// session is locked
+	suspended bool
+	locked    bool
+	away      bool
+
+	// The window placed over the icon to receive dropped files
+	dropTarget                *pDropTarget
+	dropTargetClassRegistered bool
+}
+
+// win32Backend displays the icon using a hidden window and Shell_NotifyIcon.
+type win32Backend struct {
+	w *WinTray
+}
+
+func newPlatformBackend(w *WinTray) trayBackend {
+	return &win32Backend{w: w}
+}
+
+func (b *win32Backend) create() error {
+	return b.w.create()
+}
+
+func (b *win32Backend) loop() {
+	b.w.messageLoop()
+}
+
+func (b *win32Backend) wake() {
+	win.PostMessage(b.w.hwnd, pWMAPP_MESSAGE, 0, 0)
+}
+
+func (b *win32Backend) onUIThread() bool {
+	return windows.GetCurrentThreadId() == b.w.threadId
+}
+
+func (b *win32Backend) requestClose() {
+	win.PostMessage(b.w.hwnd, win.WM_CLOSE, 0, 0)
+}
+
+func (b *win32Backend) setIcon(data []byte) error {
+	return b.w.setIcon(b.w.hwnd, b.w.iconId, data)
+}
+
+func (b *win32Backend) setTip(text string) error {
+	return b.w.setTip(text)
+}
+
+func (b *win32Backend) addMenuItem(id uint32, text string) error {
+	return b.w.addMenuItem(id, text)
+}
+
+func (b *win32Backend) addMenuSeparator() error {
+	return b.w.addMenuSeparator()
+}
+
+func (b *win32Backend) setMenuHeader(text string) error {
+	return b.w.setMenuHeader(b.w.hmenu, text)
+}
+
+func (b *win32Backend) setMenuItemText(id uint32, text string) error {
+	mii := &win.MENUITEMINFO{
+		FMask:      win.MIIM_STRING,
+		DwTypeData: utf16PtrFromString(text),
+	}
+	mii.CbSize = uint32(unsafe.Sizeof(*mii))
+	if !win.SetMenuItemInfo(b.w.hmenu, id, false, mii) {
+		return newError("SetLocale", "unable to change menu item", nil)
+	}
+	return nil
+}
+
+func (b *win32Backend) checkMenuItem(id uint32, checked bool) error {
+	var flags uint32 = win.MF_BYCOMMAND | win.MF_UNCHECKED
+	if checked {
+		flags = win.MF_BYCOMMAND | win.MF_CHECKED
+	}
+
+	// CheckMenuItem returns the previous state or -1 if there is no such item
+	if r, _, _ := pCheckMenuItem.Call(uintptr(b.w.hmenu), uintptr(id), uintptr(flags)); uint32(r) == ^uint32(0) {
+		return newErrorFrom("AddSettingMenuItem", "unable to check menu item", ErrNoMenuItem, nil)
+	}
+	return nil
+}
+
+func (b *win32Backend) removeMenuItem(id uint32) error {
+	if !win.DeleteMenu(b.w.hmenu, id, win.MF_BYCOMMAND) {
+		return newError("RemoveMenuItem", "unable to remove menu item", nil)
+	}
+	return nil
+}
+
+func (b *win32Backend) listMenuItems() []MenuItemInfo {
+	return menuItems(b.w.hmenu, 0)
+}
+
+func (b *win32Backend) showNotification(n *pDataShowNotification) error {
+	return b.w.showNotification(b.w.hwnd, b.w.iconId, n)
+}
+
+func (b *win32Backend) findMenuItem(label string) (uint32, bool) {
+	return findMenuItem(b.w.hmenu, label)
+}
+
+// findMenuItem returns the ID of the item in hmenu or its submenus with the
+// specified text.
+func findMenuItem(hmenu win.HMENU, label string) (uint32, bool) {
+	for i := int32(0); i < win.GetMenuItemCount(hmenu); i++ {
+		if sub := win.GetSubMenu(hmenu, i); sub != 0 {
+			if id, ok := findMenuItem(sub, label); ok {
+				return id, true
+			}
+			continue
+		}
+		if id := win.GetMenuItemID(hmenu, i); id != ^uint32(0) &&
+			menuItemText(hmenu, id) == label {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// simulate posts the message Windows would send for the event so that it is
+// handled once control returns to the loop.
+func (b *win32Backend) simulate(event int, id uint32) error {
+	var msg, wparam, lparam uintptr
+	switch event {
+	case pSIMULATE_MENU_ITEM:
+		msg, wparam = win.WM_COMMAND, uintptr(id)
+	case pSIMULATE_ICON_CLICK, pSIMULATE_NOTIFICATION_CLICK:
+
+		// NOTIFYICON_VERSION_4 places the anchor in wParam and the event
+		// and icon ID in lParam
+		var (
+			rc   = b.w.anchorRect()
+			x    = (rc.Left + rc.Right) / 2
+			y    = (rc.Top + rc.Bottom) / 2
+			code = pNIN_SELECT
+		)
+		if event == pSIMULATE_NOTIFICATION_CLICK {
+			code = pNIN_BALLOONUSERCLICK
+		}
+		msg = pWMAPP_NOTIFYCALLBACK
+		if b.w.notifyVersion == win.NOTIFYICON_VERSION_4 {
+			wparam = uintptr(uint16(x)) | uintptr(uint16(y))<<16
+			lparam = uintptr(code) | uintptr(b.w.iconId)<<16
+		} else {
+			wparam, lparam = uintptr(b.w.iconId), uintptr(code)
+		}
+	}
+	if win.PostMessage(b.w.hwnd, uint32(msg), wparam, lparam) == 0 {
+		return newError("Test", "unable to post message", nil)
+	}
+	return nil
+}
+
+// newError creates an Error using the calling thread's last error code. It
+// must be called immediately after the failing function returns.
+func newError(op, msg string, kind error) *Error {
+	return newErrorFrom(op, msg, kind, windows.GetLastError())
+}
+
+// newShellError creates an Error for a failed Shell_NotifyIcon call,
+// determining whether the shell is running.
+func newShellError(op, msg string) *Error {
+	err := windows.GetLastError()
+	kind := ErrShellRejected
+	if win.FindWindow(utf16PtrFromString("Shell_TrayWnd"), nil) == 0 {
+		kind = ErrShellNotRunning
+	}
+	return newErrorFrom(op, msg, kind, err)
+}
+
+// utf16FromString returns the null-terminated UTF-16 encoding of v. Unlike
+// syscall.UTF16FromString, a null character in v does not cause an error;
+// the text is cut short at that point instead, just as Windows would.
+func utf16FromString(v string) []uint16 {
+	if i := strings.IndexByte(v, 0); i != -1 {
+		v = v[:i]
+	}
+	p, _ := syscall.UTF16FromString(v)
+	return p
+}
+
+// utf16PtrFromString returns a pointer to the null-terminated UTF-16 encoding
+// of v, which is cut short at the first null character.
+func utf16PtrFromString(v string) *uint16 {
+	return &utf16FromString(v)[0]
+}
+
+// shellNotifyIcon calls Shell_NotifyIcon and logs the result. The size of
+// the structure is filled in here so that every call passes it, and icons
+// created with WithGUID are identified by the GUID.
+func (w *WinTray) shellNotifyIcon(message uint32, nid *win.NOTIFYICONDATA) bool {
+	nid.CbSize = uint32(unsafe.Sizeof(*nid))
+	if w.guidItem != nil {
+		nid.UFlags |= win.NIF_GUID
+		nid.GuidItem = *w.guidItem
+	}
+	ok := win.Shell_NotifyIcon(message, nid)
+
+	// Boxing the arguments allocates even if nothing is logged
+	if w.logger != nil {
+		w.debug("Shell_NotifyIcon", "message", message, "flags", nid.UFlags, "ok", ok)
+	}
+	return ok
+}
+
+// notifyIcon calls Shell_NotifyIcon, retrying according to the policy set by
+// WithRetry. Adding the icon is retried after any failure, since the shell
+// commonly rejects icons shortly after login while the notification area is
+// still initializing; other operations are only retried if they time out.
+func (w *WinTray) notifyIcon(op, msg string, message uint32, nid *win.NOTIFYICONDATA) error {
+	delay := w.retryDelay
+	for i := 0; ; i++ {
+		if w.shellNotifyIcon(message, nid) {
+			if message == win.NIM_MODIFY && w.State() == StateDegraded {
+				w.setState(StateReady)
+			}
+			return nil
+		}
+		err := newShellError(op, msg)
+		if i == w.retries || (message != win.NIM_ADD && !errors.Is(err, windows.ERROR_TIMEOUT)) {
+			w.logError("Shell_NotifyIcon failed", "op", op, "attempts", i+1, "err", err)
+
+			// Modifying the icon only fails for reasons other than a busy
+			// shell if the icon is no longer in the notification area
+			if message == win.NIM_MODIFY && !errors.Is(err, windows.ERROR_TIMEOUT) {
+				w.setState(StateDegraded)
+			}
+			return err
+		}
+		w.debug("retrying Shell_NotifyIcon", "op", op, "attempt", i+1, "delay", delay, "err", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// createTrayIcon adds the icon to the notification area along with the
+// current icon and tooltip, if they have been set.
+func (w *WinTray) createTrayIcon(hwnd win.HWND, iconId uint32) error {
+	nid := &win.NOTIFYICONDATA{
+		HWnd:             hwnd,
+		UID:              iconId,
+		UFlags:           win.NIF_MESSAGE,
+		UCallbackMessage: pWMAPP_NOTIFYCALLBACK,
+		HIcon:            w.hicon,
+	}
+	if w.hicon != 0 {
+		nid.UFlags |= win.NIF_ICON
+	}
+
+	// The tooltip doubles as the name announced by screen readers, so a
+	// default is used if neither has been set
+	tip := w.shellTip(w.tip)
+	if tip == "" {
+		tip = defaultAccessibleName()
+	}
+	nid.UFlags |= win.NIF_TIP
+	if !w.richTipEnabled {
+		nid.UFlags |= win.NIF_SHOWTIP
+	}
+	if copyToUint16Buffer(nid.SzTip[:], tip) {
+		w.logError("tooltip was truncated", "tip", tip)
+	}
+	if err := w.notifyIcon("New", "unable to add icon", win.NIM_ADD, nid); err != nil {
+		return err
+	}
+	w.setVersion(hwnd, iconId)
+	return nil
+}
+
+func (w *WinTray) destroyTrayIcon(hwnd win.HWND, iconId uint32) {
+	w.shellNotifyIcon(win.NIM_DELETE, &win.NOTIFYICONDATA{
+		HWnd: hwnd,
+		UID:  iconId,
+	})
+}
+
+// setVersion requests NOTIFYICON_VERSION_4 semantics for callback messages,
+// falling back to older versions if the shell does not support it (such as
+// some remote sessions). The version that was granted determines how
+// callback messages are decoded.
+func (w *WinTray) setVersion(hwnd win.HWND, iconId uint32) {
+	for _, v := range []uint32{win.NOTIFYICON_VERSION_4, win.NOTIFYICON_VERSION} {
+		if w.shellNotifyIcon(win.NIM_SETVERSION, &win.NOTIFYICONDATA{
+			HWnd:              hwnd,
+			UID:               iconId,
+			UTimeoutOrVersion: v,
+		}) {
+			w.notifyVersion = v
+			return
+		}
+	}
+	w.notifyVersion = 0
+	w.debug("using legacy notification callbacks")
+}
+
+// loadIcon loads an icon of the specified size from the contents of an ICO
+// file.
+func (w *WinTray) loadIcon(b []byte, size int32) (win.HICON, error) {
+
+	// LoadImage can only read icons from a file, so write the contents to a
+	// temporary file
+	name, err := w.writeTempIcon(b)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(name)
+
+	// Now attempt to load the icon
+	h := win.LoadImage(
+		0,
+		utf16PtrFromString(name),
+		win.IMAGE_ICON,
+		size,
+		size,
+		win.LR_LOADFROMFILE,
+	)
+	if h == 0 {
+		return 0, newError("SetIconFromBytes", "unable to load icon", ErrInvalidImage)
+	}
+
+	return win.HICON(h), nil
+}
+
+func (w *WinTray) setIcon(hwnd win.HWND, iconId uint32, b []byte) error {
+
+	// Load the icon at the correct size for the current DPI
+	hicon, err := w.loadIcon(w.displayedIcon(b), w.smallIconSize())
+	if err != nil {
+		return err
+	}
+
+	// Set the icon
+	nid := &win.NOTIFYICONDATA{
+		HWnd:   hwnd,
+		UID:    iconId,
+		UFlags: win.NIF_ICON,
+		HIcon:  hicon,
+	}
+	if err := w.notifyIcon("SetIconFromBytes", "unable to change icon", win.NIM_MODIFY, nid); err != nil {
+		win.DestroyIcon(hicon)
+		return err
+	}
+
+	// The previous icon is no longer needed; the data is kept so that the
+	// icon can be reloaded if the DPI changes
+	if w.hicon != 0 {
+		win.DestroyIcon(w.hicon)
+	}
+	w.destroyLargeIcon()
+	w.hicon = hicon
+	w.iconData = b
+
+	return nil
+}
+
+func (w *WinTray) setTip(text string) error {
+	w.richTipExplicit = false
+
+	// The standard tooltip would display the accessible name, so the text is
+	// displayed in the rich tooltip popup instead where it is supported
+	if w.accessibleName != "" && w.notifyVersion >= win.NOTIFYICON_VERSION_4 {
+		return w.enableRichTip("SetTip", text)
+	}
+	w.disableRichTip()
+	return w.setTipText("SetTip", text, false)
+}
+
+func (w *WinTray) addMenuItem(id uint32, text string) error {
+	hmenu, err := w.menuTail("AddMenuItem")
+	if err != nil {
+		return err
+	}
+	if ret, _, err := pAppendMenuW.Call(
+		uintptr(hmenu),
+		uintptr(w.menuBreak),
+		uintptr(id),
+		uintptr(unsafe.Pointer(utf16PtrFromString(text))),
+	); ret == 0 {
+		return newErrorFrom("AddMenuItem", "unable to add menu item", nil, err)
+	}
+
+	// A column break applies only to the item that follows it
+	w.menuBreak = 0
+	return nil
+}
+
+func (w *WinTray) addMenuSeparator() error {
+	hmenu, err := w.menuTail("AddMenuSeparator")
+	if err != nil {
+		return err
+	}
+	if ret, _, err := pAppendMenuW.Call(
+		uintptr(hmenu),
+		uintptr(win.MF_SEPARATOR),
+		0,
+		0,
+	); ret == 0 {
+		return newErrorFrom("AddMenuSeparator", "unable to add menu separator", nil, err)
+	}
+	return nil
+}
+
+// menuItemText returns the text of the menu item with the specified ID.
+func menuItemText(hmenu win.HMENU, id uint32) string {
+	buf := make([]uint16, 256)
+	mii := &win.MENUITEMINFO{
+		FMask:      win.MIIM_STRING,
+		DwTypeData: &buf[0],
+		Cch:        uint32(len(buf)),
+	}
+	mii.CbSize = uint32(unsafe.Sizeof(*mii))
+	if !win.GetMenuItemInfo(hmenu, id, win.FALSE, mii) {
+		return ""
+	}
+	return windows.UTF16ToString(buf)
+}
+
+// menuItems returns the items in hmenu and its submenus.
+func menuItems(hmenu win.HMENU, depth int) []MenuItemInfo {
+	var (
+		count = win.GetMenuItemCount(hmenu)
+		items []MenuItemInfo
+	)
+	for i := int32(0); i < count; i++ {
+
+		// Determine the length of the text before retrieving it
+		mii := &win.MENUITEMINFO{
+			FMask: win.MIIM_FTYPE | win.MIIM_STATE | win.MIIM_ID | win.MIIM_STRING | win.MIIM_SUBMENU,
+		}
+		mii.CbSize = uint32(unsafe.Sizeof(*mii))
+		if !win.GetMenuItemInfo(hmenu, uint32(i), win.TRUE, mii) {
+			continue
+		}
+		var label string
+		if mii.Cch > 0 {
+			buf := make([]uint16, mii.Cch+1)
+			mii.DwTypeData = &buf[0]
+			mii.Cch = uint32(len(buf))
+			win.GetMenuItemInfo(hmenu, uint32(i), win.TRUE, mii)
+			label = windows.UTF16ToString(buf)
+		}
+		item := MenuItemInfo{
+			ID:        mii.WID,
+			Label:     label,
+			Separator: mii.FType&win.MFT_SEPARATOR != 0,
+			Enabled:   mii.FState&win.MFS_DISABLED == 0,
+			Checked:   mii.FState&win.MFS_CHECKED != 0,
+			Position:  int(i),
+			Depth:     depth,
+		}
+		if item.Separator || mii.HSubMenu != 0 {
+			item.ID = 0
+		}
+		items = append(items, item)
+		if mii.HSubMenu != 0 {
+			items = append(items, menuItems(mii.HSubMenu, depth+1)...)
+		}
+	}
+	return items
+}
+
+// setMenuHeader sets the text of the disabled item at the top of the menu,
+// inserting it and a separator below it if necessary.
+func (w *WinTray) setMenuHeader(hmenu win.HMENU, text string) error {
+	mii := &win.MENUITEMINFO{
+		FMask:      win.MIIM_FTYPE | win.MIIM_STATE | win.MIIM_STRING,
+		FType:      win.MFT_STRING,
+		FState:     win.MFS_DISABLED,
+		DwTypeData: utf16PtrFromString(text),
+	}
+	mii.CbSize = uint32(unsafe.Sizeof(*mii))
+	if w.headerId != 0 {
+		if !win.SetMenuItemInfo(hmenu, w.headerId, false, mii) {
+			return newError("SetStatus", "unable to change menu item", nil)
+		}
+		return nil
+	}
+	id, err := w.newMenuId("SetStatus")
+	if err != nil {
+		return err
+	}
+	mii.FMask |= win.MIIM_ID
+	mii.WID = id
+	if !win.InsertMenuItem(hmenu, 0, true, mii) {
+		return newError("SetStatus", "unable to add menu item", nil)
+	}
+	sep := &win.MENUITEMINFO{
+		FMask: win.MIIM_FTYPE,
+		FType: win.MFT_SEPARATOR,
+	}
+	sep.CbSize = uint32(unsafe.Sizeof(*sep))
+	if !win.InsertMenuItem(hmenu, 1, true, sep) {
+		return newError("SetStatus", "unable to add menu separator", nil)
+	}
+	w.headerId = id
+	return nil
+}
+
+func (w *WinTray) showNotification(hwnd win.HWND, iconId uint32, n *pDataShowNotification) error {
+	nid := &win.NOTIFYICONDATA{
+		HWnd:   hwnd,
+		UID:    iconId,
+		UFlags: win.NIF_INFO,
+	}
+	copyToUint16Buffer(nid.SzInfo[:], n.Info)
+	copyToUint16Buffer(nid.SzInfoTitle[:], n.InfoTitle)
+
+	// Remove the previous notification with the same tag from the screen;
+	// this has no effect if it has already been dismissed
+	if n.Tag != "" && n.Tag == w.notificationTag {
+		w.hideNotification(hwnd, iconId)
+	}
+	if n.TrayIcon {
+		if hicon := w.largeIcon(); hicon != 0 {
+			nid.DwInfoFlags |= win.NIIF_USER | win.NIIF_LARGE_ICON
+			nid.HBalloonIcon = hicon
+		}
+	}
+
+	// A custom sound is played separately, so the usual one is turned off
+	if n.Sound != (Sound{}) {
+		nid.DwInfoFlags |= win.NIIF_NOSOUND
+	}
+
+	// The timeout is only honored by Windows XP and older, which clamp it to
+	// between 10 and 30 seconds
+	if n.Duration > 0 {
+		t := n.Duration
+		if t < pMinBalloonTimeout {
+			t = pMinBalloonTimeout
+		} else if t > pMaxBalloonTimeout {
+			t = pMaxBalloonTimeout
+		}
+		nid.UTimeoutOrVersion = uint32(t / time.Millisecond)
+	}
+	if err := w.notifyIcon("ShowNotification", "unable to display notification", win.NIM_MODIFY, nid); err != nil {
+		return err
+	}
+	w.notificationSeq++
+	w.notificationTag = n.Tag
+
+	// Newer versions of Windows show notifications for as long as the user
+	// has chosen in the accessibility settings, so a shorter duration is
+	// achieved by hiding the notification early
+	if n.Duration > 0 && n.Duration < messageDuration() {
+		seq := w.notificationSeq
+		time.AfterFunc(n.Duration, func() {
+			w.invoke(func() error {
+				if w.notificationSeq == seq {
+					w.hideNotification(hwnd, iconId)
+				}
+				return nil
+			})
+		})
+	}
+	reason := notificationsSuppressed()
+	if reason == "" && w.sessionDisconnected {
+		reason = "the session is disconnected"
+	}
+	if reason != "" {
+		return newErrorFrom("ShowNotification", "notification was suppressed: "+reason, ErrSuppressed, nil)
+	}
+
+	// The notification has already been shown, so failing to play the sound
+	// is reported rather than returned
+	if n.Sound != (Sound{}) && !n.Sound.silent {
+		if err := playSound("ShowNotification", n.Sound); err != nil {
+			w.reportError(err, nil)
+		}
+	}
+	return nil
+}
+
+// hideNotification removes the notification that is currently displayed.
+func (w *WinTray) hideNotification(hwnd win.HWND, iconId uint32) {
+	w.shellNotifyIcon(win.NIM_MODIFY, &win.NOTIFYICONDATA{
+		HWnd:   hwnd,
+		UID:    iconId,
+		UFlags: win.NIF_INFO,
+	})
+}
+
+// messageDuration returns how long notifications are displayed for, as set
+// in the accessibility settings.
+func messageDuration() time.Duration {
+	var secs uint32
+	if !win.SystemParametersInfo(win.SPI_GETMESSAGEDURATION, 0, unsafe.Pointer(&secs), 0) {
+		return pDefaultMessageDuration
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func (w *WinTray) showMenu(hwnd win.HWND, hmenu win.HMENU, pt *win.POINT) {
+
+	// Set the foreground window
+	win.SetForegroundWindow(hwnd)
+
+	// Get the correct alignment; menus for right-to-left languages open
+	// towards the left and are mirrored
+	var (
+		extraFlags uint32
+		rtl        = w.rtlLocale()
+	)
+	if win.GetSystemMetrics(win.SM_MENUDROPALIGNMENT) == 0 && !rtl {
+		extraFlags = win.TPM_LEFTALIGN
+	} else {
+		extraFlags = win.TPM_RIGHTALIGN
+	}
+	if rtl {
+		extraFlags |= win.TPM_LAYOUTRTL
+	}
+
+	// Position the menu against the taskbar of the monitor containing the
+	// point, whichever edge it is docked to
+	p, extraFlags, params := menuPlacement(*pt, extraFlags)
+	w.count(MetricMenuShows)
+
+	// Show the popup; TrackPopupMenuEx runs a modal loop that dispatches
+	// pWMAPP_MESSAGE, so requests from other goroutines continue to be
+	// processed while the menu is open, and posts WM_COMMAND to the window
+	// when an item is selected
+	win.TrackPopupMenuEx(
+		hmenu,
+		extraFlags,
+		p.X,
+		p.Y,
+		hwnd,
+		params,
+	)
+
+	// Posting a message forces a task switch, without which the menu may not
+	// close when the user clicks elsewhere the next time it is opened (see
+	// KB135788)
+	win.PostMessage(hwnd, win.WM_NULL, 0, 0)
+	w.hideMenuHelp()
+}
+
+func (w *WinTray) tray() *WinTray {
+	return w
+}
+
+func (w *WinTray) wndProc(hwnd win.HWND, msg uint32, wparam, lparam uintptr) uintptr {
+
+	// Offer the message to the application first
+	if handled, result := w.filterMessage(uintptr(hwnd), uintptr(msg), wparam, lparam); handled {
+		return result
+	}
+
+	// The taskbar was recreated, which removes all of the icons; this is a
+	// registered message, so it cannot be matched in the switch below
+	if pWM_TASKBARCREATED != 0 && msg == pWM_TASKBARCREATED {
+		w.taskbarCreated()
+		return 0
+	}
+
+	switch msg {
+
+	// Close was requested; destroying the window triggers WM_DESTROY
+	case win.WM_CLOSE:
+		win.DestroyWindow(hwnd)
+		return 0
+
+	// Destroy the icon and menu during shutdown and end the event loop
+	case win.WM_DESTROY:
+		for f := range w.flyouts {
+			win.DestroyWindow(f.hwnd)
+		}
+		if w.richTip != nil {
+			win.DestroyWindow(w.richTip.hwnd)
+		}
+		if w.menuHelpTip != nil {
+			win.DestroyWindow(w.menuHelpTip.hwnd)
+		}
+		if w.dropTarget != nil {
+			win.DestroyWindow(w.dropTarget.hwnd)
+		}
+		w.removeClipboardListener()
+		w.unregisterPowerNotifications()
+		w.unregisterDeviceNotifications()
+		w.unregisterSessionNotifications()
+		w.unregisterAudioNotifications()
+		w.destroyTrayIcon(hwnd, w.iconId)
+		win.DestroyMenu(w.hmenu)
+		if w.hicon != 0 {
+			win.DestroyIcon(w.hicon)
+		}
+		w.destroyLargeIcon()
+		if w.hbmShield != 0 {
+			win.DeleteObject(win.HGDIOBJ(w.hbmShield))
+		}
+		win.PostQuitMessage(0)
+		return 0
+
+	// An event occurred on the icon
+	case pWMAPP_NOTIFYCALLBACK:
+		if w.handleNotifyEvent(w.decodeNotifyEvent(wparam, lparam)) {
+			return 0
+		}
+
+	// The contents of the clipboard changed
+	case win.WM_CLIPBOARDUPDATE:
+		w.clipboardUpdated()
+		return 0
+
+	// A power management event occurred
+	case win.WM_POWERBROADCAST:
+		w.powerBroadcast(wparam, lparam)
+		return win.TRUE
+
+	// A device was added or removed
+	case win.WM_DEVICECHANGE:
+		w.deviceChange(wparam, lparam)
+		return win.TRUE
+
+	// The display resolution or monitor configuration changed
+	case win.WM_DISPLAYCHANGE:
+		w.displayChanged(lparam)
+		return 0
+
+	// The DPI of the monitor containing the window changed
+	case win.WM_DPICHANGED:
+		w.dpiChanged(wparam)
+		return 0
+
+	// The user connected to or disconnected from the session
+	case win.WM_WTSSESSION_CHANGE:
+		w.sessionChanged(wparam)
+		return 0
+
+	// The system time or time zone changed
+	case win.WM_TIMECHANGE:
+		w.timeChanged()
+		return 0
+
+	// A system setting (such as the theme) changed
+	case win.WM_SETTINGCHANGE:
+		w.settingChanged(wparam, lparam)
+		return 0
+
+	// The visual style changed, which includes switching to or from a high
+	// contrast theme
+	case win.WM_THEMECHANGED:
+		w.highContrastChanged()
+		return 0
+
+	// Another instance of the application was launched
+	case win.WM_COPYDATA:
+		if w.copyData(lparam) {
+			return win.TRUE
+		}
+
+	// A menu item was selected (including from a submenu or with
+	// Tester.ClickMenuItem)
+	case win.WM_COMMAND:
+		id := uint32(win.LOWORD(uint32(wparam)))
+		if _, ok := w.menuFns[id]; ok {
+			w.debug("menu item selected", "id", id)
+			w.itemSelected(id, menuItemText(w.hmenu, id))
+			return 0
+		}
+
+	// A styled menu item is about to be displayed
+	case win.WM_MEASUREITEM:
+		if w.measureMenuItem((*win.MEASUREITEMSTRUCT)(paramPointer(lparam))) {
+			return win.TRUE
+		}
+	case win.WM_DRAWITEM:
+		if w.drawMenuItem((*win.DRAWITEMSTRUCT)(paramPointer(lparam))) {
+			return win.TRUE
+		}
+
+	// A menu item was highlighted or the menu was closed
+	case win.WM_MENUSELECT:
+		w.menuSelect(wparam, lparam)
+		return 0
+
+	// A timer elapsed
+	case win.WM_TIMER:
+		switch wparam {
+		case pIDT_IDLE:
+			w.checkIdle()
+			return 0
+		case pIDT_DRAG:
+			w.checkDrag()
+			return 0
+		case pIDT_HEALTH:
+			w.checkHealth()
+			return 0
+		}
+
+	// Messages were queued by another thread requesting an action
+	case pWMAPP_MESSAGE:
+		w.processMessages()
+		return 0
+	}
+
+	return win.DefWindowProc(hwnd, msg, wparam, lparam)
+}
+
+// create initializes the icon and its hidden window on the current thread,
+// which must remain locked until the event loop terminates.
+func (w *WinTray) create() error {
+
+	// If we are running on Windows 10, set the thread DPI awareness,
+	// preferring per-monitor awareness where it is available (1703 and newer)
+	if pSetThreadDpiAwarenessContext != nil {
+		if r, _, _ := pSetThreadDpiAwarenessContext.Call(
+			uintptr(DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2),
+		); r == 0 {
+			pSetThreadDpiAwarenessContext.Call(
+				uintptr(DPI_AWARENESS_CONTEXT_SYSTEM_AWARE),
+			)
+		}
+	}
+
+	// Generate a unique ID for this particular tray icon and create an empty
+	// context menu (the portable state is initialized by newWinTray)
+	w.iconId = newIconId.Add(1)
+	w.hmenu = win.CreatePopupMenu()
+	w.flyouts = make(map[*Flyout]struct{})
+	w.powerNotifications = make(map[PowerSetting]uintptr)
+	w.deviceNotifications = make(map[DeviceInterfaceClass]uintptr)
+
+	// Parse the GUID and load the initial icon, which is shown as soon as the
+	// icon is added to the notification area
+	if w.guid != "" {
+		guid, err := windows.GUIDFromString(w.guid)
+		if err != nil {
+			win.DestroyMenu(w.hmenu)
+			return err
+		}
+		w.guidItem = &guid
+	}
+	w.highContrast = highContrastEnabled()
+	if w.initIcon != nil {
+		hicon, err := w.loadIcon(w.displayedIcon(w.initIcon), w.smallIconSize())
+		if err != nil {
+			win.DestroyMenu(w.hmenu)
+			return err
+		}
+		w.hicon = hicon
+		w.iconData = w.initIcon
+	}
+	w.tip = w.initTip
+
+	// Unless a name was provided, each instance registers its own class so
+	// that the name never collides with another instance or another library
+	// in the same process
+	w.threadId = windows.GetCurrentThreadId()
+	if w.className == "" {
+		w.className = fmt.Sprintf("GoWinTray_%d", w.iconId)
+	}
+	if w.windowTitle == "" {
+		w.windowTitle = "System Tray Window"
+	}
+	if err := registerClass(w.className, 0, 0); err != nil {
+		w.destroyInitialIcon()
+		win.DestroyMenu(w.hmenu)
+		return err
+	}
+
+	// Create the hidden window; this is a top-level window that is never
+	// shown (rather than a message-only window) so that it receives
+	// broadcast messages such as WM_POWERBROADCAST
+	hwnd, err := createWindow(
+		w,
+		w.className,
+		w.windowTitle,
+		win.WS_EX_TOOLWINDOW,
+		0,
+		0,
+	)
+	if err != nil {
+		unregisterClass(w.className)
+		w.destroyInitialIcon()
+		win.DestroyMenu(w.hmenu)
+		return err
+	}
+	w.hwnd = hwnd
+	w.debug("created window", "hwnd", hwnd, "class", w.className)
+
+	// Add the icon and set the version (for event handling); if this fails,
+	// destroying the window releases everything created so far
+	if err := w.createTrayIcon(hwnd, w.iconId); err != nil {
+		win.DestroyWindow(hwnd)
+		unregisterClass(w.className)
+
+		// Discard the WM_QUIT posted by WM_DESTROY so that it does not end a
+		// later message loop on this thread
+		msg := win.MSG{}
+		win.PeekMessage(&msg, 0, win.WM_QUIT, win.WM_QUIT, win.PM_REMOVE)
+		return err
+	}
+
+	if w.darkModeMenus {
+		w.enableDarkModeMenus()
+	}
+	w.startHealthCheck()
+	w.registerSessionNotifications()
+
+	return nil
+}
+
+// destroyInitialIcon releases the icon loaded by create if the window could
+// not be created.
+func (w *WinTray) destroyInitialIcon() {
+	if w.hicon != 0 {
+		win.DestroyIcon(w.hicon)
+		w.hicon = 0
+	}
+}
+
+// taskbarCreated adds the icon to the new taskbar after Explorer restarts.
+func (w *WinTray) taskbarCreated() {
+	w.debug("taskbar created")
+	if err := w.createTrayIcon(w.hwnd, w.iconId); err != nil {
+		w.setState(StateDegraded)
+		w.reportError(err, nil)
+		return
+	}
+	w.setState(StateReady)
+	w.emit(Event{Type: EventExplorerRestarted})
+}
+
+// messageLoop runs the event loop until the hidden window is destroyed.
+func (w *WinTray) messageLoop() {
+
+	// Release the classes and COM when the loop ends
+	defer func() {
+		unregisterClass(w.className)
+		if w.flyoutClassRegistered {
+			unregisterClass(w.className + "_Flyout")
+		}
+		if w.promptClassRegistered {
+			unregisterClass(w.className + "_Prompt")
+		}
+		if w.richTipClassRegistered {
+			unregisterClass(w.className + "_RichTip")
+		}
+		if w.dropTargetClassRegistered {
+			unregisterClass(w.className + "_DropTarget")
+		}
+		if w.comInitialized {
+			windows.CoUninitialize()
+		}
+	}()
+
+	// Run the event loop
+	msg := win.MSG{}
+	for win.GetMessage(&msg, 0, 0, 0) == win.TRUE {
+		win.TranslateMessage(&msg)
+		win.DispatchMessage(&msg)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package wintray_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nathan-osman/go-wintray"
+)
+
+// newWin32 creates an icon in the notification area, skipping the benchmark
+// if there is no desktop session to create it in.
+func newWin32(b *testing.B) *wintray.WinTray {
+	w := wintray.New()
+	select {
+	case <-w.Done():
+		b.Skipf("unable to create icon: %v", w.Err())
+	default:
+	}
+	return w
+}
+
+// BenchmarkSetTipWin32 exercises Shell_NotifyIcon through the real backend,
+// where no allocations should be made for logging without a logger.
+func BenchmarkSetTipWin32(b *testing.B) {
+	w := newWin32(b)
+	defer w.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := w.SetTip("Benchmark"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkShowNotificationWin32(b *testing.B) {
+	w := newWin32(b)
+	defer w.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := w.ShowNotification("Benchmark", "Title", wintray.WithTag("benchmark"))
+		if err != nil && !errors.Is(err, wintray.ErrSuppressed) {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+//go:build windows
+
+package wintray
+
+import (
+	"runtime/debug"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/nathan-osman/go-wintray/internal/win"
+	"golang.org/x/sys/windows"
+)
+
+// pWindow is implemented by each type that owns a window created by this
+// package.
+type pWindow interface {
+	wndProc(hwnd win.HWND, msg uint32, wparam, lparam uintptr) uintptr
+
+	// tray returns the icon that the window belongs to
+	tray() *WinTray
+}
+
+var (
+	hinstance = win.GetModuleHandle(nil)
+
+	// All windows share a single window procedure (callbacks created with
+	// syscall.NewCallback are never freed); messages are routed to the
+	// pWindow that owns the window
+	wndProcCallback = syscall.NewCallback(wndProc)
+	windowsMutex    sync.Mutex
+	windowsByHwnd   = make(map[win.HWND]pWindow)
+	windowsPending  = make(map[uint32]pWindow)
+)
+
+// wndProc is the window procedure shared by all windows. The first message
+// received by a new window is bound to the pWindow being created on the
+// current thread.
+func wndProc(hwnd win.HWND, msg uint32, wparam, lparam uintptr) (result uintptr) {
+	windowsMutex.Lock()
+	p, ok := windowsByHwnd[hwnd]
+	if !ok {
+		if p, ok = windowsPending[windows.GetCurrentThreadId()]; ok {
+			windowsByHwnd[hwnd] = p
+		}
+	}
+	windowsMutex.Unlock()
+	if !ok {
+		return win.DefWindowProc(hwnd, msg, wparam, lparam)
+	}
+
+	// This is the last message the window will receive
+	if msg == win.WM_NCDESTROY {
+		windowsMutex.Lock()
+		delete(windowsByHwnd, hwnd)
+		windowsMutex.Unlock()
+	}
+
+	// A panic that unwinds into Windows aborts the process, so it is
+	// recovered here and the icon is shut down instead. If the icon's own
+	// window was being destroyed, the WM_CLOSE posted by fatal can no longer
+	// be delivered, so the event loop is ended here in place of the handler
+	defer func() {
+		if r := recover(); r != nil {
+			w := p.tray()
+			w.fatal(r, debug.Stack())
+			if msg == win.WM_DESTROY && hwnd == w.hwnd {
+				win.PostQuitMessage(0)
+			}
+			result = 0
+		}
+	}()
+
+	return p.wndProc(hwnd, msg, wparam, lparam)
+}
+
+// paramPointer returns the pointer carried in a message parameter (such as
+// the lParam of WM_COPYDATA). The memory belongs to Windows, not Go.
+func paramPointer(param uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&param))
+}
+
+// registerClass registers a window class that uses the shared window
+// procedure.
+func registerClass(className string, style uint32, background win.HBRUSH) error {
+	if win.RegisterClassEx(&win.WNDCLASSEX{
+		CbSize:        uint32(unsafe.Sizeof(win.WNDCLASSEX{})),
+		Style:         style,
+		LpfnWndProc:   wndProcCallback,
+		HInstance:     hinstance,
+		HCursor:       win.LoadCursor(0, win.MAKEINTRESOURCE(win.IDC_ARROW)),
+		HbrBackground: background,
+		LpszClassName: utf16PtrFromString(className),
+	}) == 0 {
+		return newError("RegisterClass", "unable to register window class", nil)
+	}
+	return nil
+}
+
+func unregisterClass(className string) {
+	pUnregisterClassW.Call(
+		uintptr(unsafe.Pointer(utf16PtrFromString(className))),
+		uintptr(hinstance),
+	)
+}
+
+// createWindow creates a window of the specified class on the current thread
+// and routes its messages to p.
+func createWindow(
+	p pWindow,
+	className, title string,
+	exStyle, style uint32,
+	parent win.HWND,
+) (win.HWND, error) {
+	threadId := windows.GetCurrentThreadId()
+
+	// Messages sent during window creation are routed to p
+	windowsMutex.Lock()
+	windowsPending[threadId] = p
+	windowsMutex.Unlock()
+
+	hwnd := win.CreateWindowEx(
+		exStyle,
+		utf16PtrFromString(className),
+		utf16PtrFromString(title),
+		style,
+		0,
+		0,
+		0,
+		0,
+		parent,
+		0,
+		hinstance,
+		nil,
+	)
+	var err error
+	if hwnd == 0 {
+		err = newError("CreateWindow", "unable to create window", nil)
+	}
+
+	windowsMutex.Lock()
+	delete(windowsPending, threadId)
+	windowsMutex.Unlock()
+
+	return hwnd, err
+}
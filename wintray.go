@@ -1,51 +1,45 @@
 package wintray
 
 import (
-	"bytes"
+	"context"
 	"errors"
-	"io"
-	"os"
-	"reflect"
+	"image"
 	"runtime"
-	"sync/atomic"
-	"syscall"
-	"unsafe"
-
-	"github.com/lxn/win"
-	"golang.org/x/sys/windows"
+	"sync"
+	"time"
 )
 
+// DPI awareness contexts are pseudo-handles with small negative values, so they
+// are declared as the complement of a uintptr to be correct on both 32-bit and
+// 64-bit Windows.
 const (
-	DPI_AWARENESS_CONTEXT_SYSTEM_AWARE = ^uintptr(1)
-
-	pWMAPP_NOTIFYCALLBACK = iota + win.WM_APP + 1
-	pWMAPP_MESSAGE
+	DPI_AWARENESS_CONTEXT_SYSTEM_AWARE         = ^uintptr(1)
+	DPI_AWARENESS_CONTEXT_PER_MONITOR_AWARE_V2 = ^uintptr(3)
+)
 
+const (
 	pMESSAGE_SET_ICON_FROM_BYTES = iota
 	pMESSAGE_SET_TIP
 	pMESSAGE_ADD_MENU_ITEM
 	pMESSAGE_ADD_MENU_SEPARATOR
 	pMESSAGE_SHOW_NOTIFICATION
+	pMESSAGE_BATCH
+	pMESSAGE_INVOKE
 )
 
 var (
-	newIconId = atomic.Uint32{}
+	// ErrClosed is reported by Err once Close has been called.
+	ErrClosed = errors.New("tray icon has been closed")
 
-	user32                        = windows.MustLoadDLL("User32.dll")
-	pAppendMenuW                  = user32.MustFindProc("AppendMenuW")
-	pSetThreadDpiAwarenessContext *windows.Proc
+	// ErrTrayUnresponsive indicates that the UI thread did not respond in
+	// time (see WithCallTimeout and CloseWithTimeout).
+	ErrTrayUnresponsive = errors.New("tray icon is not responding")
 )
 
-func init() {
-	p, _ := user32.FindProc("SetThreadDpiAwarenessContext")
-	if p != nil {
-		pSetThreadDpiAwarenessContext = p
-	}
-}
-
 type pMessage struct {
 	Type int
 	Data any
+	Ret  chan error
 }
 
 type pDataAddMenuItem struct {
@@ -56,394 +50,788 @@ type pDataAddMenuItem struct {
 type pDataShowNotification struct {
 	Info      string
 	InfoTitle string
-}
+	Duration  time.Duration
 
-// WinTray provides a single icon in the system tray. A separate goroutine is
-// used for running all of the API functions
-type WinTray struct {
-	hwnd        win.HWND
-	messageChan chan *pMessage
-	returnChan  chan error
-	closedChan  chan any
-}
+	// OnSuppressed is run if the notification will not be displayed
+	OnSuppressed func()
 
-func mustUTF16FromString(v string) []uint16 {
-	p, err := syscall.UTF16FromString(v)
-	if err != nil {
-		panic(err)
-	}
-	return p
-}
+	// OnClick is run instead of the OnNotificationClick function if the
+	// notification is clicked
+	OnClick func()
 
-func mustUTF16PtrFromString(v string) *uint16 {
-	p, err := syscall.UTF16PtrFromString(v)
-	if err != nil {
-		panic(err)
-	}
-	return p
+	// TrayIcon shows the icon in the notification
+	TrayIcon bool
+
+	// Tag identifies notifications that replace one another
+	Tag string
+
+	// Sound is played instead of the usual sound unless it is the zero value
+	Sound Sound
 }
 
-func copyToUint16Buffer(buff any, text string) {
-	var (
-		tBuff = reflect.TypeOf(buff).Elem()
-		vBuff = reflect.ValueOf(buff).Elem()
-	)
-	for i, v := range mustUTF16FromString(text) {
-		if i == tBuff.Len() {
-			vBuff.Index(i - 1).Set(reflect.Zero(tBuff.Elem()))
-			break
-		}
-		vBuff.Index(i).Set(reflect.ValueOf(v))
+// newDataShowNotification applies opts to the data for a notification.
+func newDataShowNotification(info, infoTitle string, opts []NotificationOption) *pDataShowNotification {
+	n := &pDataShowNotification{
+		Info:      info,
+		InfoTitle: infoTitle,
 	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
-func (w *WinTray) createTrayIcon(hwnd win.HWND, iconId uint32) {
-	win.Shell_NotifyIcon(win.NIM_ADD, &win.NOTIFYICONDATA{
-		HWnd:             hwnd,
-		UID:              iconId,
-		UFlags:           win.NIF_MESSAGE,
-		UCallbackMessage: pWMAPP_NOTIFYCALLBACK,
-	})
+// trayBackend performs the platform-specific work for a WinTray. create and
+// loop run on the UI thread, as do the methods that modify, inspect or
+// simulate interaction with the icon; wake, onUIThread and requestClose may
+// be called from any goroutine.
+type trayBackend interface {
+	create() error
+	loop()
+	wake()
+	onUIThread() bool
+	requestClose()
+	setIcon(b []byte) error
+	setTip(text string) error
+	addMenuItem(id uint32, text string) error
+	addMenuSeparator() error
+	setMenuHeader(text string) error
+	showNotification(n *pDataShowNotification) error
+	findMenuItem(label string) (uint32, bool)
+	setMenuItemText(id uint32, text string) error
+	checkMenuItem(id uint32, checked bool) error
+	removeMenuItem(id uint32) error
+	listMenuItems() []MenuItemInfo
+	simulate(event int, id uint32) error
 }
 
-func (w *WinTray) destroyTrayIcon(hwnd win.HWND, iconId uint32) {
-	win.Shell_NotifyIcon(win.NIM_DELETE, &win.NOTIFYICONDATA{
-		HWnd: hwnd,
-		UID:  iconId,
-	})
+// WinTray provides a single icon in the system tray. A separate goroutine is
+// used for running all of the API functions. Multiple instances may be created
+// in the same process; each one has its own window, menu, and callbacks.
+//
+// All of the methods are safe to call concurrently from any goroutine,
+// including from callbacks. Requests are queued under a mutex and applied on
+// the UI thread in the order they were queued, so each caller receives the
+// result of its own request no matter how calls interleave. The exception is
+// a blocking method called on the UI thread itself (such as from a hook that
+// runs there), which cannot wait for the queue and so takes effect
+// immediately, ahead of any requests that are already queued; use the Async
+// variants there to keep the order.
+type WinTray struct {
+	backend     trayBackend
+	queueMutex  sync.Mutex
+	queue       []*pMessage
+	queueClosed bool
+	closedChan  chan struct{}
+	closeOnce   sync.Once
+	fatalOnce   sync.Once
+	errMutex    sync.Mutex
+	err         error
+	anchorMutex sync.Mutex
+	anchor      image.Point
+	stateMutex  sync.Mutex
+	state       State
+
+	// Set by options when the icon is created
+	darkModeMenus    bool
+	logger           Logger
+	metrics          Metrics
+	initIcon         []byte
+	initTip          string
+	guid             string
+	className        string
+	windowTitle      string
+	leftClickMenu    bool
+	menuPageSize     int
+	retries          int
+	retryDelay       time.Duration
+	statusLayout     string
+	callTimeout      time.Duration
+	tempDir          string
+	translator       Translator
+	highContrastIcon []byte
+	queueSilenced    bool
+	defaultSound     Sound
+	healthInterval   time.Duration
+
+	// These are only accessed from the UI thread
+	silenced      bool
+	silencedQueue []*pDataShowNotification
+	silencedTimer bool
+
+	// Schedules created with At and Every that have not yet finished
+	schedulesMutex  sync.Mutex
+	schedules       map[*Schedule]struct{}
+	schedulesOnHold bool
+
+	// The locale passed to the translator, guarded by localeMutex
+	localeMutex sync.Mutex
+	locale      string
+
+	// Functions registered by the application, guarded by hooksMutex
+	hooksMutex          sync.Mutex
+	onQuit              func()
+	onHandlerError      func(err error, stack []byte)
+	onFatal             func(err error, stack []byte)
+	onNotificationClick func()
+	notificationClick   func()
+	onMenuOpen          func()
+	onMenuClose         func()
+	onStateChange       func(state State)
+	events              chan Event
+	eventsClosed        bool
+
+	// These are only accessed from the UI thread; the keys are recorded so
+	// that SetLocale can translate the text again
+	menuIds     uint32
+	menuFreeIds []uint32
+	menuFns     map[uint32]func()
+	menuKeys    map[uint32]string
+	menuHelp    map[uint32]string
+	menuStyles  map[uint32]ItemStyle
+	menuSetting map[uint32]*pSettingItem
+	tipKey      string
+	tipIsKey    bool
+	statuses    map[string]*pStatus
+
+	pPlatform
 }
 
-func (w *WinTray) setVersion(hwnd win.HWND, iconId uint32) {
-	win.Shell_NotifyIcon(win.NIM_SETVERSION, &win.NOTIFYICONDATA{
-		HWnd:     hwnd,
-		UID:      iconId,
-		UVersion: win.NOTIFYICON_VERSION_4,
-	})
-}
+const (
+	// IDs below this are reserved for standard dialog commands such as IDOK
+	pMinMenuId = 100
 
-func (w *WinTray) setIcon(hwnd win.HWND, iconId uint32, b []byte) error {
+	// WM_COMMAND only carries the low 16 bits of the ID, and IDs from 0xf000
+	// upwards are used for system commands such as SC_CLOSE
+	pMaxMenuId = 0xefff
+)
 
-	// Create a temporary file with the image contents
-	f, err := os.CreateTemp("", "*.ico")
-	if err != nil {
-		return err
+// newMenuId returns an unused command ID for a menu item. The IDs of removed
+// items are only reused once every other ID has been assigned, so that a
+// stale ID held by a callback is unlikely to refer to a different item.
+func (w *WinTray) newMenuId(op string) (uint32, error) {
+	if w.menuIds <= pMaxMenuId {
+		v := w.menuIds
+		w.menuIds += 1
+		return v, nil
 	}
-	defer func() {
-		os.Remove(f.Name())
-	}()
-	io.Copy(f, bytes.NewReader(b))
-	f.Close()
-
-	// Now attempt to load the icon
-	h := win.LoadImage(
-		0,
-		mustUTF16PtrFromString(f.Name()),
-		win.IMAGE_ICON,
-		0,
-		0,
-		win.LR_DEFAULTSIZE|win.LR_LOADFROMFILE,
-	)
-	if h == 0 {
-		return errors.New("unable to load icon")
+	if n := len(w.menuFreeIds); n > 0 {
+		v := w.menuFreeIds[0]
+		w.menuFreeIds = w.menuFreeIds[1:]
+		return v, nil
 	}
+	return 0, newErrorFrom(op, "too many menu items", ErrMenuFull, nil)
+}
 
-	hicon := win.HICON(h)
+// releaseMenuId makes the ID of a removed menu item available for reuse,
+// unregistering any settings watcher bound to it so that it cannot affect
+// the item that reuses the ID.
+func (w *WinTray) releaseMenuId(id uint32) {
+	if item, ok := w.menuSetting[id]; ok {
+		item.unwatch()
+		delete(w.menuSetting, id)
+	}
+	delete(w.menuFns, id)
+	delete(w.menuKeys, id)
+	delete(w.menuHelp, id)
+	delete(w.menuStyles, id)
+	w.menuFreeIds = append(w.menuFreeIds, id)
+}
 
-	// Set the icon
-	nid := &win.NOTIFYICONDATA{
-		HWnd:   hwnd,
-		UID:    iconId,
-		UFlags: win.NIF_ICON,
-		HIcon:  hicon,
+// deliverNotification shows a notification whose text has been translated.
+func (w *WinTray) deliverNotification(d *pDataShowNotification) error {
+	err := w.backend.showNotification(d)
+	w.countNotification(err)
+	if err == nil || errors.Is(err, ErrSuppressed) {
+		w.hooksMutex.Lock()
+		w.notificationClick = d.OnClick
+		w.hooksMutex.Unlock()
 	}
-	if !win.Shell_NotifyIcon(win.NIM_MODIFY, nid) {
-		return errors.New("unable to change icon")
+	if d.OnSuppressed != nil && errors.Is(err, ErrSuppressed) {
+		go w.invokeHandler(d.OnSuppressed)
 	}
-
-	return nil
+	return err
 }
 
-func (w *WinTray) setTip(hwnd win.HWND, iconId uint32, text string) error {
-	nid := &win.NOTIFYICONDATA{
-		CbSize: uint32(unsafe.Sizeof(win.NOTIFYICONDATA{})),
-		HWnd:   hwnd,
-		UID:    iconId,
-		UFlags: win.NIF_TIP | win.NIF_SHOWTIP,
-	}
-	copyToUint16Buffer(&nid.SzTip, text)
-	if !win.Shell_NotifyIcon(win.NIM_MODIFY, nid) {
-		return errors.New("unable to change tooltip")
+// handleMessage performs the action requested by m on the UI thread.
+func (w *WinTray) handleMessage(m *pMessage) error {
+	switch m.Type {
+	case pMESSAGE_SET_ICON_FROM_BYTES:
+		return w.backend.setIcon(m.Data.([]byte))
+	case pMESSAGE_SET_TIP:
+		key := m.Data.(string)
+		text, err := normalizeText("SetTip", w.translate(key))
+		if err != nil {
+			return err
+		}
+		w.tipKey, w.tipIsKey = key, w.translator != nil
+		return w.backend.setTip(text)
+	case pMESSAGE_ADD_MENU_ITEM:
+		d := m.Data.(*pDataAddMenuItem)
+		text, err := normalizeText("AddMenuItem", w.translate(d.Text))
+		if err != nil {
+			return err
+		}
+		id, err := w.newMenuId("AddMenuItem")
+		if err != nil {
+			return err
+		}
+		w.menuFns[id] = d.Fn
+		if w.translator != nil {
+			w.menuKeys[id] = d.Text
+		}
+		return w.backend.addMenuItem(id, text)
+	case pMESSAGE_ADD_MENU_SEPARATOR:
+		return w.backend.addMenuSeparator()
+	case pMESSAGE_SHOW_NOTIFICATION:
+		d := *m.Data.(*pDataShowNotification)
+		var err error
+		if d.Info, err = normalizeText("ShowNotification", w.translate(d.Info)); err != nil {
+			return err
+		}
+		if d.InfoTitle, err = normalizeText("ShowNotification", w.translate(d.InfoTitle)); err != nil {
+			return err
+		}
+		if d.Sound == (Sound{}) {
+			d.Sound = w.defaultSound
+		}
+		if w.silenced || len(w.silencedQueue) > 0 {
+			return w.silenceNotification(&d)
+		}
+		return w.deliverNotification(&d)
+	case pMESSAGE_BATCH:
+		return w.handleBatch(m.Data.([]*pMessage))
+	case pMESSAGE_INVOKE:
+		return m.Data.(func() error)()
 	}
 	return nil
 }
 
-func (w *WinTray) addMenuItem(hmenu win.HMENU, id uint32, text string) error {
-	if ret, _, err := pAppendMenuW.Call(
-		uintptr(hmenu),
-		0,
-		uintptr(id),
-		uintptr(unsafe.Pointer(mustUTF16PtrFromString(text))),
-	); ret == 0 {
-		return err
+// handleMessageOrRelease handles the message, ensuring that the caller is not
+// left waiting for the result if handling it panics.
+func (w *WinTray) handleMessageOrRelease(m *pMessage) error {
+	defer func() {
+		if r := recover(); r != nil {
+			m.Ret <- panicError("UI thread panicked", r)
+			panic(r)
+		}
+	}()
+	return w.handleMessage(m)
+}
+
+// processMessages handles the messages in the queue until it is empty.
+// Messages are removed one at a time so that a modal loop entered while
+// handling one (such as a dialog or the context menu) can continue
+// processing the rest in order instead of leaving them blocked until it
+// ends.
+func (w *WinTray) processMessages() {
+	for {
+		w.queueMutex.Lock()
+		if len(w.queue) == 0 {
+			w.queueMutex.Unlock()
+			return
+		}
+		m := w.queue[0]
+		w.queue = w.queue[1:]
+		more := len(w.queue) != 0
+		w.queueMutex.Unlock()
+
+		// Functions run with invoke may enter a modal loop; since post only
+		// wakes the UI thread when the queue is empty, wake it now so that
+		// the modal loop picks up the remaining messages
+		if more && m.Type == pMESSAGE_INVOKE {
+			w.backend.wake()
+		}
+
+		// The check avoids allocating the arguments when logging is disabled
+		err := w.handleMessageOrRelease(m)
+		w.count(MetricMessages)
+		if w.logger != nil {
+			if err != nil {
+				w.debug("message failed", "type", messageName(m.Type), "err", err)
+			} else {
+				w.debug("message processed", "type", messageName(m.Type))
+			}
+		}
+		m.Ret <- err
 	}
-	return nil
 }
 
-func (w *WinTray) addMenuSeparator(hmenu win.HMENU) error {
-	if ret, _, err := pAppendMenuW.Call(
-		uintptr(hmenu),
-		uintptr(win.MF_SEPARATOR),
-		0,
-		0,
-	); ret == 0 {
-		return err
+// closeQueue prevents further messages from being queued and fails any that
+// are still pending.
+func (w *WinTray) closeQueue() {
+	w.queueMutex.Lock()
+	queue := w.queue
+	w.queue = nil
+	w.queueClosed = true
+	w.queueMutex.Unlock()
+	for _, m := range queue {
+		m.Ret <- ErrClosed
 	}
-	return nil
 }
 
-func (w *WinTray) showNotification(hwnd win.HWND, iconId uint32, info, infoTitle string) error {
-	nid := &win.NOTIFYICONDATA{
-		CbSize: uint32(unsafe.Sizeof(win.NOTIFYICONDATA{})),
-		HWnd:   hwnd,
-		UID:    iconId,
-		UFlags: win.NIF_INFO,
+// post queues a message for the UI thread and returns a channel that receives
+// the result. It never blocks. If m.Ret is nil, a new channel is created.
+func (w *WinTray) post(m *pMessage) <-chan error {
+	if m.Ret == nil {
+		m.Ret = make(chan error, 1)
 	}
-	copyToUint16Buffer(&nid.SzInfo, info)
-	copyToUint16Buffer(&nid.SzInfoTitle, infoTitle)
-	if !win.Shell_NotifyIcon(win.NIM_MODIFY, nid) {
-		return errors.New("unable to display notification")
+	w.queueMutex.Lock()
+	defer w.queueMutex.Unlock()
+	if w.queueClosed {
+		m.Ret <- ErrClosed
+		return m.Ret
 	}
-	return nil
+
+	// The UI thread drains the entire queue each time it is woken, so it
+	// only needs to be woken when the queue was empty
+	if len(w.queue) == 0 {
+		w.backend.wake()
+	}
+	w.queue = append(w.queue, m)
+	return m.Ret
 }
 
-func (w *WinTray) showMenu(hwnd win.HWND, hmenu win.HMENU, pt *win.POINT) uint32 {
+// retPool holds result channels for reuse by call, which avoids allocating
+// one for every request when many are made in succession (such as when
+// building a large menu).
+var retPool = sync.Pool{
+	New: func() any {
+		return make(chan error, 1)
+	},
+}
 
-	// Set the foreground window
-	win.SetForegroundWindow(hwnd)
+// call queues a message for the UI thread and waits for the result. If a
+// timeout was set with WithCallTimeout and the UI thread does not begin
+// processing the message in time, the message is withdrawn and
+// ErrTrayUnresponsive is returned. Once processing has begun, call waits for
+// it to finish, since functions run with invoke may legitimately take a long
+// time (such as a dialog waiting for the user).
+//
+// When called on the UI thread itself (such as from a callback that runs
+// there), waiting would deadlock, so the message is handled immediately
+// instead, ahead of any that are already queued.
+func (w *WinTray) call(m *pMessage) error {
+	if w.backend.onUIThread() {
+		w.queueMutex.Lock()
+		closed := w.queueClosed
+		w.queueMutex.Unlock()
+		if closed {
+			return ErrClosed
+		}
+		return w.handleMessage(m)
+	}
 
-	// Get the correct alignment
-	var extraFlags uint32
-	if win.GetSystemMetrics(win.SM_MENUDROPALIGNMENT) == 0 {
-		extraFlags = win.TPM_LEFTALIGN
-	} else {
-		extraFlags = win.TPM_RIGHTALIGN
+	if w.metrics != nil {
+		defer w.observeCall(time.Now())
 	}
 
-	// Show the popup
-	return win.TrackPopupMenu(
-		hmenu,
-		win.TPM_RETURNCMD|extraFlags,
-		pt.X,
-		pt.Y,
-		0,
-		hwnd,
-		nil,
-	)
+	// The result channel is returned to the pool once it has been drained (or
+	// the message withdrawn), since nothing else can write to it afterwards
+	m.Ret = retPool.Get().(chan error)
+	defer retPool.Put(m.Ret)
+	ret := w.post(m)
+	if w.callTimeout <= 0 {
+		return <-ret
+	}
+	t := time.NewTimer(w.callTimeout)
+	defer t.Stop()
+	select {
+	case err := <-ret:
+		return err
+	case <-t.C:
+	}
+	if w.withdraw(m) {
+		w.logError("UI thread is unresponsive", "type", messageName(m.Type), "timeout", w.callTimeout)
+		return ErrTrayUnresponsive
+	}
+	return <-ret
+}
+
+// withdraw removes a message from the queue, returning false if the UI
+// thread has already removed it for processing.
+func (w *WinTray) withdraw(m *pMessage) bool {
+	w.queueMutex.Lock()
+	defer w.queueMutex.Unlock()
+	for i, v := range w.queue {
+		if v == m {
+			w.queue = append(w.queue[:i:i], w.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
 }
 
-func (w *WinTray) run(hwndChan chan<- win.HWND) {
+// invoke runs fn on the UI thread and waits for the result.
+func (w *WinTray) invoke(fn func() error) error {
+	return w.call(&pMessage{
+		Type: pMESSAGE_INVOKE,
+		Data: fn,
+	})
+}
+
+// loop runs the event loop until the icon is closed.
+func (w *WinTray) loop() {
 
 	// Signal termination when the method ends
 	defer close(w.closedChan)
+	defer w.setState(StateClosed)
+	defer w.setErr(ErrClosed)
+	defer w.closeEvents()
+	defer w.closeQueue()
+
+	w.backend.loop()
+}
+
+func (w *WinTray) run(errChan chan<- error) {
 
 	// Lock this goroutine to an OS thread until termination
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
-	// If we are running on Windows 10, set the thread DPI awareness
-	if pSetThreadDpiAwarenessContext != nil {
-		pSetThreadDpiAwarenessContext.Call(
-			uintptr(DPI_AWARENESS_CONTEXT_SYSTEM_AWARE),
-		)
-	}
-
-	// Generate a unique ID for this particular tray icon and create an empty
-	// context menu
-	var (
-		iconId         = newIconId.Add(1)
-		hmenu          = win.CreatePopupMenu()
-		menuIds uint32 = 100
-		menuFns        = make(map[uint32]func())
-	)
-
-	newMenuId := func() (v uint32) {
-		v = menuIds
-		menuIds += 1
+	if err := w.backend.create(); err != nil {
+		w.logError("unable to create icon", "err", err)
+		w.setErr(err)
+		w.closeQueue()
+		w.closeEvents()
+		w.setState(StateClosed)
+
+		// Err only reports the error once closedChan is closed, so this must
+		// happen before New returns
+		close(w.closedChan)
+		errChan <- err
 		return
 	}
+	w.setState(StateReady)
+	close(errChan)
 
-	wndProc := func(hwnd win.HWND, msg uint32, wparam, lparam uintptr) uintptr {
-
-		switch msg {
-
-		// Initialize the icon and set the version (for event handling)
-		case win.WM_CREATE:
-			w.createTrayIcon(hwnd, iconId)
-			w.setVersion(hwnd, iconId)
-			return 0
-
-		// Destroy the icon during shutdown
-		case win.WM_QUIT:
-			w.destroyTrayIcon(hwnd, iconId)
-			return 0
-
-		// The context menu was activated
-		case pWMAPP_NOTIFYCALLBACK:
-			if win.LOWORD(uint32(lparam)) == win.WM_RBUTTONUP {
-
-				// Get the cursor position
-				pt := win.POINT{}
-				win.GetCursorPos(&pt)
-
-				// Show the menu at that position and invoke the callback for
-				// the item that is selected
-				id := w.showMenu(hwnd, hmenu, &pt)
-				if fn, ok := menuFns[id]; ok {
-					go fn()
-				}
-
-				return 0
-			}
-
-		// A message was sent from another thread requesting an action
-		case pWMAPP_MESSAGE:
-			m := <-w.messageChan
-			switch m.Type {
-			case pMESSAGE_SET_ICON_FROM_BYTES:
-				w.returnChan <- w.setIcon(hwnd, iconId, m.Data.([]byte))
-			case pMESSAGE_SET_TIP:
-				w.returnChan <- w.setTip(hwnd, iconId, m.Data.(string))
-			case pMESSAGE_ADD_MENU_ITEM:
-				var (
-					d  = m.Data.(*pDataAddMenuItem)
-					id = newMenuId()
-				)
-				menuFns[id] = d.Fn
-				w.returnChan <- w.addMenuItem(hmenu, id, d.Text)
-			case pMESSAGE_ADD_MENU_SEPARATOR:
-				w.returnChan <- w.addMenuSeparator(hmenu)
-			case pMESSAGE_SHOW_NOTIFICATION:
-				d := m.Data.(*pDataShowNotification)
-				w.returnChan <- w.showNotification(hwnd, iconId, d.Info, d.InfoTitle)
-			}
-			return 0
-		}
+	w.loop()
+}
 
-		return win.DefWindowProc(hwnd, msg, wparam, lparam)
+func newWinTray(opts []Option, newBackend func(*WinTray) trayBackend) *WinTray {
+	w := &WinTray{
+		closedChan: make(chan struct{}),
+		retries:    pDefaultRetries,
+		retryDelay: pDefaultRetryDelay,
+		menuIds:    pMinMenuId,
+		menuFns:    make(map[uint32]func()),
+		menuKeys:   make(map[uint32]string),
+		menuHelp:   make(map[uint32]string),
+		menuStyles: make(map[uint32]ItemStyle),
+
+		menuSetting: make(map[uint32]*pSettingItem),
 	}
+	w.backend = newBackend(w)
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.translator != nil && w.initTip != "" {
+		w.tipKey, w.tipIsKey = w.initTip, true
+		w.initTip = w.translate(w.initTip)
+	}
+	return w
+}
 
-	var (
-		CLASS_NAME = "WndClass"
-		hinstance  = win.GetModuleHandle(nil)
-	)
-
-	// Register the window class
-	win.RegisterClassEx(&win.WNDCLASSEX{
-		CbSize:        uint32(unsafe.Sizeof(win.WNDCLASSEX{})),
-		LpfnWndProc:   syscall.NewCallback(wndProc),
-		HInstance:     hinstance,
-		LpszClassName: mustUTF16PtrFromString(CLASS_NAME),
-	})
-
-	// Create the hidden window
-	hwndChan <- win.CreateWindowEx(
-		0,
-		mustUTF16PtrFromString(CLASS_NAME),
-		mustUTF16PtrFromString("System Tray Window"),
-		0,
-		0,
-		0,
-		0,
-		0,
-		win.HWND_MESSAGE,
-		0,
-		hinstance,
-		nil,
-	)
-	close(hwndChan)
-
-	// Run the event loop
-	msg := win.MSG{}
-	for win.GetMessage(&msg, 0, 0, 0) == win.TRUE {
-		win.TranslateMessage(&msg)
-		win.DispatchMessage(&msg)
+// setErr records the reason for shutdown; only the first reason is kept.
+func (w *WinTray) setErr(err error) {
+	w.errMutex.Lock()
+	defer w.errMutex.Unlock()
+	if w.err == nil {
+		w.err = err
 	}
 }
 
+// Supported reports whether icons can be displayed on the current platform.
+// On other platforms, the functions return ErrUnsupported (icons created
+// with NewFake work everywhere).
+func Supported() bool {
+	return runtime.GOOS == "windows"
+}
+
 // New creates a new WinTray icon.
-func New() *WinTray {
+func New(opts ...Option) *WinTray {
 	var (
-		w = &WinTray{
-			messageChan: make(chan *pMessage),
-			returnChan:  make(chan error),
-			closedChan:  make(chan any),
-		}
-		hwndChan = make(chan win.HWND)
+		w       = newWinTray(opts, newPlatformBackend)
+		errChan = make(chan error)
 	)
-	go w.run(hwndChan)
-	w.hwnd = <-hwndChan
+	go w.run(errChan)
+	<-errChan
 	return w
 }
 
+// NewWithContext creates a new WinTray icon that is closed automatically when
+// ctx is cancelled. Err reports ctx.Err() in that case.
+func NewWithContext(ctx context.Context, opts ...Option) *WinTray {
+	w := New(opts...)
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.setErr(ctx.Err())
+			w.Close()
+		case <-w.closedChan:
+		}
+	}()
+	return w
+}
+
+// Run creates a new WinTray icon and runs its event loop on the calling
+// goroutine instead of spawning a new one. This is useful when the icon must
+// live on a specific thread (such as the main thread or a COM STA thread).
+// The calling goroutine is locked to its OS thread until Run returns.
+//
+// setup is invoked on a separate goroutine once the icon has been created and
+// may use any of the API functions. Run returns once Close is called.
+func Run(setup func(*WinTray), opts ...Option) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	w := newWinTray(opts, newPlatformBackend)
+	if err := w.backend.create(); err != nil {
+		w.logError("unable to create icon", "err", err)
+		w.setErr(err)
+		w.closeQueue()
+		w.closeEvents()
+		w.setState(StateClosed)
+		close(w.closedChan)
+		return err
+	}
+	w.setState(StateReady)
+	go setup(w)
+	w.loop()
+	return nil
+}
+
 // SetIconFromBytes reads an ICO file from a byte array.
 func (w *WinTray) SetIconFromBytes(b []byte) error {
-	win.PostMessage(w.hwnd, pWMAPP_MESSAGE, 0, 0)
-	w.messageChan <- &pMessage{
+	return w.call(&pMessage{
 		Type: pMESSAGE_SET_ICON_FROM_BYTES,
 		Data: b,
-	}
-	return <-w.returnChan
+	})
 }
 
-// SetTip sets the tooltip for the icon.
+// SetTip sets the tooltip for the icon. Tooltips are limited to 127
+// characters; longer text is truncated and ErrTruncated is returned (use
+// SetRichTip for longer text). ErrInvalidText is returned if the text contains
+// a null character or is not valid UTF-8.
 func (w *WinTray) SetTip(text string) error {
-	win.PostMessage(w.hwnd, pWMAPP_MESSAGE, 0, 0)
-	w.messageChan <- &pMessage{
+	return w.call(&pMessage{
 		Type: pMESSAGE_SET_TIP,
 		Data: text,
-	}
-	return <-w.returnChan
+	})
 }
 
 // AddMenuItem adds an item to the menu that will invoke the provided function
 // when selected.
 func (w *WinTray) AddMenuItem(text string, fn func()) error {
-	win.PostMessage(w.hwnd, pWMAPP_MESSAGE, 0, 0)
-	w.messageChan <- &pMessage{
+	return w.call(&pMessage{
 		Type: pMESSAGE_ADD_MENU_ITEM,
 		Data: &pDataAddMenuItem{
 			Text: text,
 			Fn:   fn,
 		},
-	}
-	return <-w.returnChan
+	})
 }
 
 // AddMenuSeparator inserts a menu separator after the last item.
 func (w *WinTray) AddMenuSeparator() error {
-	win.PostMessage(w.hwnd, pWMAPP_MESSAGE, 0, 0)
-	w.messageChan <- &pMessage{
+	return w.call(&pMessage{
 		Type: pMESSAGE_ADD_MENU_SEPARATOR,
+	})
+}
+
+// RemoveMenuItem removes the item with the specified ID (as reported by
+// MenuItems) from the menu. Only items that run a function when selected can
+// be removed; ErrNoMenuItem is returned for any other ID.
+func (w *WinTray) RemoveMenuItem(id uint32) error {
+	return w.invoke(func() error {
+		if err := w.checkMenuId("RemoveMenuItem", id); err != nil {
+			return err
+		}
+		if err := w.backend.removeMenuItem(id); err != nil {
+			return err
+		}
+		w.releaseMenuId(id)
+		return nil
+	})
+}
+
+// SetMenuItemHelp sets text that describes the item with the specified ID
+// (as reported by MenuItems), which is shown in a popup beside the item while
+// it is highlighted. Lines are separated with "\n". Passing an empty string
+// removes the text. If a translator is set, the text is used as its key.
+func (w *WinTray) SetMenuItemHelp(id uint32, text string) error {
+	text, err := normalizeText("SetMenuItemHelp", text)
+	if err != nil {
+		return err
+	}
+	return w.invoke(func() error {
+		if err := w.checkMenuId("SetMenuItemHelp", id); err != nil {
+			return err
+		}
+		if text == "" {
+			delete(w.menuHelp, id)
+		} else {
+			w.menuHelp[id] = text
+		}
+		return nil
+	})
+}
+
+// checkMenuId returns ErrNoMenuItem unless id belongs to an item that runs a
+// function when selected.
+func (w *WinTray) checkMenuId(op string, id uint32) error {
+	if id < pMinMenuId || id > pMaxMenuId {
+		return newErrorFrom(op, "menu item ID out of range", ErrNoMenuItem, nil)
+	}
+	if _, ok := w.menuFns[id]; !ok {
+		return newErrorFrom(op, "no menu item with that ID", ErrNoMenuItem, nil)
+	}
+	return nil
+}
+
+// MenuItems returns the items currently in the context menu, in the order in
+// which they are displayed (with the items of each submenu following the item
+// that opens it). It returns nil if the icon has been closed.
+func (w *WinTray) MenuItems() []MenuItemInfo {
+	var items []MenuItemInfo
+	w.invoke(func() error {
+		items = w.backend.listMenuItems()
+		return nil
+	})
+	return items
+}
+
+// AddQuitItem adds an item to the menu that invokes the function registered
+// with OnQuit (if any) and then closes the icon.
+func (w *WinTray) AddQuitItem(text string) error {
+	return w.AddMenuItem(text, w.quit)
+}
+
+// OnQuit registers a function to be invoked when the item added by AddQuitItem
+// is selected. The function runs before the icon is closed, so it may still
+// use the API functions.
+func (w *WinTray) OnQuit(fn func()) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onQuit = fn
+}
+
+func (w *WinTray) quit() {
+	w.hooksMutex.Lock()
+	fn := w.onQuit
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		fn()
+	}
+	w.Close()
+}
+
+// OnMenuOpen registers a function to be invoked when the context menu is
+// opened, such as to refresh data or pause background work while the user is
+// looking at the menu. The function runs on its own goroutine while the menu
+// is shown, so changes it makes to the menu may not be visible until the menu
+// is next opened.
+func (w *WinTray) OnMenuOpen(fn func()) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onMenuOpen = fn
+}
+
+// OnMenuClose registers a function to be invoked when the context menu is
+// closed, whether or not an item was selected.
+func (w *WinTray) OnMenuClose(fn func()) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onMenuClose = fn
+}
+
+// OnNotificationClick registers a function to be invoked when the user clicks
+// a notification displayed with ShowNotification.
+func (w *WinTray) OnNotificationClick(fn func()) {
+	w.hooksMutex.Lock()
+	defer w.hooksMutex.Unlock()
+	w.onNotificationClick = fn
+}
+
+// notificationClicked invokes the function passed to WithClickHandler for the
+// most recent notification, or else the function registered with
+// OnNotificationClick.
+func (w *WinTray) notificationClicked() {
+	w.emit(Event{Type: EventNotificationClicked})
+	w.hooksMutex.Lock()
+	fn := w.notificationClick
+	if fn == nil {
+		fn = w.onNotificationClick
+	}
+	w.hooksMutex.Unlock()
+	if fn != nil {
+		w.invokeHandler(fn)
 	}
-	return <-w.returnChan
 }
 
 // ShowNotification displays a balloon notification with the provided message
 // and title.
-func (w *WinTray) ShowNotification(info, infoTitle string) error {
-	win.PostMessage(w.hwnd, pWMAPP_MESSAGE, 0, 0)
-	w.messageChan <- &pMessage{
+func (w *WinTray) ShowNotification(info, infoTitle string, opts ...NotificationOption) error {
+	return w.call(&pMessage{
 		Type: pMESSAGE_SHOW_NOTIFICATION,
-		Data: &pDataShowNotification{
-			Info:      info,
-			InfoTitle: infoTitle,
-		},
-	}
-	return <-w.returnChan
+		Data: newDataShowNotification(info, infoTitle, opts),
+	})
+}
+
+// requestClose asks the UI thread to shut down. Only the first request has any
+// effect.
+func (w *WinTray) requestClose() {
+	w.closeOnce.Do(func() {
+		w.setErr(ErrClosed)
+		w.setState(StateClosing)
+		w.backend.requestClose()
+	})
 }
 
-// Close removes the icon and shuts down the event loop.
+// Close removes the icon and shuts down the event loop. It is safe to call
+// Close more than once and from within menu callbacks. When called on the UI
+// thread itself, Close returns without waiting for the event loop to end.
 func (w *WinTray) Close() {
-	win.PostMessage(w.hwnd, win.WM_QUIT, 0, 0)
+	w.requestClose()
+	if w.backend.onUIThread() {
+		return
+	}
 	<-w.closedChan
 }
+
+// CloseWithTimeout is identical to Close but gives up waiting for the event
+// loop to end after the specified duration, returning ErrTrayUnresponsive.
+func (w *WinTray) CloseWithTimeout(d time.Duration) error {
+	w.requestClose()
+	if w.backend.onUIThread() {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-w.closedChan:
+		return nil
+	case <-t.C:
+		return ErrTrayUnresponsive
+	}
+}
+
+// Done returns a channel that is closed once the event loop has terminated.
+func (w *WinTray) Done() <-chan struct{} {
+	return w.closedChan
+}
+
+// Err returns nil until Done is closed. Afterwards, it returns the reason the
+// icon was shut down: ErrClosed if Close was called, the context's error if
+// the context passed to NewWithContext was cancelled, or the error that
+// prevented the icon from being created.
+func (w *WinTray) Err() error {
+	select {
+	case <-w.closedChan:
+	default:
+		return nil
+	}
+	w.errMutex.Lock()
+	defer w.errMutex.Unlock()
+	return w.err
+}
@@ -0,0 +1,235 @@
+package wintray_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nathan-osman/go-wintray"
+)
+
+// The number of items BenchmarkAddMenuItem adds to each icon, which is well
+// below the number of menu IDs available
+const pMenuItemsPerIcon = 50000
+
+// checkCallError fails the test unless err is nil or reports that the icon
+// was closed, which is expected for calls that race with Close.
+func checkCallError(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil && !errors.Is(err, wintray.ErrClosed) {
+		t.Errorf("%s: %v", op, err)
+	}
+}
+
+// waitAsync returns the result of an Async call, failing the test if it never
+// arrives.
+func waitAsync(t *testing.T, op string, c <-chan error) {
+	t.Helper()
+	select {
+	case err := <-c:
+		checkCallError(t, op, err)
+	case <-time.After(5 * time.Second):
+		t.Errorf("%s: no result", op)
+	}
+}
+
+// TestConcurrentCalls makes calls from many goroutines at once, including
+// while the icon is being closed; run it with -race.
+func TestConcurrentCalls(t *testing.T) {
+	const (
+		goroutines = 8
+		calls      = 200
+	)
+	w, _ := wintray.NewFake()
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+	)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			<-start
+			for i := 0; i < calls; i++ {
+				text := fmt.Sprintf("%d-%d", g, i)
+				checkCallError(t, "SetTip", w.SetTip(text))
+				checkCallError(t, "AddMenuItem", w.AddMenuItem(text, func() {}))
+				checkCallError(t, "ShowNotification", w.ShowNotification(text, "Title"))
+				waitAsync(t, "SetTipAsync", w.SetTipAsync(text))
+				waitAsync(t, "AddMenuItemAsync", w.AddMenuItemAsync(text, func() {}))
+				waitAsync(t, "AddMenuSeparatorAsync", w.AddMenuSeparatorAsync())
+				waitAsync(t, "ShowNotificationAsync", w.ShowNotificationAsync(text, "Title"))
+			}
+		}(g)
+	}
+
+	// Close part way through so that calls race with the shutdown
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-start
+		time.Sleep(10 * time.Millisecond)
+		w.Close()
+	}()
+	close(start)
+	wg.Wait()
+	select {
+	case <-w.Done():
+	default:
+		t.Fatal("icon was not closed")
+	}
+	if err := w.SetTip("closed"); !errors.Is(err, wintray.ErrClosed) {
+		t.Fatalf("SetTip after Close returned %v", err)
+	}
+}
+
+// TestConcurrentCallsWithoutClose checks that every call made concurrently
+// takes effect.
+func TestConcurrentCallsWithoutClose(t *testing.T) {
+	const (
+		goroutines = 8
+		calls      = 50
+	)
+	w, f := wintray.NewFake()
+	defer w.Close()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				text := fmt.Sprintf("%d-%d", g, i)
+				if err := w.AddMenuItem(text, func() {}); err != nil {
+					t.Error(err)
+				}
+				waitAsync(t, "AddMenuItemAsync", w.AddMenuItemAsync(text+"-async", func() {}))
+			}
+		}(g)
+	}
+	wg.Wait()
+	if n := len(f.MenuItems()); n != goroutines*calls*2 {
+		t.Fatalf("menu has %d items, want %d", n, goroutines*calls*2)
+	}
+}
+
+// TestBatchError checks that errors.Is and errors.As find the individual
+// errors in a BatchError.
+func TestBatchError(t *testing.T) {
+	w, f := wintray.NewFake()
+	defer w.Close()
+	f.SetSuppressed(true)
+	err := w.Batch(func(b *wintray.Batch) {
+		b.SetTip("Tip")
+		b.AddMenuItem("a\x00b", nil)
+		b.ShowNotification("Info", "Title")
+	})
+	var batchErr *wintray.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 2 {
+		t.Fatalf("got %v, want a BatchError with 2 errors", err)
+	}
+	for _, target := range []error{wintray.ErrInvalidText, wintray.ErrSuppressed} {
+		if !errors.Is(err, target) {
+			t.Errorf("errors.Is(%v, %v) = false", err, target)
+		}
+	}
+	if errors.Is(err, wintray.ErrClosed) {
+		t.Errorf("errors.Is(%v, ErrClosed) = true", err)
+	}
+	var wintrayErr *wintray.Error
+	if !errors.As(err, &wintrayErr) || !errors.Is(wintrayErr, wintray.ErrInvalidText) {
+		t.Errorf("errors.As found %v, want the ErrInvalidText error", wintrayErr)
+	}
+}
+
+// checkDisplayText fails the test unless text could have been produced by
+// normalizing application text.
+func checkDisplayText(t *testing.T, op, text string) {
+	t.Helper()
+	if !utf8.ValidString(text) {
+		t.Fatalf("%s: %q is not valid UTF-8", op, text)
+	}
+	for _, r := range text {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			t.Fatalf("%s: %q contains a control character", op, text)
+		}
+	}
+}
+
+// FuzzFake passes arbitrary text to the functions that display it and checks
+// that the text reaching the backend has been normalized.
+func FuzzFake(f *testing.F) {
+	for _, s := range []string{"", "hello", "a\r\nb", "\u202eabc", "a\x00b", "a\xffb"} {
+		f.Add(s, s)
+	}
+	w, fake := wintray.NewFake()
+	f.Cleanup(w.Close)
+	f.Fuzz(func(t *testing.T, text, title string) {
+		check := func(op string, err error) bool {
+			t.Helper()
+			if err != nil && !errors.Is(err, wintray.ErrInvalidText) {
+				t.Fatalf("%s: %v", op, err)
+			}
+			return err == nil
+		}
+		if check("SetTip", w.SetTip(text)) {
+			checkDisplayText(t, "SetTip", fake.Tip())
+		}
+
+		// A long run can use every menu ID
+		if err := w.AddMenuItem(text, nil); !errors.Is(err, wintray.ErrMenuFull) && check("AddMenuItem", err) {
+			items := fake.MenuItems()
+			checkDisplayText(t, "AddMenuItem", items[len(items)-1].Text)
+		}
+		if check("ShowNotification", w.ShowNotification(text, title)) {
+			notifications := fake.Notifications()
+			n := notifications[len(notifications)-1]
+			checkDisplayText(t, "ShowNotification", n.Info)
+			checkDisplayText(t, "ShowNotification", n.InfoTitle)
+		}
+	})
+}
+
+func BenchmarkSetTip(b *testing.B) {
+	w, _ := wintray.NewFake()
+	defer w.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := w.SetTip("Benchmark"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAddMenuItem(b *testing.B) {
+	w, _ := wintray.NewFake()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+
+		// Replace the icon before the menu runs out of IDs
+		if i > 0 && i%pMenuItemsPerIcon == 0 {
+			b.StopTimer()
+			w.Close()
+			w, _ = wintray.NewFake()
+			b.StartTimer()
+		}
+		if err := w.AddMenuItem("Benchmark", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+	w.Close()
+}
+
+func BenchmarkShowNotification(b *testing.B) {
+	w, _ := wintray.NewFake()
+	defer w.Close()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := w.ShowNotification("Benchmark", "Title", wintray.WithTag("benchmark")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}